@@ -0,0 +1,121 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Define the `ec controller` command
+package controller
+
+import (
+	"fmt"
+	"time"
+
+	hd "github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+
+	"github.com/enterprise-contract/ec-cli/internal/controller"
+	"github.com/enterprise-contract/ec-cli/internal/image"
+	"github.com/enterprise-contract/ec-cli/internal/kubernetes"
+	"github.com/enterprise-contract/ec-cli/internal/policy"
+)
+
+var ControllerCmd *cobra.Command
+
+func init() {
+	ControllerCmd = controllerCmd(image.ValidateImage)
+}
+
+func controllerCmd(validate controller.ValidateFunc) *cobra.Command {
+	opts := controller.Options{
+		EffectiveTime: policy.Now,
+		Workers:       1,
+		ResyncPeriod:  10 * time.Minute,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "controller",
+		Short: "Validate the Snapshots created in a Kubernetes cluster",
+
+		Long: hd.Doc(fmt.Sprintf(`
+			Validate the Snapshots created in a Kubernetes cluster.
+
+			Watch the Snapshot resources, optionally only those in one namespace and matching a
+			label selector, and validate the images of the components of each new Snapshot,
+			or of each new generation of a Snapshot, against its EnterpriseContractPolicy. The
+			policy is given by the --policy flag as [namespace/]name or, when the flag is not
+			set, by the %[1]s annotation of the Snapshot as [namespace/]name.
+			The annotation can only reference policies in the namespace of the Snapshot.
+			Policies without a namespace are looked up in the namespace of the Snapshot.
+
+			The outcome is recorded as the %[2]s status condition of the
+			Snapshot, which is true when all of the components pass and false otherwise, with
+			the %[3]s, %[4]s or %[5]s reason and a summary message. A Kubernetes Event
+			with the same reason and message is created for the Snapshot as well. Validations
+			with the %[5]s reason, e.g. when an image cannot be fetched, are retried.
+
+			The policy configuration not part of the EnterpriseContractPolicy API, e.g.
+			exceptions, componentPolicies, trustedRoot, tsaCertChain and the rulePrecedence,
+			ruleNamespaces, blobSizeLimit, documentationUrl and codePrefixes options of the
+			source groups, is read from the EnterpriseContractPolicy resource as well. It is only
+			available if the EnterpriseContractPolicy CRD of the cluster keeps fields it does not
+			define, otherwise the cluster removes it and the Snapshots are validated without it.
+
+			The command runs until interrupted. It needs permission to watch Snapshots, update
+			their status, read EnterpriseContractPolicies and create Events.
+		`, controller.PolicyAnnotation, controller.ConditionType, controller.ReasonPassed, controller.ReasonFailed, controller.ReasonError)),
+
+		Example: hd.Doc(`
+			Validate the Snapshots in the "release" namespace labeled for release against the
+			"release-policy" policy of their namespace:
+
+			  ec controller --namespace release --selector ready-for-release=true \
+			    --policy release-policy
+		`),
+
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			client, err := kubernetes.NewDynamicClient(ctx)
+			if err != nil {
+				return fmt.Errorf("cannot initialize Kubernetes client: %w", err)
+			}
+
+			c, err := controller.New(client, validate, opts)
+			if err != nil {
+				return err
+			}
+
+			return c.Run(ctx)
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.Namespace, "namespace", "n", opts.Namespace, "namespace of the Snapshots to validate, all namespaces if not set")
+	cmd.Flags().StringVarP(&opts.LabelSelector, "selector", "l", opts.LabelSelector, "label selector of the Snapshots to validate, e.g. key=value")
+	cmd.Flags().StringVarP(&opts.Policy, "policy", "p", opts.Policy, hd.Doc(fmt.Sprintf(`
+		EnterpriseContractPolicy, as [namespace/]name, for all Snapshots. When not set, the
+		policy is given by the %s annotation of each Snapshot`, controller.PolicyAnnotation)))
+	cmd.Flags().StringVar(&opts.EffectiveTime, "effective-time", opts.EffectiveTime, hd.Doc(`
+		Run policy checks with the provided time. Useful for testing rules with
+		effective dates in the future. The value can be "now" (default) - for
+		current time, "attestation" - for time from the youngest attestation, or
+		a RFC3339 formatted value, e.g. 2022-11-18T00:00:00Z.
+	`))
+	cmd.Flags().IntVar(&opts.Workers, "workers", opts.Workers, "number of Snapshots validated concurrently")
+	cmd.Flags().DurationVar(&opts.ResyncPeriod, "resync", opts.ResyncPeriod, hd.Doc(`
+		how often all Snapshots are reconsidered, each generation of a Snapshot is validated once
+		unless the outcome is an error`))
+
+	return cmd
+}
@@ -0,0 +1,109 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unit
+
+package controller
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	ecc "github.com/enterprise-contract/enterprise-contract-controller/api/v1alpha1"
+	app "github.com/redhat-appstudio/application-api/api/v1alpha1"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+
+	"github.com/enterprise-contract/ec-cli/cmd/root"
+	"github.com/enterprise-contract/ec-cli/internal/controller"
+	"github.com/enterprise-contract/ec-cli/internal/kubernetes"
+	"github.com/enterprise-contract/ec-cli/internal/output"
+	"github.com/enterprise-contract/ec-cli/internal/policy"
+	"github.com/enterprise-contract/ec-cli/internal/utils"
+)
+
+func setUpCobra(command *cobra.Command) *cobra.Command {
+	cmd := root.NewRootCmd()
+	cmd.AddCommand(command)
+	return cmd
+}
+
+func TestController(t *testing.T) {
+	utils.SetTestRekorPublicKey(t)
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, ecc.AddToScheme(scheme))
+	require.NoError(t, app.AddToScheme(scheme))
+	require.NoError(t, corev1.AddToScheme(scheme))
+	client := fake.NewSimpleDynamicClient(scheme,
+		&ecc.EnterpriseContractPolicy{
+			TypeMeta:   v1.TypeMeta{Kind: "EnterpriseContractPolicy", APIVersion: ecc.GroupVersion.String()},
+			ObjectMeta: v1.ObjectMeta{Name: "policy", Namespace: "test"},
+			Spec:       ecc.EnterpriseContractPolicySpec{PublicKey: utils.TestPublicKey},
+		},
+		&app.Snapshot{
+			TypeMeta:   v1.TypeMeta{Kind: "Snapshot", APIVersion: app.GroupVersion.String()},
+			ObjectMeta: v1.ObjectMeta{Name: "snapshot", Namespace: "test"},
+			Spec: app.SnapshotSpec{Components: []app.SnapshotComponent{
+				{Name: "a", ContainerImage: "registry.io/repository/a"},
+			}},
+		},
+	)
+
+	validate := func(_ context.Context, comp app.SnapshotComponent, _ policy.Policy, _ bool) (*output.Output, error) {
+		return &output.Output{ImageURL: comp.ContainerImage}, nil
+	}
+
+	cmd := setUpCobra(controllerCmd(validate))
+	ctx, cancel := context.WithCancel(kubernetes.WithDynamicClient(context.Background(), client))
+	defer cancel()
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetArgs([]string{"controller", "--namespace", "test", "--policy", "policy"})
+
+	done := make(chan error)
+	go func() {
+		done <- cmd.ExecuteContext(ctx)
+	}()
+
+	assert.Eventually(t, func() bool {
+		u, err := client.Resource(app.GroupVersion.WithResource("snapshots")).Namespace("test").Get(ctx, "snapshot", v1.GetOptions{})
+		require.NoError(t, err)
+		conditions, _, _ := unstructured.NestedSlice(u.Object, "status", "conditions")
+		return len(conditions) == 1 && conditions[0].(map[string]any)["type"] == controller.ConditionType &&
+			conditions[0].(map[string]any)["status"] == "True"
+	}, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	assert.NoError(t, <-done)
+}
+
+func TestControllerInvalidFlags(t *testing.T) {
+	cmd := setUpCobra(controllerCmd(nil))
+	ctx := kubernetes.WithDynamicClient(context.Background(), fake.NewSimpleDynamicClient(runtime.NewScheme()))
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs([]string{"controller", "--workers", "0"})
+
+	assert.EqualError(t, cmd.ExecuteContext(ctx), "invalid number of workers 0, expecting at least 1")
+}
@@ -20,33 +20,61 @@ package initialize
 import (
 	"fmt"
 	"path/filepath"
+	"strings"
 
 	hd "github.com/MakeNowJust/heredoc"
 	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
 
+	"github.com/enterprise-contract/ec-cli/internal/policy/scaffold"
 	"github.com/enterprise-contract/ec-cli/internal/utils"
 )
 
 func initPoliciesCmd() *cobra.Command {
-	var destDir string
+	var (
+		destDir      string
+		templateName string
+		force        bool
+	)
 
 	cmd := &cobra.Command{
 		Use:   "policies --dest-dir <directory-url>",
-		Short: "Initialize a directory with minimal EC scaffolding",
+		Short: "Initialize a directory with EC policy repository scaffolding",
 
 		Long: hd.Doc(`
-			This command creates the necessary files for a minimal EC policy setup in the
-			specified destination directory.
+			This command creates the files of a policy repository in the specified destination
+			directory:
+
+			  * policy/release and policy/pipeline with annotated rules and their tests
+			  * policy/lib with helpers shared by the rules
+			  * data/rule_data.yml with the data the rules are configured with
+			  * policy.yaml, an EnterpriseContractPolicy using the release rules
+			  * policy-input.json, a sample input the rules are satisfied with
+			  * README.md describing how to test the rules and validate the sample input
+
+			The template selects the rules created. The default template checks image labels,
+			the sbom template checks the CycloneDX SBOM attested for an image, and the tekton
+			template checks the SLSA provenance attested by Tekton Chains and the task
+			bundles of pipeline definitions.
+
+			The policy configuration refers to the policy and data directories by their
+			absolute path. Replace them with the URL of the repository once it is published.
+			Existing files are not overwritten unless --force is used. If no destination
+			directory is specified, the files are written to stdout.
 
 			More information about authoring policies is available in the EC documentation:
 			https://enterprisecontract.dev/docs/ec-policies/authoring.html
 		`),
 
 		Example: hd.Doc(`
-			Initialize the "my-policy" directory with minimal EC policy scaffolding:
+			Initialize the "my-policy" directory with EC policy repository scaffolding:
 
 			  ec init policies --dest-dir my-policy
+
+			Initialize the "my-policy" directory with rules checking the SBOM of images:
+
+			  ec init policies --dest-dir my-policy --template sbom
 		`),
 
 		Args: cobra.NoArgs,
@@ -55,47 +83,62 @@ func initPoliciesCmd() *cobra.Command {
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			ctx := cmd.Context()
-			samplePolicy := hd.Doc(`
-				# Simplest never-failing policy
-				package main
-
-				# METADATA
-				# title: Allow rule
-				# description: This rule will never fail
-				# custom:
-				#   short_name: acceptor
-				#   failure_msg: Always succeeds
-				#   solution: Easy
-				#   collections:
-				#   - A
-				deny[result] {
-					false
-					result := "Never denies"
-				}
-			`)
-			if destDir == "" {
-				fmt.Fprintf(cmd.OutOrStdout(), "%s", samplePolicy)
-				return nil
+
+			dir := destDir
+			if dir == "" {
+				dir = "."
 			}
-			fs := utils.FS(ctx)
-			workDir := destDir
-			err := fs.MkdirAll(workDir, 0755)
+			absDir, err := filepath.Abs(dir)
 			if err != nil {
-				log.Debug("Failed to create policy directory!")
 				return err
 			}
-			policyPath := filepath.Join(workDir, "sample.rego")
-			file, err := fs.Create(policyPath)
+
+			files, err := scaffold.Files(scaffold.Params{
+				Name:     scaffold.Name(absDir),
+				Dir:      filepath.ToSlash(absDir),
+				Template: templateName,
+			})
 			if err != nil {
-				log.Debug("Failed to create sample policy!")
 				return err
 			}
-			defer file.Close()
-			fmt.Fprintf(file, "%s", samplePolicy)
+
+			if destDir == "" {
+				for _, f := range files {
+					fmt.Fprintf(cmd.OutOrStdout(), "# File: %s\n\n%s\n", f.Path, f.Content)
+				}
+				return nil
+			}
+
+			fs := utils.FS(ctx)
+			if !force {
+				for _, f := range files {
+					p := filepath.Join(destDir, f.Path)
+					if exists, err := afero.Exists(fs, p); err != nil {
+						return err
+					} else if exists {
+						return fmt.Errorf("file %s already exists, use --force to overwrite it", p)
+					}
+				}
+			}
+
+			for _, f := range files {
+				p := filepath.Join(destDir, f.Path)
+				if err := fs.MkdirAll(filepath.Dir(p), 0755); err != nil {
+					log.Debug("Failed to create policy directory!")
+					return err
+				}
+				if err := afero.WriteFile(fs, p, f.Content, 0644); err != nil {
+					log.Debugf("Failed to create %s!", p)
+					return err
+				}
+			}
+
 			return nil
 		},
 	}
 
 	cmd.Flags().StringVarP(&destDir, "dest-dir", "d", "", "Directory to use when creating EC policy scaffolding. If not specified stdout will be used.")
+	cmd.Flags().StringVarP(&templateName, "template", "t", scaffold.DefaultTemplate, fmt.Sprintf("Template of the rules to create. One of: %s", strings.Join(scaffold.Templates(), ", ")))
+	cmd.Flags().BoolVar(&force, "force", false, "Overwrite existing files in the destination directory.")
 	return cmd
 }
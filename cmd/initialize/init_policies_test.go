@@ -21,11 +21,15 @@ package initialize
 import (
 	"bytes"
 	"context"
+	"fmt"
+	"path"
+	"path/filepath"
 	"testing"
 
 	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/enterprise-contract/ec-cli/cmd/root"
 	"github.com/enterprise-contract/ec-cli/internal/utils"
@@ -52,7 +56,7 @@ func TestInitializeNoError(t *testing.T) {
 	assert.NoError(t, err)
 }
 
-func TestInitializeSamplePolicy(t *testing.T) {
+func TestInitializePolicyRepository(t *testing.T) {
 	fs := afero.NewMemMapFs()
 	ctx := utils.WithFS(context.Background(), fs)
 
@@ -71,11 +75,124 @@ func TestInitializeSamplePolicy(t *testing.T) {
 
 	err := cmd.Execute()
 	assert.NoError(t, err)
-	samplePolicy, err := afero.ReadFile(fs, "sample/sample.rego")
-	if err != nil {
-		t.Fatal(err)
+
+	for _, f := range []string{
+		"README.md",
+		"data/rule_data.yml",
+		"policy-input.json",
+		"policy/lib/lib.rego",
+		"policy/lib/lib_test.rego",
+		"policy/pipeline/required_tasks.rego",
+		"policy/pipeline/required_tasks_test.rego",
+		"policy/release/labels.rego",
+		"policy/release/labels_test.rego",
+	} {
+		exists, err := afero.Exists(fs, path.Join("sample", f))
+		assert.NoError(t, err)
+		assert.True(t, exists, "%s not created", f)
 	}
-	assert.Contains(t, string(samplePolicy), "Simplest never-failing policy")
+
+	dir, err := filepath.Abs("sample")
+	require.NoError(t, err)
+	policyYAML, err := afero.ReadFile(fs, "sample/policy.yaml")
+	require.NoError(t, err)
+	assert.Contains(t, string(policyYAML), "  name: sample\n")
+	assert.Contains(t, string(policyYAML), fmt.Sprintf("    - %s/policy/release\n", dir))
+}
+
+func TestInitializeTemplate(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	ctx := utils.WithFS(context.Background(), fs)
+
+	initPoliciesCmd := initPoliciesCmd()
+	cmd := setUpCobra(initPoliciesCmd)
+	cmd.SetContext(ctx)
+	cmd.SetOut(new(bytes.Buffer))
+
+	cmd.SetArgs([]string{
+		"init",
+		"policies",
+		"--dest-dir",
+		"sample",
+		"--template",
+		"sbom",
+	})
+
+	err := cmd.Execute()
+	assert.NoError(t, err)
+
+	sbom, err := afero.ReadFile(fs, "sample/policy/release/sbom.rego")
+	require.NoError(t, err)
+	assert.Contains(t, string(sbom), "package policy.release.sbom")
+
+	exists, err := afero.Exists(fs, "sample/policy/release/labels.rego")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestInitializeUnknownTemplate(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	ctx := utils.WithFS(context.Background(), fs)
+
+	initPoliciesCmd := initPoliciesCmd()
+	cmd := setUpCobra(initPoliciesCmd)
+	cmd.SetContext(ctx)
+	cmd.SetOut(new(bytes.Buffer))
+	cmd.SetErr(new(bytes.Buffer))
+
+	cmd.SetArgs([]string{
+		"init",
+		"policies",
+		"--template",
+		"nope",
+	})
+
+	err := cmd.Execute()
+	assert.EqualError(t, err, `unknown template "nope", available templates: default, sbom, tekton`)
+}
+
+func TestInitializeExistingFiles(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	ctx := utils.WithFS(context.Background(), fs)
+	require.NoError(t, afero.WriteFile(fs, "sample/README.md", []byte("existing"), 0644))
+
+	cmd := setUpCobra(initPoliciesCmd())
+	cmd.SetContext(ctx)
+	cmd.SetOut(new(bytes.Buffer))
+	cmd.SetErr(new(bytes.Buffer))
+
+	cmd.SetArgs([]string{
+		"init",
+		"policies",
+		"--dest-dir",
+		"sample",
+	})
+
+	err := cmd.Execute()
+	assert.EqualError(t, err, "file sample/README.md already exists, use --force to overwrite it")
+
+	exists, err := afero.Exists(fs, "sample/policy.yaml")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	cmd = setUpCobra(initPoliciesCmd())
+	cmd.SetContext(ctx)
+	cmd.SetOut(new(bytes.Buffer))
+
+	cmd.SetArgs([]string{
+		"init",
+		"policies",
+		"--dest-dir",
+		"sample",
+		"--force",
+	})
+
+	err = cmd.Execute()
+	assert.NoError(t, err)
+
+	readme, err := afero.ReadFile(fs, "sample/README.md")
+	require.NoError(t, err)
+	assert.NotEqual(t, "existing", string(readme))
 }
 
 func TestInitializeStdOut(t *testing.T) {
@@ -95,7 +212,8 @@ func TestInitializeStdOut(t *testing.T) {
 
 	err := cmd.Execute()
 	assert.NoError(t, err)
-	assert.Contains(t, buffy.String(), "Simplest never-failing policy")
+	assert.Contains(t, buffy.String(), "# File: policy/lib/lib.rego\n\n")
+	assert.Contains(t, buffy.String(), "# File: policy/release/labels.rego\n\n")
 }
 
 func setUpCobra(command *cobra.Command) *cobra.Command {
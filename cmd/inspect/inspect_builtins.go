@@ -0,0 +1,128 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Define the `ec inspect builtins` command
+package inspect
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	hd "github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+	"golang.org/x/exp/slices"
+
+	"github.com/enterprise-contract/ec-cli/internal/evaluator"
+)
+
+func inspectBuiltinsCmd() *cobra.Command {
+	var outputFormat string
+
+	validFormats := []string{"text", "json", "capabilities"}
+
+	cmd := &cobra.Command{
+		Use:   "builtins",
+		Short: "List the rego functions provided by ec",
+
+		Long: hd.Doc(`
+			List the rego functions provided by ec.
+
+			In addition to the OPA built-in functions, policy rules evaluated by ec can use
+			the functions listed by this command. For each function, its arguments and
+			result are listed with their types and descriptions. A memoized function is
+			evaluated only once for the same arguments during a policy evaluation. A
+			non-deterministic function relies on external entities, e.g. an OCI registry, so
+			its result can vary between evaluations.
+
+			The capabilities output format prints the OPA capabilities policies are evaluated
+			with. These include the functions provided by ec, and exclude the OPA built-in
+			functions ec does not allow, e.g. http.send. Use them to have editors, or the
+			'opa check --capabilities' command, understand the policy rules written for ec.
+		`),
+
+		Example: hd.Doc(`
+			List the rego functions provided by ec:
+
+			  ec inspect builtins
+
+			Check policy rules using the capabilities of ec:
+
+			  ec inspect builtins -o capabilities > capabilities.json
+			  opa check --capabilities capabilities.json policy/
+		`),
+
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !slices.Contains(validFormats, outputFormat) {
+				return fmt.Errorf("invalid value for --output '%s'. accepted values: %s", outputFormat, strings.Join(validFormats, ", "))
+			}
+
+			out := cmd.OutOrStdout()
+			switch outputFormat {
+			case "json":
+				return json.NewEncoder(out).Encode(evaluator.Builtins())
+			case "capabilities":
+				capabilities, err := evaluator.Capabilities(cmd.Context())
+				if err != nil {
+					return err
+				}
+				_, err = fmt.Fprintln(out, capabilities)
+				return err
+			default:
+				return outputBuiltinsText(out, evaluator.Builtins())
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "text", fmt.Sprintf("output format. one of: %s", strings.Join(validFormats, ", ")))
+
+	return cmd
+}
+
+func outputBuiltinsText(out io.Writer, builtins []evaluator.Builtin) error {
+	for i, b := range builtins {
+		if i > 0 {
+			fmt.Fprintln(out)
+		}
+
+		args := make([]string, 0, len(b.Args))
+		for _, a := range b.Args {
+			args = append(args, fmt.Sprintf("%s: %s", a.Name, a.Type))
+		}
+		fmt.Fprintf(out, "%s(%s) => %s: %s\n", b.Name, strings.Join(args, ", "), b.Result.Name, b.Result.Type)
+
+		for _, a := range append(b.Args, b.Result) {
+			fmt.Fprintf(out, "  %s: %s\n", a.Name, a.Description)
+		}
+
+		var flags []string
+		if b.Memoize {
+			flags = append(flags, "memoized")
+		}
+		if b.Nondeterministic {
+			flags = append(flags, "non-deterministic")
+		} else {
+			flags = append(flags, "deterministic")
+		}
+		if _, err := fmt.Fprintf(out, "  %s\n", strings.Join(flags, ", ")); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unit
+
+package inspect
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/enterprise-contract/ec-cli/internal/evaluator"
+)
+
+func TestInspectBuiltins(t *testing.T) {
+	cmd := setUpCobra(inspectBuiltinsCmd())
+	cmd.SetContext(context.Background())
+	buffy := bytes.Buffer{}
+	cmd.SetOut(&buffy)
+
+	cmd.SetArgs([]string{"inspect", "builtins"})
+	require.NoError(t, cmd.Execute())
+
+	assert.Contains(t, buffy.String(), `ec.oci.blob(ref: string) => blob: string
+  ref: OCI blob reference
+  blob: the OCI blob
+  memoized, non-deterministic
+`)
+	assert.Contains(t, buffy.String(), "ec.purl.parse(purl: string) => object: object<")
+}
+
+func TestInspectBuiltinsJSON(t *testing.T) {
+	cmd := setUpCobra(inspectBuiltinsCmd())
+	cmd.SetContext(context.Background())
+	buffy := bytes.Buffer{}
+	cmd.SetOut(&buffy)
+
+	cmd.SetArgs([]string{"inspect", "builtins", "--output", "json"})
+	require.NoError(t, cmd.Execute())
+
+	var builtins []evaluator.Builtin
+	require.NoError(t, json.Unmarshal(buffy.Bytes(), &builtins))
+	assert.Equal(t, evaluator.Builtins(), builtins)
+}
+
+func TestInspectBuiltinsCapabilities(t *testing.T) {
+	cmd := setUpCobra(inspectBuiltinsCmd())
+	cmd.SetContext(context.Background())
+	buffy := bytes.Buffer{}
+	cmd.SetOut(&buffy)
+
+	cmd.SetArgs([]string{"inspect", "builtins", "-o", "capabilities"})
+	require.NoError(t, cmd.Execute())
+
+	var capabilities struct {
+		Builtins []struct {
+			Name string `json:"name"`
+		} `json:"builtins"`
+	}
+	require.NoError(t, json.Unmarshal(buffy.Bytes(), &capabilities))
+
+	names := []string{}
+	for _, b := range capabilities.Builtins {
+		names = append(names, b.Name)
+	}
+	assert.Contains(t, names, "ec.oci.blob")
+	assert.NotContains(t, names, "http.send")
+}
+
+func TestInspectBuiltinsInvalidOutput(t *testing.T) {
+	cmd := setUpCobra(inspectBuiltinsCmd())
+	cmd.SetContext(context.Background())
+	cmd.SetOut(&bytes.Buffer{})
+
+	cmd.SetArgs([]string{"inspect", "builtins", "--output", "yaml"})
+	assert.EqualError(t, cmd.Execute(), "invalid value for --output 'yaml'. accepted values: text, json, capabilities")
+}
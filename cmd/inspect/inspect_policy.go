@@ -18,8 +18,10 @@
 package inspect
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"path/filepath"
 	"strings"
 
 	hd "github.com/MakeNowJust/heredoc"
@@ -44,9 +46,10 @@ func inspectPolicyCmd() *cobra.Command {
 		ruleFilter       string
 		packageFilter    string
 		collectionFilter string
+		diffSourceUrls   []string
 	)
 
-	validFormats := []string{"json", "text", "names", "short-names"}
+	validFormats := append([]string{"json", "text", "names", "short-names"}, opa.DocsFormats...)
 
 	cmd := &cobra.Command{
 		Use:   "policy --source <source-url>",
@@ -63,6 +66,17 @@ func inspectPolicyCmd() *cobra.Command {
 			including the rule annotations which include the rule's title and description
 			and custom fields used by ec to filter the results produced by conftest.
 
+			The markdown, asciidoc and html output formats produce a rule reference, the
+			documentation of each deny and warn rule grouped by policy source and package,
+			followed by the rules included in each collection.
+
+			With --diff, the rules of the policy sources are compared, by their code, to the
+			rules of the sources provided with --diff, e.g. an older version of the same
+			policy. The rules added, removed, renamed, and the rules with changed annotations
+			are reported. Changes that could make the validation of images start failing,
+			e.g. a new deny rule or an earlier effective_on date, are marked as breaking.
+			Only the text and json output formats are supported with --diff.
+
 			Note that this command is not typically required to verify the Enterprise
 			Contract. It has been made available for troubleshooting and debugging purposes.
 		`),
@@ -75,6 +89,15 @@ func inspectPolicyCmd() *cobra.Command {
 			Display details about the latest Enterprise Contract release policy in json format:
 
 			  ec inspect policy --source quay.io/enterprise-contract/ec-release-policy -o json | jq
+
+			Generate the rule reference of the latest Enterprise Contract release policy in markdown:
+
+			  ec inspect policy --source quay.io/enterprise-contract/ec-release-policy -o markdown > rules.md
+
+			Show the rules changed between two versions of the Enterprise Contract release policy:
+
+			  ec inspect policy --source oci::quay.io/enterprise-contract/ec-release-policy:git-5f3b2a1 \
+			    --diff oci::quay.io/enterprise-contract/ec-release-policy:git-0c2c1e8
 		`),
 
 		Args: cobra.NoArgs,
@@ -102,6 +125,10 @@ func inspectPolicyCmd() *cobra.Command {
 				return fmt.Errorf("invalid value for --output '%s'. accepted values: %s", outputFormat, strings.Join(validFormats, ", "))
 			}
 
+			if len(diffSourceUrls) > 0 && outputFormat != "text" && outputFormat != "json" {
+				return fmt.Errorf("invalid value for --output '%s'. accepted values with --diff: text, json", outputFormat)
+			}
+
 			ctx := cmd.Context()
 			fs := utils.FS(ctx)
 
@@ -116,35 +143,40 @@ func inspectPolicyCmd() *cobra.Command {
 				defer utils.CleanupWorkDir(fs, workDir)
 			}
 
-			allResults := make(map[string][]*ast.AnnotationsRef)
-			for _, url := range sourceUrls {
-				s := &source.PolicyUrl{Url: url, Kind: source.PolicyKind}
+			allResults, err := inspectSources(ctx, destDir, sourceUrls)
+			if err != nil {
+				return err
+			}
+
+			allResults, err = filterResults(allResults, ruleFilter, packageFilter, collectionFilter)
+			if err != nil {
+				return err
+			}
 
-				// Download
-				policyDir, err := s.GetPolicy(ctx, destDir, false)
+			out := cmd.OutOrStdout()
+			if len(diffSourceUrls) > 0 {
+				// the same url can be in both sets of sources, keep their downloads apart
+				diffResults, err := inspectSources(ctx, filepath.Join(destDir, "diff"), diffSourceUrls)
 				if err != nil {
 					return err
 				}
 
-				// Inspect
-				result, err := opa.InspectDir(fs, policyDir)
+				diffResults, err = filterResults(diffResults, ruleFilter, packageFilter, collectionFilter)
 				if err != nil {
 					return err
 				}
 
-				// Collect results
-				allResults[s.PolicyUrl()] = result
-			}
-
-			var err error
-			allResults, err = filterResults(allResults, ruleFilter, packageFilter, collectionFilter)
-			if err != nil {
-				return err
+				diff := opa.DiffRules(diffResults, allResults)
+				if outputFormat == "json" {
+					return json.NewEncoder(out).Encode(diff)
+				}
+				return opa.OutputDiff(out, diff)
 			}
 
-			out := cmd.OutOrStdout()
 			if outputFormat == "json" {
 				return json.NewEncoder(out).Encode(allResults)
+			} else if slices.Contains(opa.DocsFormats, outputFormat) {
+				return opa.OutputDocs(out, allResults, outputFormat)
 			} else {
 				return opa.OutputText(out, allResults, outputFormat)
 			}
@@ -159,12 +191,41 @@ func inspectPolicyCmd() *cobra.Command {
 	flags.StringVar(&ruleFilter, "rule", ruleFilter, "display results matching rule name")
 	flags.StringVar(&packageFilter, "package", packageFilter, "display results matching package name")
 	flags.StringVar(&collectionFilter, "collection", collectionFilter, "display rules included in given collection")
+	flags.StringArrayVar(&diffSourceUrls, "diff", []string{}, "policy source url to compare the rules to, e.g. an older version of the policy source. multiple values are allowed")
 
 	cmd.MarkFlagsMutuallyExclusive("policy", "source")
 
 	return cmd
 }
 
+// inspectSources downloads the policy sources to the destination directory and returns the
+// annotations of their rules by source url.
+func inspectSources(ctx context.Context, destDir string, urls []string) (map[string][]*ast.AnnotationsRef, error) {
+	fs := utils.FS(ctx)
+
+	allResults := make(map[string][]*ast.AnnotationsRef)
+	for _, url := range urls {
+		s := &source.PolicyUrl{Url: url, Kind: source.PolicyKind}
+
+		// Download
+		policyDir, err := s.GetPolicy(ctx, destDir, false)
+		if err != nil {
+			return nil, err
+		}
+
+		// Inspect
+		result, err := opa.InspectDir(fs, policyDir)
+		if err != nil {
+			return nil, err
+		}
+
+		// Collect results
+		allResults[s.PolicyUrl()] = result
+	}
+
+	return allResults, nil
+}
+
 func filterResults(results map[string][]*ast.AnnotationsRef, rule, pkg, collection string) (map[string][]*ast.AnnotationsRef, error) {
 	if rule == "" && pkg == "" && collection == "" {
 		return results, nil
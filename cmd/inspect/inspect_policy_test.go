@@ -22,6 +22,7 @@ import (
 	"bytes"
 	"testing"
 
+	hd "github.com/MakeNowJust/heredoc"
 	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
@@ -172,3 +173,133 @@ func setUpCobra(command *cobra.Command) *cobra.Command {
 	cmd.AddCommand(inspectCmd)
 	return cmd
 }
+
+func TestInspectPolicyMarkdown(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	ctx := utils.WithFS(context.Background(), fs)
+
+	downloader := mockDownloader{}
+	ctx = context.WithValue(ctx, source.DownloaderFuncKey, &downloader)
+
+	downloader.On("Download", mock.Anything, "one", false).Return(nil).Run(func(args mock.Arguments) {
+		dir := args.String(0)
+
+		if err := afero.WriteFile(fs, dir+"/release/image.rego", []byte(hd.Doc(`
+			package policy.release.image
+
+			import future.keywords.contains
+			import future.keywords.if
+
+			# METADATA
+			# title: Image present
+			# description: The image is present.
+			# custom:
+			#   short_name: present
+			deny contains result if {
+				false
+			}
+		`)), 0644); err != nil {
+			panic(err)
+		}
+	})
+
+	cmd := setUpCobra(inspectPolicyCmd())
+	cmd.SetContext(ctx)
+	buffy := bytes.Buffer{}
+	cmd.SetOut(&buffy)
+
+	cmd.SetArgs([]string{
+		"inspect",
+		"policy",
+		"--source",
+		"one",
+		"--output",
+		"markdown",
+	})
+
+	err := cmd.Execute()
+	assert.NoError(t, err)
+
+	assert.Contains(t, buffy.String(), "# Rule reference\n\n## Source: `one`\n\n### Package: `policy.release.image`\n")
+	assert.Contains(t, buffy.String(), "<a id=\"rule-image-present\"></a>\n#### Image present\n")
+}
+
+func TestInspectPolicyDiff(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	ctx := utils.WithFS(context.Background(), fs)
+
+	downloader := mockDownloader{}
+	ctx = context.WithValue(ctx, source.DownloaderFuncKey, &downloader)
+
+	writeRule := func(shortName string) func(mock.Arguments) {
+		return func(args mock.Arguments) {
+			dir := args.String(0)
+
+			if err := afero.WriteFile(fs, dir+"/release/image.rego", []byte(hd.Doc(`
+				package policy.release.image
+
+				import future.keywords.contains
+				import future.keywords.if
+
+				# METADATA
+				# title: Image present
+				# custom:
+				#   short_name: `+shortName+`
+				deny contains result if {
+					false
+				}
+			`)), 0644); err != nil {
+				panic(err)
+			}
+		}
+	}
+
+	downloader.On("Download", mock.Anything, "old", false).Return(nil).Run(writeRule("present"))
+	downloader.On("Download", mock.Anything, "new", false).Return(nil).Run(writeRule("exists"))
+
+	cmd := setUpCobra(inspectPolicyCmd())
+	cmd.SetContext(ctx)
+	buffy := bytes.Buffer{}
+	cmd.SetOut(&buffy)
+
+	cmd.SetArgs([]string{
+		"inspect",
+		"policy",
+		"--source",
+		"new",
+		"--diff",
+		"old",
+	})
+
+	err := cmd.Execute()
+	assert.NoError(t, err)
+
+	assert.Equal(t, hd.Doc(`
+		Renamed:
+		! image.present -> image.exists (deny): Image present
+		    ! the rule was renamed, excludes and includes of image.present no longer apply to it
+
+		0 added, 0 removed, 1 renamed, 0 changed, 1 breaking
+	`), buffy.String())
+}
+
+func TestInspectPolicyDiffUnsupportedFormat(t *testing.T) {
+	cmd := setUpCobra(inspectPolicyCmd())
+	cmd.SetContext(utils.WithFS(context.Background(), afero.NewMemMapFs()))
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+
+	cmd.SetArgs([]string{
+		"inspect",
+		"policy",
+		"--source",
+		"new",
+		"--diff",
+		"old",
+		"--output",
+		"markdown",
+	})
+
+	err := cmd.Execute()
+	assert.EqualError(t, err, "invalid value for --output 'markdown'. accepted values with --diff: text, json")
+}
@@ -0,0 +1,203 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Define the `ec policy lint` command
+package policy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	hd "github.com/MakeNowJust/heredoc"
+	ecc "github.com/enterprise-contract/enterprise-contract-controller/api/v1alpha1"
+	"github.com/spf13/cobra"
+
+	"github.com/enterprise-contract/ec-cli/internal/policy"
+	"github.com/enterprise-contract/ec-cli/internal/policy/lint"
+	"github.com/enterprise-contract/ec-cli/internal/utils"
+)
+
+func lintCmd() *cobra.Command {
+	var (
+		policyConfiguration string
+		outputFormat        string
+		strict              bool
+	)
+
+	validFormats := []string{"text", "json", "github"}
+
+	cmd := &cobra.Command{
+		Use:   "lint [path...]",
+		Short: "Check the annotations of policy rules for mistakes",
+
+		Long: hd.Doc(`
+			Check the annotations of policy rules for mistakes.
+
+			The deny and warn rules in the rego files in the provided paths, or the current
+			directory if none are provided, are checked together, excluding the tests in files
+			ending in _test.rego. The following problems are reported:
+
+			  * rules without the short_name annotation, their successes are not reported and
+			    they cannot be included or excluded by their code
+			  * rules without the title or description annotations, their results are reported
+			    without them
+			  * rules with the same code
+			  * effective_on annotations not in the 2006-01-02T15:04:05Z format, such rules
+			    are always effective
+			  * depends_on annotations referring to unknown rules
+			  * rules depending on each other in a cycle
+			  * xref: links in descriptions and solutions that are not rendered
+			  * documentation_url templates, declared by the rule or its packages, that cannot
+			    be rendered
+
+			With --policy, rules in collections not included by any source group of the policy
+			configuration are reported as well.
+
+			Each problem is reported with the file and line of the rule. With the github output
+			format, the problems are reported as GitHub Actions workflow commands, which show
+			up as annotations of the changed files. Problems reported as warnings do not cause
+			a non-zero exit status.
+		`),
+
+		Example: hd.Doc(`
+			Check the rules in the policy directory:
+
+			  ec policy lint policy
+
+			Check the rules and report the collections not included by a policy configuration:
+
+			  ec policy lint --policy "$(cat policy.yaml)" policy
+
+			Report the problems as annotations in a GitHub Actions workflow:
+
+			  ec policy lint --output github policy
+		`),
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !slices.Contains(validFormats, outputFormat) {
+				return fmt.Errorf("invalid value for --output '%s'. accepted values: %s", outputFormat, strings.Join(validFormats, ", "))
+			}
+
+			ctx := cmd.Context()
+			var opts lint.RulesOptions
+			if policyConfiguration != "" {
+				p, err := policy.NewInertPolicy(ctx, policyConfiguration)
+				if err != nil {
+					return err
+				}
+				opts.Collections = includedCollections(p.Spec())
+			}
+
+			paths := args
+			if len(paths) == 0 {
+				paths = []string{"."}
+			}
+
+			issues, err := lint.LintRules(utils.FS(ctx), paths, opts)
+			if err != nil {
+				return err
+			}
+
+			out := cmd.OutOrStdout()
+			switch outputFormat {
+			case "json":
+				if issues == nil {
+					issues = []lint.Issue{}
+				}
+				if err := json.NewEncoder(out).Encode(issues); err != nil {
+					return err
+				}
+			case "github":
+				if err := lintReportGitHub(out, issues); err != nil {
+					return err
+				}
+			default:
+				for _, i := range issues {
+					fmt.Fprintln(out, i)
+				}
+			}
+
+			if strict && slices.ContainsFunc(issues, func(i lint.Issue) bool { return i.Severity == lint.SeverityError }) {
+				return errors.New("the policy rules have errors")
+			}
+
+			return nil
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVarP(&policyConfiguration, "policy", "p", "", "policy configuration, the collections it includes are checked against the collections of the rules")
+	flags.StringVarP(&outputFormat, "output", "o", "text", fmt.Sprintf("output format. one of: %s", strings.Join(validFormats, ", ")))
+	flags.BoolVarP(&strict, "strict", "s", true, "return non-zero status if errors are found. Use --strict=false to return a zero status code.")
+
+	return cmd
+}
+
+// includedCollections returns the collections included by any of the source groups of the
+// policy configuration, either as @collection include entries or in the deprecated
+// collections list.
+func includedCollections(spec ecc.EnterpriseContractPolicySpec) []string {
+	collections := []string{}
+	add := func(entries []string) {
+		for _, e := range entries {
+			name, _, _ := strings.Cut(e, ":")
+			if c, ok := strings.CutPrefix(name, "@"); ok && !slices.Contains(collections, c) {
+				collections = append(collections, c)
+			}
+		}
+	}
+
+	if c := spec.Configuration; c != nil {
+		add(c.Include)
+		for _, name := range c.Collections {
+			add([]string{"@" + name})
+		}
+	}
+
+	for _, src := range spec.Sources {
+		if src.Config != nil {
+			add(src.Config.Include)
+		}
+		if vc := src.VolatileConfig; vc != nil {
+			for _, c := range vc.Include {
+				add([]string{c.Value})
+			}
+		}
+	}
+
+	return collections
+}
+
+// lintReportGitHub writes the issues as GitHub Actions workflow commands.
+func lintReportGitHub(out io.Writer, issues []lint.Issue) error {
+	escapeData := strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+	escapeProperty := strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+	for _, i := range issues {
+		command := "error"
+		if i.Severity == lint.SeverityWarning {
+			command = "warning"
+		}
+		if _, err := fmt.Fprintf(out, "::%s file=%s,line=%d,title=%s::%s\n", command, escapeProperty.Replace(i.File), i.Row, escapeProperty.Replace(i.Check), escapeData.Replace(i.Message)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,142 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unit
+
+package policy
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+
+	ecc "github.com/enterprise-contract/enterprise-contract-controller/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/enterprise-contract/ec-cli/internal/policy/lint"
+)
+
+const lintedRules = `package pkg
+
+import rego.v1
+
+# METADATA
+# title: Enforced
+# description: Enforced rule
+# custom:
+#   short_name: enforced
+#   collections:
+#   - minimal
+#   - extra
+deny contains result if {
+	input.enforce
+	result := {"code": "pkg.enforced", "msg": "enforced"}
+}
+
+warn contains result if {
+	input.warn
+	result := {"msg": "warned"}
+}
+`
+
+func TestPolicyLint(t *testing.T) {
+	dir := writeTestedRules(t, map[string]string{
+		"rules.rego":      lintedRules,
+		"rules_test.rego": rulesTests,
+	})
+
+	cmd := setUpCobra(lintCmd())
+	cmd.SetContext(context.Background())
+	out := bytes.Buffer{}
+	cmd.SetOut(&out)
+	cmd.SetErr(&bytes.Buffer{})
+
+	cmd.SetArgs([]string{"policy", "lint", "--policy", `{"configuration": {"include": ["@minimal"]}}`, dir})
+	err := cmd.Execute()
+	assert.EqualError(t, err, "the policy rules have errors")
+
+	assert.Equal(t, fmt.Sprintf(
+		"%[1]s/rules.rego:13: warning: rule \"pkg.enforced\" is in the collection \"extra\", which is not included by the policy configuration (unused-collection)\n"+
+			"%[1]s/rules.rego:18: error: rule data.pkg.warn has no short_name annotation, its successes are not reported and it cannot be included or excluded by its code (missing-short-name)\n",
+		dir), out.String())
+}
+
+func TestPolicyLintGitHub(t *testing.T) {
+	dir := writeTestedRules(t, map[string]string{
+		"rules.rego": lintedRules,
+	})
+
+	cmd := setUpCobra(lintCmd())
+	cmd.SetContext(context.Background())
+	out := bytes.Buffer{}
+	cmd.SetOut(&out)
+
+	cmd.SetArgs([]string{"policy", "lint", "--output", "github", "--strict=false", dir})
+	require.NoError(t, cmd.Execute())
+
+	assert.Equal(t, fmt.Sprintf(
+		"::error file=%s/rules.rego,line=18,title=missing-short-name::rule data.pkg.warn has no short_name annotation, its successes are not reported and it cannot be included or excluded by its code\n",
+		dir), out.String())
+}
+
+func TestPolicyLintJSON(t *testing.T) {
+	dir := writeTestedRules(t, map[string]string{
+		"rules.rego": testedRules,
+	})
+
+	cmd := setUpCobra(lintCmd())
+	cmd.SetContext(context.Background())
+	out := bytes.Buffer{}
+	cmd.SetOut(&out)
+
+	cmd.SetArgs([]string{"policy", "lint", "--output", "json", dir})
+	require.NoError(t, cmd.Execute())
+
+	assert.JSONEq(t, "[]", out.String())
+}
+
+func TestLintReportGitHub(t *testing.T) {
+	out := bytes.Buffer{}
+	require.NoError(t, lintReportGitHub(&out, []lint.Issue{
+		{File: "a,b:c.rego", Row: 3, Severity: lint.SeverityWarning, Check: "broken-xref", Message: "100% broken\nlink"},
+	}))
+
+	assert.Equal(t, "::warning file=a%2Cb%3Ac.rego,line=3,title=broken-xref::100%25 broken%0Alink\n", out.String())
+}
+
+func TestIncludedCollections(t *testing.T) {
+	spec := ecc.EnterpriseContractPolicySpec{
+		Configuration: &ecc.EnterpriseContractPolicyConfiguration{
+			Include:     []string{"@a", "pkg.rule"},
+			Collections: []string{"b"},
+		},
+		Sources: []ecc.Source{
+			{
+				Config: &ecc.SourceConfig{Include: []string{"@c:term", "@a"}},
+			},
+			{
+				VolatileConfig: &ecc.VolatileSourceConfig{
+					Include: []ecc.VolatileCriteria{{Value: "@d"}},
+				},
+			},
+		},
+	}
+
+	assert.Equal(t, []string{"a", "b", "c", "d"}, includedCollections(spec))
+	assert.Equal(t, []string{}, includedCollections(ecc.EnterpriseContractPolicySpec{}))
+}
@@ -0,0 +1,202 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Define the `ec policy resolve` command
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	hd "github.com/MakeNowJust/heredoc"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+
+	"github.com/enterprise-contract/ec-cli/internal/evaluator"
+	"github.com/enterprise-contract/ec-cli/internal/policy"
+	"github.com/enterprise-contract/ec-cli/internal/policy/source"
+	"github.com/enterprise-contract/ec-cli/internal/utils"
+)
+
+type resolvedSource struct {
+	Name  string                     `json:"name"`
+	Rules []evaluator.RuleResolution `json:"rules"`
+}
+
+type resolvedPolicy struct {
+	EffectiveTime time.Time        `json:"effectiveTime"`
+	Sources       []resolvedSource `json:"sources"`
+}
+
+func resolveCmd() *cobra.Command {
+	var (
+		policyRef     string
+		effectiveTime string
+		outputFormat  string
+	)
+
+	validFormats := []string{"text", "json", "yaml"}
+
+	cmd := &cobra.Command{
+		Use:   "resolve --policy <policy>",
+		Short: "Show the rules enforced, warned about or ignored by a policy configuration",
+
+		Long: hd.Doc(`
+			Show the rules enforced, warned about or ignored by a policy configuration.
+
+			The policy sources of each source group are fetched and the include and exclude
+			lists, collections and volatile configuration of the policy configuration are
+			applied to the rules found in them, at the provided effective time. This is the
+			same logic applied when validating an image, so the rule set that will be in
+			effect can be reviewed before rolling out a change to the policy configuration.
+
+			Rules that are not yet effective are reported as warned. Results for particular
+			terms cannot be predicted, so include and exclude entries with a term only apply
+			when validating.
+		`),
+
+		Example: hd.Doc(`
+			Show the rules in effect for the policy configuration in a file:
+
+			  ec policy resolve --policy policy.yaml
+
+			Show the rules that will be in effect on a given date in JSON format:
+
+			  ec policy resolve --policy my-namespace/my-policy --effective-time 2024-06-01 --output json
+		`),
+
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !slices.Contains(validFormats, outputFormat) {
+				return fmt.Errorf("invalid value for --output '%s'. accepted values: %s", outputFormat, strings.Join(validFormats, ", "))
+			}
+
+			ctx := cmd.Context()
+
+			policyConfiguration, err := source.ReadPolicyConfiguration(ctx, policyRef)
+			if err != nil {
+				return err
+			}
+
+			p, err := policy.NewInertPolicyAt(ctx, policyConfiguration, effectiveTime)
+			if err != nil {
+				return err
+			}
+
+			fs := utils.FS(ctx)
+			workDir, err := utils.CreateWorkDir(fs)
+			if err != nil {
+				log.Debug("Failed to create work dir!")
+				return err
+			}
+			defer utils.CleanupWorkDir(fs, workDir)
+
+			resolved := resolvedPolicy{EffectiveTime: p.EffectiveTime()}
+			for i, src := range p.Spec().Sources {
+				rules, err := evaluator.FetchRules(ctx, src, workDir)
+				if err != nil {
+					return err
+				}
+
+				resolutions, err := evaluator.ResolveRules(p, src, rules)
+				if err != nil {
+					return fmt.Errorf("resolving the rules of %s: %w", sourceName(i, src.Name), err)
+				}
+
+				resolved.Sources = append(resolved.Sources, resolvedSource{
+					Name:  sourceName(i, src.Name),
+					Rules: resolutions,
+				})
+			}
+
+			out := cmd.OutOrStdout()
+			switch outputFormat {
+			case "json":
+				return json.NewEncoder(out).Encode(resolved)
+			case "yaml":
+				data, err := yaml.Marshal(resolved)
+				if err != nil {
+					return err
+				}
+				_, err = out.Write(data)
+				return err
+			default:
+				return resolvedText(out, resolved)
+			}
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVarP(&policyRef, "policy", "p", "", hd.Doc(`
+		Policy configuration as:
+		  * Kubernetes reference ([<namespace>/]<name>)
+		  * file (policy.yaml)
+		  * git reference (github.com/user/repo//default?ref=main), or
+		  * inline JSON ('{sources: {...}, configuration: {...}}')`))
+	flags.StringVar(&effectiveTime, "effective-time", policy.Now, hd.Doc(`
+		Resolve the rules at the provided time. The value can be "now" (default) - for
+		current time, or a RFC3339 formatted value, e.g. 2022-11-18T00:00:00Z.`))
+	flags.StringVarP(&outputFormat, "output", "o", "text", fmt.Sprintf("output format. one of: %s", strings.Join(validFormats, ", ")))
+
+	if err := cmd.MarkFlagRequired("policy"); err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+// sourceName returns the name of the source group, or a name based on its position when the
+// source group is not named.
+func sourceName(i int, name string) string {
+	if name != "" {
+		return name
+	}
+
+	return fmt.Sprintf("sources[%d]", i)
+}
+
+func resolvedText(out io.Writer, resolved resolvedPolicy) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Effective time: %s\n", resolved.EffectiveTime.Format(time.RFC3339))
+	for _, s := range resolved.Sources {
+		fmt.Fprintf(&b, "\n# Source group: %s\n", s.Name)
+		for _, resolution := range []string{evaluator.ResolutionEnforced, evaluator.ResolutionWarned, evaluator.ResolutionIgnored} {
+			header := false
+			for _, r := range s.Rules {
+				if r.Resolution != resolution {
+					continue
+				}
+				if !header {
+					fmt.Fprintf(&b, "\n%s:\n", strings.ToUpper(resolution[:1])+resolution[1:])
+					header = true
+				}
+				if r.Reason == "" {
+					fmt.Fprintf(&b, "  %s\n", r.Code)
+				} else {
+					fmt.Fprintf(&b, "  %s: %s\n", r.Code, r.Reason)
+				}
+			}
+		}
+	}
+
+	_, err := io.WriteString(out, b.String())
+	return err
+}
@@ -0,0 +1,275 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unit
+
+package policy
+
+import (
+	"bytes"
+	"context"
+	"path"
+	"testing"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/enterprise-contract/ec-cli/cmd/root"
+	"github.com/enterprise-contract/ec-cli/internal/policy/source"
+	"github.com/enterprise-contract/ec-cli/internal/utils"
+)
+
+type mockDownloader struct {
+	mock.Mock
+}
+
+func (m *mockDownloader) Download(_ context.Context, dest string, sourceUrl string, showMsg bool) error {
+	args := m.Called(dest, sourceUrl, showMsg)
+
+	return args.Error(0)
+}
+
+const testRules = `package pkg
+
+import future.keywords.contains
+import future.keywords.if
+
+# METADATA
+# custom:
+#   short_name: enforced
+#   collections:
+#   - minimal
+deny contains result if {
+	false
+	result := "enforced"
+}
+
+# METADATA
+# custom:
+#   short_name: future
+#   effective_on: 2099-01-01T00:00:00Z
+deny contains result if {
+	false
+	result := "future"
+}
+
+# METADATA
+# custom:
+#   short_name: warning
+warn contains result if {
+	false
+	result := "warning"
+}
+
+# METADATA
+# custom:
+#   short_name: excluded
+deny contains result if {
+	false
+	result := "excluded"
+}
+`
+
+func setUpCobra(command *cobra.Command) *cobra.Command {
+	policyCmd := NewPolicyCmd()
+	policyCmd.AddCommand(command)
+	cmd := root.NewRootCmd()
+	cmd.AddCommand(policyCmd)
+	return cmd
+}
+
+func setUpDownloader(t *testing.T) context.Context {
+	fs := afero.NewMemMapFs()
+	ctx := utils.WithFS(context.Background(), fs)
+
+	downloader := mockDownloader{}
+	ctx = context.WithValue(ctx, source.DownloaderFuncKey, &downloader)
+
+	downloader.On("Download", mock.Anything, "rules", false).Return(nil).Run(func(args mock.Arguments) {
+		dir := args.String(0)
+		require.NoError(t, fs.MkdirAll(dir, 0755))
+		require.NoError(t, afero.WriteFile(fs, path.Join(dir, "rules.rego"), []byte(testRules), 0644))
+	})
+
+	return ctx
+}
+
+func TestResolve(t *testing.T) {
+	ctx := setUpDownloader(t)
+
+	cmd := setUpCobra(resolveCmd())
+	cmd.SetContext(ctx)
+	out := bytes.Buffer{}
+	cmd.SetOut(&out)
+
+	cmd.SetArgs([]string{
+		"policy",
+		"resolve",
+		"--effective-time",
+		"2024-01-01T00:00:00Z",
+		"--policy",
+		`{"sources":[{"name":"release","policy":["rules"],"config":{"exclude":["pkg.excluded"]}},{"policy":["rules"],"config":{"include":["@minimal"]}}]}`,
+	})
+
+	require.NoError(t, cmd.Execute())
+
+	assert.Equal(t, heredoc.Doc(`
+		Effective time: 2024-01-01T00:00:00Z
+
+		# Source group: release
+
+		Enforced:
+		  pkg.enforced
+
+		Warned:
+		  pkg.future: not yet effective, effective on 2099-01-01T00:00:00Z
+		  pkg.warning
+
+		Ignored:
+		  pkg.excluded: excluded by `+"`exclude: [pkg.excluded]`"+` (score 110 vs 1)
+
+		# Source group: sources[1]
+
+		Enforced:
+		  pkg.enforced
+
+		Ignored:
+		  pkg.excluded: not matched by `+"`include: [@minimal]`"+` (score 0 vs 0)
+		  pkg.future: not matched by `+"`include: [@minimal]`"+` (score 0 vs 0)
+		  pkg.warning: not matched by `+"`include: [@minimal]`"+` (score 0 vs 0)
+	`), out.String())
+}
+
+func TestResolveJSON(t *testing.T) {
+	ctx := setUpDownloader(t)
+
+	cmd := setUpCobra(resolveCmd())
+	cmd.SetContext(ctx)
+	out := bytes.Buffer{}
+	cmd.SetOut(&out)
+
+	cmd.SetArgs([]string{
+		"policy",
+		"resolve",
+		"--effective-time",
+		"2100-01-01",
+		"--output",
+		"json",
+		"--policy",
+		`{"sources":[{"policy":["rules"]}]}`,
+	})
+
+	require.NoError(t, cmd.Execute())
+
+	assert.JSONEq(t, `{
+		"effectiveTime": "2100-01-01T00:00:00Z",
+		"sources": [
+			{
+				"name": "sources[0]",
+				"rules": [
+					{"code": "pkg.enforced", "resolution": "enforced"},
+					{"code": "pkg.excluded", "resolution": "enforced"},
+					{"code": "pkg.future", "resolution": "enforced"},
+					{"code": "pkg.warning", "resolution": "warned"}
+				]
+			}
+		]
+	}`, out.String())
+}
+
+func TestResolveFromFile(t *testing.T) {
+	ctx := setUpDownloader(t)
+
+	fs := utils.FS(ctx)
+	require.NoError(t, afero.WriteFile(fs, "policy.yaml", []byte(heredoc.Doc(`
+		sources:
+		  - name: namespaced
+		    policy:
+		      - rules
+		    ruleNamespaces:
+		      rules: org
+		    config:
+		      exclude:
+		        - org/pkg.excluded
+	`)), 0644))
+
+	cmd := setUpCobra(resolveCmd())
+	cmd.SetContext(ctx)
+	out := bytes.Buffer{}
+	cmd.SetOut(&out)
+
+	cmd.SetArgs([]string{
+		"policy",
+		"resolve",
+		"--effective-time",
+		"2024-01-01T00:00:00Z",
+		"--policy",
+		"policy.yaml",
+	})
+
+	require.NoError(t, cmd.Execute())
+
+	assert.Equal(t, heredoc.Doc(`
+		Effective time: 2024-01-01T00:00:00Z
+
+		# Source group: namespaced
+
+		Enforced:
+		  org/pkg.enforced
+
+		Warned:
+		  org/pkg.future: not yet effective, effective on 2099-01-01T00:00:00Z
+		  org/pkg.warning
+
+		Ignored:
+		  org/pkg.excluded: excluded by `+"`exclude: [org/pkg.excluded]`"+` (score 110 vs 1)
+	`), out.String())
+}
+
+func TestResolveEmptyFile(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	ctx := utils.WithFS(context.Background(), fs)
+	require.NoError(t, afero.WriteFile(fs, "policy.yaml", []byte{}, 0644))
+
+	cmd := setUpCobra(resolveCmd())
+	cmd.SetContext(ctx)
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+
+	cmd.SetArgs([]string{
+		"policy",
+		"resolve",
+		"--policy",
+		"policy.yaml",
+	})
+
+	assert.EqualError(t, cmd.Execute(), "file policy.yaml is empty")
+}
+
+func TestResolveInvalidOutput(t *testing.T) {
+	cmd := setUpCobra(resolveCmd())
+	cmd.SetContext(context.Background())
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+
+	cmd.SetArgs([]string{"policy", "resolve", "--policy", "{}", "--output", "xml"})
+
+	assert.EqualError(t, cmd.Execute(), "invalid value for --output 'xml'. accepted values: text, json, yaml")
+}
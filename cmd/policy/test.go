@@ -0,0 +1,204 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Define the `ec policy test` command
+package policy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	hd "github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+
+	"github.com/enterprise-contract/ec-cli/internal/policy/tester"
+)
+
+func testCmd() *cobra.Command {
+	var (
+		run          string
+		coverage     bool
+		threshold    float64
+		timeout      time.Duration
+		outputFormat string
+	)
+
+	validFormats := []string{"text", "json"}
+
+	cmd := &cobra.Command{
+		Use:   "test [path...]",
+		Short: "Run the unit tests of policy rules",
+
+		Long: hd.Doc(`
+			Run the unit tests of policy rules.
+
+			The rego files and data files in the provided paths, or the current directory if
+			none are provided, are loaded, and the tests, rules with names starting with
+			"test_", are run. The rules and tests are compiled with the same capabilities used
+			when validating, so the rego functions provided by ec, see 'ec inspect builtins',
+			are available, and the OPA built-in functions ec does not allow, e.g. http.send,
+			are not.
+
+			The annotations of the deny and warn rules are checked as with 'ec policy lint'.
+			Among others, every deny and warn rule is required to have the short_name
+			annotation, without it ec cannot report the successes of the rule, nor apply the
+			include and exclude lists of a policy configuration to it. Rules without the
+			title or description annotations are reported as warnings.
+
+			With --coverage, the line coverage of the rules, excluding the tests in files
+			ending in _test.rego, is reported for each package. With --threshold, a package
+			with a lower line coverage fails the run.
+
+			The command fails if any test fails, if any problem with the annotations of the
+			rules is reported as an error, or if the coverage of any package is below the
+			threshold.
+		`),
+
+		Example: hd.Doc(`
+			Run the tests of the policy rules in the policy directory:
+
+			  ec policy test policy
+
+			Run the tests with names matching a regular expression:
+
+			  ec policy test --run 'test_.*_signature' policy
+
+			Require at least 80% line coverage for each package:
+
+			  ec policy test --threshold 80 policy
+		`),
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !slices.Contains(validFormats, outputFormat) {
+				return fmt.Errorf("invalid value for --output '%s'. accepted values: %s", outputFormat, strings.Join(validFormats, ", "))
+			}
+
+			if threshold < 0 || threshold > 100 {
+				return fmt.Errorf("invalid value for --threshold %v, expecting a percentage between 0 and 100", threshold)
+			}
+
+			paths := args
+			if len(paths) == 0 {
+				paths = []string{"."}
+			}
+
+			report, err := tester.Run(cmd.Context(), tester.Options{
+				Paths:    paths,
+				Run:      run,
+				Coverage: coverage || cmd.Flags().Changed("threshold"),
+				Timeout:  timeout,
+			})
+			if err != nil {
+				return err
+			}
+
+			out := cmd.OutOrStdout()
+			if outputFormat == "json" {
+				if report.Tests == nil {
+					report.Tests = []tester.Result{}
+				}
+				if err := json.NewEncoder(out).Encode(report); err != nil {
+					return err
+				}
+			} else if err := testReportText(out, report); err != nil {
+				return err
+			}
+
+			var failures []string
+			if failed := report.Failed(); failed > 0 {
+				failures = append(failures, fmt.Sprintf("%d of %d tests failed", failed, len(report.Tests)))
+			}
+			if problems := report.AnnotationErrors(); problems > 0 {
+				failures = append(failures, fmt.Sprintf("%d problems with rule annotations found", problems))
+			}
+			if below := report.BelowThreshold(threshold); len(below) > 0 {
+				failures = append(failures, fmt.Sprintf("%d packages are below the coverage threshold of %.2f%%", len(below), threshold))
+			}
+
+			if len(failures) > 0 {
+				return errors.New(strings.Join(failures, ", "))
+			}
+
+			return nil
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVarP(&run, "run", "r", "", "run only the tests with names matching the regular expression")
+	flags.BoolVar(&coverage, "coverage", false, "report the line coverage of each package")
+	flags.Float64Var(&threshold, "threshold", 0, "minimum line coverage of each package, in percent, implies --coverage")
+	flags.DurationVar(&timeout, "timeout", 5*time.Second, "timeout of each test, 0 for no timeout")
+	flags.StringVarP(&outputFormat, "output", "o", "text", fmt.Sprintf("output format. one of: %s", strings.Join(validFormats, ", ")))
+
+	return cmd
+}
+
+func testReportText(out io.Writer, report tester.Report) error {
+	var b strings.Builder
+	// sections are separated by an empty line
+	section := func(title string) {
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(title)
+	}
+
+	for _, t := range report.Tests {
+		if t.Outcome == tester.OutcomePass {
+			continue
+		}
+		fmt.Fprintf(&b, "%s: %s (%s)\n", strings.ToUpper(t.Outcome), t.Name, t.Location)
+		if t.Error != "" {
+			fmt.Fprintf(&b, "  %s\n", t.Error)
+		}
+		for _, line := range strings.Split(strings.TrimSpace(t.Output), "\n") {
+			if line != "" {
+				fmt.Fprintf(&b, "  %s\n", line)
+			}
+		}
+	}
+
+	if len(report.Annotations) > 0 {
+		section("Annotations:\n")
+		for _, i := range report.Annotations {
+			fmt.Fprintf(&b, "  %s\n", i)
+		}
+	}
+
+	if len(report.Coverage) > 0 {
+		section("Coverage:\n")
+		for _, c := range report.Coverage {
+			fmt.Fprintf(&b, "  %s: %.2f%% (%d/%d lines)\n", c.Package, c.Coverage, c.CoveredLines, c.CoveredLines+c.NotCoveredLines)
+			for _, l := range c.NotCovered {
+				fmt.Fprintf(&b, "    not covered: %s\n", l)
+			}
+		}
+	}
+
+	section("")
+	fmt.Fprintf(&b, "PASS: %d/%d\n", report.Passed(), len(report.Tests))
+	if failed := report.Failed(); failed > 0 {
+		fmt.Fprintf(&b, "FAIL: %d/%d\n", failed, len(report.Tests))
+	}
+
+	_, err := io.WriteString(out, b.String())
+	return err
+}
@@ -0,0 +1,150 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unit
+
+package policy
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testedRules = `package pkg
+
+import rego.v1
+
+# METADATA
+# title: Enforced
+# description: Enforced rule
+# custom:
+#   short_name: enforced
+deny contains result if {
+	input.enforce
+	result := {"code": "pkg.enforced", "msg": "enforced"}
+}
+`
+
+const rulesTests = `package pkg_test
+
+import rego.v1
+
+import data.pkg
+
+test_enforced if {
+	count(pkg.deny) == 1 with input as {"enforce": true}
+}
+`
+
+func writeTestedRules(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(path.Join(dir, name), []byte(content), 0600))
+	}
+
+	return dir
+}
+
+func TestPolicyTest(t *testing.T) {
+	dir := writeTestedRules(t, map[string]string{
+		"rules.rego":      testedRules,
+		"rules_test.rego": rulesTests,
+	})
+
+	cmd := setUpCobra(testCmd())
+	cmd.SetContext(context.Background())
+	out := bytes.Buffer{}
+	cmd.SetOut(&out)
+
+	cmd.SetArgs([]string{"policy", "test", "--coverage", dir})
+	require.NoError(t, cmd.Execute())
+
+	assert.Equal(t, heredoc.Doc(`
+		Coverage:
+		  data.pkg: 100.00% (3/3 lines)
+
+		PASS: 1/1
+	`), out.String())
+}
+
+func TestPolicyTestFailures(t *testing.T) {
+	dir := writeTestedRules(t, map[string]string{
+		"rules.rego": testedRules + `
+warn contains result if {
+	input.warn
+	result := {"msg": "warned"}
+}
+`,
+		"rules_test.rego": rulesTests + `
+test_not_enforced if {
+	count(pkg.deny) == 1 with input as {}
+}
+`,
+	})
+
+	cmd := setUpCobra(testCmd())
+	cmd.SetContext(context.Background())
+	out := bytes.Buffer{}
+	cmd.SetOut(&out)
+	cmd.SetErr(&bytes.Buffer{})
+
+	cmd.SetArgs([]string{"policy", "test", "--threshold", "90", dir})
+	err := cmd.Execute()
+	assert.EqualError(t, err, "1 of 2 tests failed, 1 problems with rule annotations found, 1 packages are below the coverage threshold of 90.00%")
+
+	assert.Contains(t, out.String(), "FAIL: data.pkg_test.test_not_enforced ("+path.Join(dir, "rules_test.rego")+":11)\n")
+	assert.Contains(t, out.String(), path.Join(dir, "rules.rego")+":15: error: rule data.pkg.warn has no short_name annotation, its successes are not reported and it cannot be included or excluded by its code (missing-short-name)\n")
+	assert.Contains(t, out.String(), "not covered: "+path.Join(dir, "rules.rego")+":15-17\n")
+}
+
+func TestPolicyTestInvalidFlags(t *testing.T) {
+	cases := []struct {
+		name string
+		args []string
+		err  string
+	}{
+		{
+			name: "output",
+			args: []string{"--output", "xml"},
+			err:  "invalid value for --output 'xml'. accepted values: text, json",
+		},
+		{
+			name: "threshold",
+			args: []string{"--threshold", "101"},
+			err:  "invalid value for --threshold 101, expecting a percentage between 0 and 100",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cmd := setUpCobra(testCmd())
+			cmd.SetContext(context.Background())
+			cmd.SetOut(&bytes.Buffer{})
+			cmd.SetErr(&bytes.Buffer{})
+
+			cmd.SetArgs(append([]string{"policy", "test"}, c.args...))
+
+			assert.EqualError(t, cmd.Execute(), c.err)
+		})
+	}
+}
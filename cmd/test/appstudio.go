@@ -48,6 +48,8 @@ func appstudioReport(results []output.CheckResult, namespaces []string) applicat
 		report.Successes += result.Successes
 		report.Failures += len(result.Failures)
 		report.Warnings += len(result.Warnings)
+		report.Exceptions += len(result.Exceptions)
+		report.Skipped += len(result.Skipped)
 	}
 
 	report.DeriveResult(false)
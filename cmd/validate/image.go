@@ -27,13 +27,12 @@ import (
 	"github.com/hashicorp/go-multierror"
 	app "github.com/redhat-appstudio/application-api/api/v1alpha1"
 	"github.com/sigstore/cosign/v2/pkg/cosign"
-	log "github.com/sirupsen/logrus"
-	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
 
 	"github.com/enterprise-contract/ec-cli/internal/applicationsnapshot"
 	"github.com/enterprise-contract/ec-cli/internal/evaluator"
 	"github.com/enterprise-contract/ec-cli/internal/format"
+	"github.com/enterprise-contract/ec-cli/internal/image"
 	"github.com/enterprise-contract/ec-cli/internal/output"
 	"github.com/enterprise-contract/ec-cli/internal/policy"
 	"github.com/enterprise-contract/ec-cli/internal/policy/source"
@@ -44,11 +43,17 @@ type imageValidationFunc func(context.Context, app.SnapshotComponent, policy.Pol
 
 func validateImageCmd(validate imageValidationFunc) *cobra.Command {
 	var data = struct {
+		caRoots                     string
 		certificateIdentity         string
 		certificateIdentityRegExp   string
 		certificateOIDCIssuer       string
 		certificateOIDCIssuerRegExp string
+		componentPolicies           []componentPolicy
+		componentPoliciesFile       string
+		ctLogPublicKey              string
 		effectiveTime               string
+		exceptions                  string
+		explain                     []string
 		filePath                    string // Deprecated: images replaced this
 		imageRef                    string
 		info                        bool
@@ -59,11 +64,14 @@ func validateImageCmd(validate imageValidationFunc) *cobra.Command {
 		policy                      policy.Policy
 		policyConfiguration         string
 		publicKey                   string
+		rekorPublicKey              string
 		rekorURL                    string
 		snapshot                    string
 		spec                        *app.SnapshotSpec
 		strict                      bool
 		images                      string
+		trustedRoot                 string
+		tsaCertChain                string
 	}{
 		strict: true,
 	}
@@ -161,6 +169,15 @@ func validateImageCmd(validate imageValidationFunc) *cobra.Command {
 
 			  ec validate image --image registry/name:tag --output data=<path>
 
+			Explain the evaluation of the rule with the code "pkg.rule" for the violations and warnings
+			it reported
+
+			  ec validate image --image registry/name:tag --explain pkg.rule --output explain=<path>
+
+			Write why each policy rule did, or did not, produce a result to stdout
+
+			  ec validate image --image registry/name:tag --output coverage
+
 			Validate a single image with keyless workflow.
 
 			  ec validate image --image registry/name:tag --policy my-policy \
@@ -174,6 +191,21 @@ func validateImageCmd(validate imageValidationFunc) *cobra.Command {
 			    --certificate-identity-regexp '^https://github\.com' \
 			    --certificate-oidc-issuer-regexp 'githubusercontent' \
 			    --rekor-url 'https://rekor.sigstore.dev'
+
+			Use the verification material of a private Sigstore deployment instead of the
+			material distributed via TUF.
+
+			  ec validate image --image registry/name:tag --policy my-policy \
+			    --certificate-identity 'https://github.com/user/repo/.github/workflows/push.yaml@refs/heads/main' \
+			    --certificate-oidc-issuer 'https://token.actions.githubusercontent.com' \
+			    --trusted-root trusted_root.json
+
+			Use different policy source groups, and signers, for some of the components. The
+			file contains a list of component policies, each matching components by name,
+			image repository, or image labels.
+
+			  ec validate image --images my-app.yaml --policy my-policy.yaml \
+			    --component-policies component-policies.yaml
 		`),
 
 		PreRunE: func(cmd *cobra.Command, args []string) (allErrors error) {
@@ -190,53 +222,16 @@ func validateImageCmd(validate imageValidationFunc) *cobra.Command {
 				data.spec = s
 			}
 
-			// Check if policyConfiguration is a git url, if so, try to download a config file from git
-			if source.SourceIsGit(data.policyConfiguration) {
-				log.Debugf("Fetching policy config from git url %s", data.policyConfiguration)
-
-				// Create a temporary dir to download the config. It will be different to the
-				// workdir used later for downloading policy sources, but it won't matter
-				// because this dir is not used again once the config file has been read.
-				fs := utils.FS(ctx)
-				tmpDir, err := utils.CreateWorkDir(fs)
-				if err != nil {
-					allErrors = multierror.Append(allErrors, err)
-					return
-				}
-				defer utils.CleanupWorkDir(fs, tmpDir)
-
-				// Git download and find a suitable config file
-				configFile, err := source.GitConfigDownload(cmd.Context(), tmpDir, data.policyConfiguration)
-				if err != nil {
-					allErrors = multierror.Append(allErrors, err)
-					return
-				}
-
-				// Changing data.policyConfiguration to the name of the newly downloaded
-				// file means we can use the code below to load the config
-				data.policyConfiguration = configFile
-			}
-
-			// Check if policyConfiguration is a file path, if so, we read it into the var data.policyConfiguration
-			if utils.HasJsonOrYamlExt(data.policyConfiguration) {
-				fs := utils.FS(ctx)
-				policyBytes, err := afero.ReadFile(fs, data.policyConfiguration)
-				if err != nil {
-					allErrors = multierror.Append(allErrors, err)
-					return
-				}
-				// Check for empty file as that would cause a false "success"
-				if len(policyBytes) == 0 {
-					err := fmt.Errorf("file %s is empty", data.policyConfiguration)
-					allErrors = multierror.Append(allErrors, err)
-					return
-				}
-
-				data.policyConfiguration = string(policyBytes)
+			policyConfiguration, err := source.ReadPolicyConfiguration(ctx, data.policyConfiguration)
+			if err != nil {
+				allErrors = multierror.Append(allErrors, err)
+				return
 			}
+			data.policyConfiguration = policyConfiguration
 
 			if p, err := policy.NewPolicy(cmd.Context(), policy.Options{
 				EffectiveTime: data.effectiveTime,
+				Exceptions:    data.exceptions,
 				Identity: cosign.Identity{
 					Issuer:        data.certificateOIDCIssuer,
 					IssuerRegExp:  data.certificateOIDCIssuerRegExp,
@@ -247,10 +242,23 @@ func validateImageCmd(validate imageValidationFunc) *cobra.Command {
 				PolicyRef:   data.policyConfiguration,
 				PublicKey:   data.publicKey,
 				RekorURL:    data.rekorURL,
+				TrustedRoot: policy.TrustedRootOptions{
+					TrustedRoot:    data.trustedRoot,
+					CARoots:        data.caRoots,
+					CTLogPublicKey: data.ctLogPublicKey,
+					RekorPublicKey: data.rekorPublicKey,
+				},
+				TSACertChain: data.tsaCertChain,
 			}); err != nil {
 				allErrors = multierror.Append(allErrors, err)
 			} else {
 				data.policy = p
+
+				if cps, err := loadComponentPolicies(ctx, p, data.componentPoliciesFile); err != nil {
+					allErrors = multierror.Append(allErrors, err)
+				} else {
+					data.componentPolicies = cps
+				}
 			}
 
 			return
@@ -275,12 +283,20 @@ func validateImageCmd(validate imageValidationFunc) *cobra.Command {
 					defer lock.Done()
 
 					ctx := cmd.Context()
-					out, err := validate(ctx, comp, data.policy, data.info)
+					if len(data.explain) > 0 {
+						ctx = evaluator.WithExplain(ctx, data.explain)
+					}
+					p, applied, err := policyForComponent(ctx, data.policy, data.componentPolicies, comp)
+					var out *output.Output
+					if err == nil {
+						out, err = validate(ctx, comp, p, data.info)
+					}
 					res := result{
 						err: err,
 						component: applicationsnapshot.Component{
 							SnapshotComponent: comp,
 							Success:           err == nil,
+							Policy:            applied,
 						},
 					}
 
@@ -289,6 +305,9 @@ func validateImageCmd(validate imageValidationFunc) *cobra.Command {
 						res.component.Violations = out.Violations()
 						showSuccesses, _ := cmd.Flags().GetBool("show-successes")
 						res.component.Warnings = out.Warnings()
+						res.component.Exceptions = out.Exceptions()
+						res.component.Skipped = out.Skipped()
+						res.component.Coverage = out.Coverage()
 
 						successes := out.Successes()
 						res.component.SuccessCount = len(successes)
@@ -364,6 +383,21 @@ func validateImageCmd(validate imageValidationFunc) *cobra.Command {
 		  * git reference (github.com/user/repo//default?ref=main), or
 		  * inline JSON ('{sources: {...}, configuration: {...}}')")`))
 
+	cmd.Flags().StringVar(&data.exceptions, "exceptions", data.exceptions, hd.Doc(`
+		path to a YAML or JSON file with policy exceptions waiving the results of policy rules.
+		Added to the exceptions from the policy configuration`))
+
+	cmd.Flags().StringSliceVar(&data.explain, "explain", data.explain, hd.Doc(`
+		code of a policy rule, e.g. "pkg.rule", whose evaluation is explained for the violations
+		and warnings it reports. The explanation lists the evaluated expressions of the rule and
+		the input paths it referenced. Use the explain output format to render the explanations.
+		May be used multiple times`))
+
+	cmd.Flags().StringVar(&data.componentPoliciesFile, "component-policies", data.componentPoliciesFile, hd.Doc(`
+		path to a YAML or JSON file with the component policies selecting the policy source groups,
+		and signer, used for specific components. Overrides componentPolicies from the policy
+		configuration`))
+
 	cmd.Flags().StringVarP(&data.imageRef, "image", "i", data.imageRef, "OCI image reference")
 
 	cmd.Flags().StringVarP(&data.publicKey, "public-key", "k", data.publicKey,
@@ -387,6 +421,26 @@ func validateImageCmd(validate imageValidationFunc) *cobra.Command {
 	cmd.Flags().StringVar(&data.certificateOIDCIssuerRegExp, "certificate-oidc-issuer-regexp", data.certificateOIDCIssuerRegExp,
 		"Regular expresssion for the URL of the certificate OIDC issuer for keyless verification")
 
+	cmd.Flags().StringVar(&data.trustedRoot, "trusted-root", data.trustedRoot, hd.Doc(`
+		path to a Sigstore trusted_root.json file. When provided, or when any of --ca-roots,
+		--ctlog-public-key, or --rekor-public-key are provided, the verification material is not
+		fetched via TUF, and validation fails if material it requires is not provided. Overrides
+		trustedRoot from EnterpriseContractPolicy`))
+
+	cmd.Flags().StringVar(&data.caRoots, "ca-roots", data.caRoots,
+		"path to a PEM file with the root and intermediate certificates of the certificate authority for keyless verification")
+
+	cmd.Flags().StringVar(&data.ctLogPublicKey, "ctlog-public-key", data.ctLogPublicKey,
+		"path to a PEM file with the public key of the certificate transparency log for keyless verification")
+
+	cmd.Flags().StringVar(&data.rekorPublicKey, "rekor-public-key", data.rekorPublicKey,
+		"path to a PEM file with the public key of the Rekor transparency log")
+
+	cmd.Flags().StringVar(&data.tsaCertChain, "timestamp-certificate-chain", data.tsaCertChain, hd.Doc(`
+		path to a PEM file with the certificate chain of the RFC 3161 timestamp authority used to
+		verify signed timestamps of signatures and attestations. Must contain the root certificate.
+		Overrides tsaCertChain from EnterpriseContractPolicy`))
+
 	// Deprecated: images replaced this
 	cmd.Flags().StringVarP(&data.filePath, "file-path", "f", data.filePath,
 		"DEPRECATED - use --images: path to ApplicationSnapshot Spec JSON file")
@@ -401,7 +455,7 @@ func validateImageCmd(validate imageValidationFunc) *cobra.Command {
 	cmd.Flags().StringSliceVar(&data.output, "output", data.output, hd.Doc(`
 		write output to a file in a specific format. Use empty string path for stdout.
 		May be used multiple times. Possible formats are json, yaml, appstudio, junit,
-		summary, data, and policy-input.
+		summary, data, policy-input, explain, and coverage.
 	`))
 
 	cmd.Flags().StringVarP(&data.outputFile, "output-file", "o", data.outputFile,
@@ -434,3 +488,85 @@ func validateImageCmd(validate imageValidationFunc) *cobra.Command {
 
 	return cmd
 }
+
+// componentPolicy pairs a component policy with the policy derived from it.
+type componentPolicy struct {
+	policy.ComponentPolicy
+	policy policy.Policy
+}
+
+// loadComponentPolicies derives a policy for each of the component policies. The component
+// policies are read from the given file, if any, otherwise from the policy configuration.
+func loadComponentPolicies(ctx context.Context, p policy.Policy, file string) ([]componentPolicy, error) {
+	cps := p.Extensions().ComponentPolicies
+	if file != "" {
+		var err error
+		if cps, err = policy.LoadComponentPolicies(ctx, file); err != nil {
+			return nil, err
+		}
+	}
+
+	var allErrors error
+	componentPolicies := make([]componentPolicy, 0, len(cps))
+	for _, cp := range cps {
+		derived, err := p.WithComponentPolicy(ctx, cp)
+		if err != nil {
+			allErrors = multierror.Append(allErrors, err)
+			continue
+		}
+		componentPolicies = append(componentPolicies, componentPolicy{ComponentPolicy: cp, policy: derived})
+	}
+
+	return componentPolicies, allErrors
+}
+
+// policyForComponent returns the policy used to validate the given component, and the record
+// of it for the report. When no component policies are in use, the default policy is returned
+// and nothing is recorded.
+func policyForComponent(ctx context.Context, defaultPolicy policy.Policy, componentPolicies []componentPolicy, comp app.SnapshotComponent) (policy.Policy, *applicationsnapshot.AppliedPolicy, error) {
+	if len(componentPolicies) == 0 {
+		return defaultPolicy, nil, nil
+	}
+
+	cps := make([]policy.ComponentPolicy, 0, len(componentPolicies))
+	for _, cp := range componentPolicies {
+		cps = append(cps, cp.ComponentPolicy)
+	}
+
+	var labels map[string]string
+	fetchLabels := func() (map[string]string, error) {
+		if labels != nil {
+			return labels, nil
+		}
+		var err error
+		if labels, err = image.ImageLabels(ctx, comp.ContainerImage); err != nil {
+			return nil, fmt.Errorf("fetching labels of image %s: %w", comp.ContainerImage, err)
+		}
+		return labels, nil
+	}
+
+	selected, err := policy.SelectComponentPolicy(cps, comp, fetchLabels)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	p, name := defaultPolicy, policy.DefaultComponentPolicyName
+	if selected != nil {
+		for _, cp := range componentPolicies {
+			if cp.Name == selected.Name {
+				p, name = cp.policy, cp.Name
+			}
+		}
+	}
+
+	key, err := p.PublicKeyPEM()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return p, &applicationsnapshot.AppliedPolicy{
+		Name:    name,
+		Sources: p.Spec().Sources,
+		Key:     string(key),
+	}, nil
+}
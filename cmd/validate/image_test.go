@@ -21,8 +21,10 @@ package validate
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"sync"
 	"testing"
 	"time"
 
@@ -1032,3 +1034,108 @@ func setUpCobra(command *cobra.Command) *cobra.Command {
 	cmd.AddCommand(validateCmd)
 	return cmd
 }
+
+func Test_ValidateImageCommandComponentPolicies(t *testing.T) {
+	sources := map[string][]string{}
+	var mu sync.Mutex
+	validate := func(_ context.Context, component app.SnapshotComponent, p policy.Policy, _ bool) (*output.Output, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, s := range p.Spec().Sources {
+			sources[component.Name] = append(sources[component.Name], s.Name)
+		}
+		return &output.Output{ImageURL: component.ContainerImage}, nil
+	}
+
+	validateImageCmd := validateImageCmd(validate)
+	cmd := setUpCobra(validateImageCmd)
+
+	fs := afero.NewMemMapFs()
+	assert.NoError(t, afero.WriteFile(fs, "/component-policies.yaml", []byte(hd.Doc(`
+		- name: bundles
+		  match:
+		    name: "*-bundle"
+		  sources:
+		    - bundle
+		- name: base
+		  match:
+		    repository: registry.io/base/*
+		  sources:
+		    - base
+	`)), 0644))
+	cmd.SetContext(utils.WithFS(context.TODO(), fs))
+
+	cmd.SetArgs(append(rootArgs, []string{
+		"--images",
+		`{"components":[
+			{"name": "app", "containerImage": "registry.io/app/app:latest"},
+			{"name": "app-bundle", "containerImage": "registry.io/app/bundle:latest"},
+			{"name": "ubi", "containerImage": "registry.io/base/ubi:latest"}
+		]}`,
+		"--policy",
+		fmt.Sprintf(`{"publicKey": %s, "sources": [
+			{"name": "app", "policy": ["app"]},
+			{"name": "bundle", "policy": ["bundle"]},
+			{"name": "base", "policy": ["base"]}
+		]}`, utils.TestPublicKeyJSON),
+		"--component-policies",
+		"/component-policies.yaml",
+		"--strict=false",
+	}...))
+
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+
+	utils.SetTestRekorPublicKey(t)
+
+	err := cmd.Execute()
+	assert.NoError(t, err)
+
+	assert.Equal(t, map[string][]string{
+		"app":        {"app", "bundle", "base"},
+		"app-bundle": {"bundle"},
+		"ubi":        {"base"},
+	}, sources)
+
+	var report struct {
+		Components []struct {
+			Name   string                            `json:"name"`
+			Policy applicationsnapshot.AppliedPolicy `json:"policy"`
+		} `json:"components"`
+	}
+	assert.NoError(t, json.Unmarshal(out.Bytes(), &report))
+	applied := map[string]string{}
+	for _, c := range report.Components {
+		applied[c.Name] = c.Policy.Name
+		assert.Equal(t, utils.TestPublicKey, c.Policy.Key)
+	}
+	assert.Equal(t, map[string]string{
+		"app":        "default",
+		"app-bundle": "bundles",
+		"ubi":        "base",
+	}, applied)
+}
+
+func Test_ValidateImageCommandInvalidComponentPolicies(t *testing.T) {
+	validateImageCmd := validateImageCmd(nil)
+	cmd := setUpCobra(validateImageCmd)
+
+	cmd.SetContext(utils.WithFS(context.TODO(), afero.NewMemMapFs()))
+
+	cmd.SetArgs(append(rootArgs, []string{
+		"--image",
+		"registry/image:tag",
+		"--policy",
+		fmt.Sprintf(`{"publicKey": %s, "sources": [{"name": "app", "policy": ["app"]}], "componentPolicies": [
+			{"name": "bundles", "match": {"name": "*-bundle"}, "sources": ["bundle"]}
+		]}`, utils.TestPublicKeyJSON),
+	}...))
+
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+
+	utils.SetTestRekorPublicKey(t)
+
+	err := cmd.Execute()
+	assert.ErrorContains(t, err, `component policy "bundles" refers to unknown source group "bundle"`)
+}
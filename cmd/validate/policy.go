@@ -0,0 +1,145 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package validate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	hd "github.com/MakeNowJust/heredoc"
+	"github.com/spf13/cobra"
+
+	"github.com/enterprise-contract/ec-cli/internal/policy"
+	"github.com/enterprise-contract/ec-cli/internal/policy/lint"
+	"github.com/enterprise-contract/ec-cli/internal/policy/source"
+)
+
+type policyValidationFunc func(context.Context, policy.Policy) ([]lint.Issue, error)
+
+func validatePolicyCmd(validate policyValidationFunc) *cobra.Command {
+	var data = struct {
+		policyConfiguration string
+		output              string
+		strict              bool
+	}{
+		output: "text",
+		strict: true,
+	}
+
+	validFormats := []string{"text", "json"}
+
+	cmd := &cobra.Command{
+		Use:   "policy --policy <policy>",
+		Short: "Check a policy configuration for mistakes",
+
+		Long: hd.Doc(`
+			Check a policy configuration for mistakes.
+
+			The policy sources of each source group are fetched and the policy configuration is
+			checked against the rules found in them. The following problems are reported:
+
+			  * include and exclude entries not matching any rule, package or collection
+			  * volatile configuration with invalid, or inverted, effectiveOn and
+			    effectiveUntil dates
+			  * policy sources that cannot be fetched, or contain no annotated rules
+			  * rules defined in more than one policy source of a source group
+			  * ruleData keys not read by any rule
+
+			Include and exclude entries with typos are otherwise silently ignored when
+			validating. Problems reported as warnings do not cause a non-zero exit status.
+
+			Invalid source group options, exceptions and component policies are rejected before
+			checking, as when validating an image.
+		`),
+
+		Example: hd.Doc(`
+			Check the policy configuration in a file:
+
+			  ec validate policy --policy policy.yaml
+
+			Check the policy configuration from a cluster and output the problems in JSON format:
+
+			  ec validate policy --policy my-namespace/my-policy --output json
+		`),
+
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !slices.Contains(validFormats, data.output) {
+				return fmt.Errorf("invalid value for --output '%s'. accepted values: %s", data.output, strings.Join(validFormats, ", "))
+			}
+
+			ctx := cmd.Context()
+			policyConfiguration, err := source.ReadPolicyConfiguration(ctx, data.policyConfiguration)
+			if err != nil {
+				return err
+			}
+
+			p, err := policy.NewInertPolicyAt(ctx, policyConfiguration, policy.Now)
+			if err != nil {
+				return err
+			}
+
+			issues, err := validate(ctx, p)
+			if err != nil {
+				return err
+			}
+
+			out := cmd.OutOrStdout()
+			if data.output == "json" {
+				if issues == nil {
+					issues = []lint.Issue{}
+				}
+				if err := json.NewEncoder(out).Encode(issues); err != nil {
+					return err
+				}
+			} else {
+				for _, i := range issues {
+					fmt.Fprintln(out, i)
+				}
+			}
+
+			if data.strict && slices.ContainsFunc(issues, func(i lint.Issue) bool { return i.Severity == lint.SeverityError }) {
+				return errors.New("the policy configuration has errors")
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&data.policyConfiguration, "policy", "p", data.policyConfiguration, hd.Doc(`
+		Policy configuration as:
+		  * Kubernetes reference ([<namespace>/]<name>)
+		  * file (policy.yaml)
+		  * git reference (github.com/user/repo//default?ref=main), or
+		  * inline JSON ('{sources: {...}, configuration: {...}}')`))
+
+	cmd.Flags().StringVarP(&data.output, "output", "o", data.output,
+		fmt.Sprintf("output format. one of: %s", strings.Join(validFormats, ", ")))
+
+	cmd.Flags().BoolVarP(&data.strict, "strict", "s", data.strict,
+		"return non-zero status if errors are found. Use --strict=false to return a zero status code.")
+
+	if err := cmd.MarkFlagRequired("policy"); err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
@@ -0,0 +1,192 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unit
+
+package validate
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/enterprise-contract/ec-cli/internal/policy"
+	"github.com/enterprise-contract/ec-cli/internal/policy/lint"
+	"github.com/enterprise-contract/ec-cli/internal/utils"
+)
+
+func TestValidatePolicyCommand(t *testing.T) {
+	cases := []struct {
+		name     string
+		issues   []lint.Issue
+		args     []string
+		expected string
+		err      string
+	}{
+		{
+			name: "no issues",
+		},
+		{
+			name:     "warnings only",
+			issues:   []lint.Issue{{Severity: lint.SeverityWarning, Source: "release", Message: "something odd"}},
+			expected: "warning: release: something odd\n",
+		},
+		{
+			name: "errors",
+			issues: []lint.Issue{
+				{Severity: lint.SeverityError, Source: "release", Message: "something wrong"},
+				{Severity: lint.SeverityWarning, Source: "release", Message: "something odd"},
+			},
+			expected: "error: release: something wrong\nwarning: release: something odd\n",
+			err:      "the policy configuration has errors",
+		},
+		{
+			name:     "errors not strict",
+			issues:   []lint.Issue{{Severity: lint.SeverityError, Source: "release", Message: "something wrong"}},
+			args:     []string{"--strict=false"},
+			expected: "error: release: something wrong\n",
+		},
+		{
+			name:     "json",
+			issues:   []lint.Issue{{Severity: lint.SeverityError, Source: "release", Message: "something wrong"}},
+			args:     []string{"--output", "json", "--strict=false"},
+			expected: `[{"severity":"error","source":"release","message":"something wrong"}]` + "\n",
+		},
+		{
+			name:     "json no issues",
+			args:     []string{"--output", "json"},
+			expected: "[]\n",
+		},
+		{
+			name: "invalid output",
+			args: []string{"--output", "xml"},
+			err:  "invalid value for --output 'xml'. accepted values: text, json",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var sources []string
+			validate := func(_ context.Context, p policy.Policy) ([]lint.Issue, error) {
+				for _, s := range p.Spec().Sources {
+					sources = append(sources, s.Policy...)
+				}
+				return c.issues, nil
+			}
+
+			cmd := setUpCobra(validatePolicyCmd(validate))
+			cmd.SetContext(context.Background())
+
+			var out bytes.Buffer
+			cmd.SetOut(&out)
+			cmd.SetErr(&bytes.Buffer{})
+
+			cmd.SetArgs(append([]string{
+				"validate",
+				"policy",
+				"--policy",
+				`{"sources":[{"policy":["registry.io/policy:latest"]}]}`,
+			}, c.args...))
+
+			err := cmd.Execute()
+			if c.err != "" {
+				assert.EqualError(t, err, c.err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, c.expected, out.String())
+			if c.err == "" || c.issues != nil {
+				assert.Equal(t, []string{"registry.io/policy:latest"}, sources)
+			}
+		})
+	}
+}
+
+func TestValidatePolicyCommandFromFile(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	ctx := utils.WithFS(context.Background(), fs)
+	require.NoError(t, afero.WriteFile(fs, "policy.yaml", []byte(`
+sources:
+  - policy:
+      - registry.io/policy:latest
+`), 0644))
+	require.NoError(t, afero.WriteFile(fs, "empty.yaml", []byte{}, 0644))
+
+	var sources []string
+	validate := func(_ context.Context, p policy.Policy) ([]lint.Issue, error) {
+		for _, s := range p.Spec().Sources {
+			sources = append(sources, s.Policy...)
+		}
+		return nil, nil
+	}
+
+	cmd := setUpCobra(validatePolicyCmd(validate))
+	cmd.SetContext(ctx)
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+
+	cmd.SetArgs([]string{"validate", "policy", "--policy", "policy.yaml"})
+	assert.NoError(t, cmd.Execute())
+	assert.Equal(t, []string{"registry.io/policy:latest"}, sources)
+
+	cmd.SetArgs([]string{"validate", "policy", "--policy", "empty.yaml"})
+	assert.EqualError(t, cmd.Execute(), "file empty.yaml is empty")
+}
+
+func TestValidatePolicyCommandInvalidExtensions(t *testing.T) {
+	cases := []struct {
+		name   string
+		policy string
+		err    string
+	}{
+		{
+			name:   "rule precedence",
+			policy: `{"sources":[{"policy":["registry.io/policy:latest"],"rulePrecedence":"random"}]}`,
+			err:    `source group at index 0 has an invalid rulePrecedence "random", expecting one of: error, first, last`,
+		},
+		{
+			name:   "blob size limit",
+			policy: `{"sources":[{"policy":["registry.io/policy:latest"],"blobSizeLimit":"lots"}]}`,
+			err:    `source group at index 0 has an invalid blobSizeLimit "lots", expecting a positive quantity, e.g. 50Mi`,
+		},
+		{
+			name:   "exception expiry date",
+			policy: `{"sources":[{"policy":["registry.io/policy:latest"]}],"exceptions":[{"value":"pkg.rule","reason":"r","expiresOn":"soon"}]}`,
+			err:    `exception at index 0 has an invalid expiry date "soon"`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			validate := func(_ context.Context, _ policy.Policy) ([]lint.Issue, error) {
+				t.Fatal("the policy configuration should not be checked")
+				return nil, nil
+			}
+
+			cmd := setUpCobra(validatePolicyCmd(validate))
+			cmd.SetContext(context.Background())
+			cmd.SetOut(&bytes.Buffer{})
+			cmd.SetErr(&bytes.Buffer{})
+
+			cmd.SetArgs([]string{"validate", "policy", "--policy", c.policy})
+			assert.ErrorContains(t, cmd.Execute(), c.err)
+		})
+	}
+}
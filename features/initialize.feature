@@ -1,17 +1,28 @@
 Feature: init policies command
   The ec init policies command should work as expected
 
-  Background:
-    Given the environment variable is set "EC_EXPERIMENTAL=1"
-
   Scenario: success
     When ec command is run with "init policies --dest-dir=${TMPDIR}"
-    When ec command is run with "test --policy ${TMPDIR} acceptance/examples/empty_input.json -o json"
+    When ec command is run with "policy test ${TMPDIR}/policy"
     Then the exit status should be 0
     Then the output should match the snapshot
 
-  Scenario: appstudio success
+  Scenario: sample input
     When ec command is run with "init policies --dest-dir=${TMPDIR}"
-    When ec command is run with "test --policy ${TMPDIR} acceptance/examples/empty_input.json -o appstudio"
+    When ec command is run with "validate definition --file ${TMPDIR}/policy-input.json --policy ${TMPDIR}/policy --data ${TMPDIR}/data --show-successes"
     Then the exit status should be 0
     Then the output should match the snapshot
+
+  Scenario: sbom template
+    When ec command is run with "init policies --dest-dir=${TMPDIR} --template sbom"
+    When ec command is run with "policy test ${TMPDIR}/policy"
+    Then the exit status should be 0
+    When ec command is run with "validate definition --file ${TMPDIR}/policy-input.json --policy ${TMPDIR}/policy --data ${TMPDIR}/data"
+    Then the exit status should be 0
+
+  Scenario: tekton template
+    When ec command is run with "init policies --dest-dir=${TMPDIR} --template tekton"
+    When ec command is run with "policy test ${TMPDIR}/policy"
+    Then the exit status should be 0
+    When ec command is run with "validate definition --file ${TMPDIR}/policy-input.json --policy ${TMPDIR}/policy --data ${TMPDIR}/data"
+    Then the exit status should be 0
@@ -0,0 +1,48 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package applicationsnapshot
+
+import (
+	"bytes"
+	"fmt"
+	"text/tabwriter"
+)
+
+// renderCoverage renders, for each component, the disposition of every policy rule, and the
+// reason a rule did not produce a result.
+func (r *Report) renderCoverage() []byte {
+	var b bytes.Buffer
+	for i, c := range r.Components {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "Component: %s\nImageRef: %s\n\n", c.Name, c.ContainerImage)
+
+		if len(c.Coverage) == 0 {
+			b.WriteString("  No policy rules evaluated\n")
+			continue
+		}
+
+		w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+		for _, rc := range c.Coverage {
+			fmt.Fprintf(w, "  %s\t%s\t%s\n", rc.Code, rc.Disposition, rc.Reason)
+		}
+		_ = w.Flush()
+	}
+
+	return b.Bytes()
+}
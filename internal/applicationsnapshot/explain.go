@@ -0,0 +1,66 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package applicationsnapshot
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/enterprise-contract/ec-cli/internal/evaluator"
+)
+
+// renderExplanations renders, for each component, the violations and warnings explained by
+// the evaluator, i.e. the results of the rules selected with the --explain flag.
+func (r *Report) renderExplanations() []byte {
+	var b bytes.Buffer
+	for _, c := range r.Components {
+		var explained bytes.Buffer
+		for _, kind := range []struct {
+			name    string
+			results []evaluator.Result
+		}{
+			{"violation", c.Violations},
+			{"warning", c.Warnings},
+		} {
+			for _, result := range kind.results {
+				explanation, ok := result.Metadata["explanation"].(evaluator.Explanation)
+				if !ok {
+					continue
+				}
+				fmt.Fprintf(&explained, "\n%s %s: %s\n", kind.name, result.Metadata["code"], result.Message)
+				for _, line := range strings.Split(strings.TrimSuffix(explanation.String(), "\n"), "\n") {
+					fmt.Fprintf(&explained, "  %s\n", line)
+				}
+			}
+		}
+
+		if explained.Len() == 0 {
+			continue
+		}
+
+		fmt.Fprintf(&b, "Component: %s\nImageRef: %s\n", c.Name, c.ContainerImage)
+		b.Write(explained.Bytes())
+		b.WriteString("\n")
+	}
+
+	if b.Len() == 0 {
+		return []byte("No explanations, use --explain to select the rules to explain\n")
+	}
+
+	return b.Bytes()
+}
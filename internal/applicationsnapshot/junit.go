@@ -123,7 +123,8 @@ func (r *Report) toJUnit() junit.Testsuites {
 			return c
 		})
 
-		mapResults(&suite, component.Warnings, func(r evaluator.Result) junit.Testcase {
+		// Warnings, results waived by exceptions, and skipped results, did not fail nor pass
+		skipped := func(r evaluator.Result) junit.Testcase {
 			c := asTestCase(r)
 			c.Skipped = &junit.Result{
 				Message: r.Message,
@@ -131,7 +132,13 @@ func (r *Report) toJUnit() junit.Testsuites {
 			}
 
 			return c
-		})
+		}
+
+		mapResults(&suite, component.Warnings, skipped)
+
+		mapResults(&suite, component.Exceptions, skipped)
+
+		mapResults(&suite, component.Skipped, skipped)
 
 		report.AddSuite(suite)
 	}
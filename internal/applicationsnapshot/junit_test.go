@@ -231,6 +231,82 @@ func TestToJunit(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "exceptions and skipped",
+			report: Report{
+				Components: []Component{
+					{
+						SnapshotComponent: app.SnapshotComponent{
+							Name:           "Name",
+							ContainerImage: "registry.io/repository/image:tag",
+						},
+						Exceptions: []evaluator.Result{
+							{
+								Message: "exception",
+								Metadata: map[string]interface{}{
+									"code": "exception",
+								},
+							},
+						},
+						Skipped: []evaluator.Result{
+							{
+								Message: "skipped",
+								Metadata: map[string]interface{}{
+									"code": "skipped",
+								},
+							},
+						},
+						Success: true,
+					},
+				},
+				Key:     "key",
+				Success: true,
+			},
+			expected: junit.Testsuites{
+				Tests:   2,
+				Skipped: 2,
+				Suites: []junit.Testsuite{
+					{
+						Name:      "Name (registry.io/repository/image:tag)",
+						Timestamp: "0001-01-01T00:00:00Z",
+						Tests:     2,
+						Skipped:   2,
+						Properties: &[]junit.Property{
+							{
+								Name:  "image",
+								Value: "registry.io/repository/image:tag",
+							},
+							{
+								Name:  "key",
+								Value: "key",
+							},
+							{
+								Name:  "success",
+								Value: "true",
+							},
+						},
+						Testcases: []junit.Testcase{
+							{
+								Name:      "exception: exception",
+								Classname: "exception: exception",
+								Skipped: &junit.Result{
+									Message: "exception",
+									Data:    "exception",
+								},
+							},
+							{
+								Name:      "skipped: skipped",
+								Classname: "skipped: skipped",
+								Skipped: &junit.Result{
+									Message: "skipped",
+									Data:    "skipped",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
 	}
 
 	for _, c := range cases {
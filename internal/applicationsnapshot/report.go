@@ -40,11 +40,24 @@ type Component struct {
 	app.SnapshotComponent
 	Violations   []evaluator.Result          `json:"violations,omitempty"`
 	Warnings     []evaluator.Result          `json:"warnings,omitempty"`
+	Exceptions   []evaluator.Result          `json:"exceptions,omitempty"`
+	Skipped      []evaluator.Result          `json:"skipped,omitempty"`
 	Successes    []evaluator.Result          `json:"successes,omitempty"`
 	Success      bool                        `json:"success"`
 	SuccessCount int                         `json:"-"`
 	Signatures   []signature.EntitySignature `json:"signatures,omitempty"`
 	Attestations []attestation.Attestation   `json:"attestations,omitempty"`
+	Policy       *AppliedPolicy              `json:"policy,omitempty"`
+	Coverage     []evaluator.RuleCoverage    `json:"-"`
+}
+
+// AppliedPolicy records the policy used to validate a component when component policies are
+// in use. Components not matching any component policy are recorded with the name
+// policy.DefaultComponentPolicyName.
+type AppliedPolicy struct {
+	Name    string       `json:"name"`
+	Sources []ecc.Source `json:"sources,omitempty"`
+	Key     string       `json:"key,omitempty"`
 }
 
 type Report struct {
@@ -73,9 +86,13 @@ type componentSummary struct {
 	Violations      map[string][]string `json:"violations"`
 	Warnings        map[string][]string `json:"warnings"`
 	Successes       map[string][]string `json:"successes"`
+	Exceptions      map[string][]string `json:"exceptions,omitempty"`
+	Skipped         map[string][]string `json:"skipped,omitempty"`
 	TotalViolations int                 `json:"total_violations"`
 	TotalWarnings   int                 `json:"total_warnings"`
 	TotalSuccesses  int                 `json:"total_successes"`
+	TotalExceptions int                 `json:"total_exceptions,omitempty"`
+	TotalSkipped    int                 `json:"total_skipped,omitempty"`
 }
 
 // TestReport represents the standardized TEST_OUTPUT format.
@@ -88,8 +105,12 @@ type TestReport struct {
 	Successes int    `json:"successes"`
 	Failures  int    `json:"failures"`
 	Warnings  int    `json:"warnings"`
-	Result    string `json:"result"`
-	Note      string `json:"note,omitempty"`
+	// Exceptions and Skipped are not part of the standardized format, they are
+	// omitted when zero to not disturb consumers unaware of them.
+	Exceptions int    `json:"exceptions,omitempty"`
+	Skipped    int    `json:"skipped,omitempty"`
+	Result     string `json:"result"`
+	Note       string `json:"note,omitempty"`
 }
 
 // Possible formats the report can be written as.
@@ -106,6 +127,8 @@ const (
 	ATTESTATION = "attestation"
 	PolicyInput = "policy-input"
 	VSA         = "vsa"
+	EXPLAIN     = "explain"
+	COVERAGE    = "coverage"
 )
 
 // WriteReport returns a new instance of Report representing the state of
@@ -194,6 +217,10 @@ func (r *Report) toFormat(format string) (data []byte, err error) {
 		data = bytes.Join(r.PolicyInput, []byte("\n"))
 	case VSA:
 		data, err = r.toVSA()
+	case EXPLAIN:
+		data = r.renderExplanations()
+	case COVERAGE:
+		data = r.renderCoverage()
 	default:
 		return nil, fmt.Errorf("%q is not a valid report format", format)
 	}
@@ -225,12 +252,21 @@ func (r *Report) toSummary() summary {
 			// flag was set, cmp.SuccessCount is used here instead of len(cmp.Successes)
 			TotalSuccesses: cmp.SuccessCount,
 
+			TotalExceptions: len(cmp.Exceptions),
+			TotalSkipped:    len(cmp.Skipped),
+
 			Success:    cmp.Success,
 			Name:       cmp.Name,
 			Violations: condensedMsg(cmp.Violations),
 			Warnings:   condensedMsg(cmp.Warnings),
 			Successes:  condensedMsg(cmp.Successes),
 		}
+		if len(cmp.Exceptions) > 0 {
+			c.Exceptions = condensedMsg(cmp.Exceptions)
+		}
+		if len(cmp.Skipped) > 0 {
+			c.Skipped = condensedMsg(cmp.Skipped)
+		}
 		pr.Components = append(pr.Components, c)
 	}
 	pr.Key = r.Key
@@ -266,12 +302,14 @@ func generateMarkdownSummary(r *Report) ([]byte, error) {
 	markdownBuffer.WriteString("| Field     | Value |Status|\n")
 	markdownBuffer.WriteString("|-----------|-------|-------|\n")
 
-	var totalViolations, totalWarnings, totalSuccesses int
+	var totalViolations, totalWarnings, totalSuccesses, totalExceptions, totalSkipped int
 	pr := r.toSummary()
 	for _, component := range pr.Components {
 		totalViolations += component.TotalViolations
 		totalWarnings += component.TotalWarnings
 		totalSuccesses += component.TotalSuccesses
+		totalExceptions += component.TotalExceptions
+		totalSkipped += component.TotalSkipped
 	}
 
 	writeIcon := func(condition bool) string {
@@ -285,6 +323,13 @@ func generateMarkdownSummary(r *Report) ([]byte, error) {
 	writeMarkdownField(&markdownBuffer, "Successes", totalSuccesses, writeIcon(totalSuccesses >= 1 && totalViolations == 0))
 	writeMarkdownField(&markdownBuffer, "Failures", totalViolations, writeIcon(totalViolations == 0))
 	writeMarkdownField(&markdownBuffer, "Warnings", totalWarnings, writeIcon(totalWarnings == 0))
+	// Only shown when present to keep the summary short
+	if totalExceptions > 0 {
+		writeMarkdownField(&markdownBuffer, "Exceptions", totalExceptions, ":information_source:")
+	}
+	if totalSkipped > 0 {
+		writeMarkdownField(&markdownBuffer, "Skipped", totalSkipped, ":information_source:")
+	}
 	writeMarkdownField(&markdownBuffer, "Result", "", writeIcon(r.Success))
 	return markdownBuffer.Bytes(), nil
 }
@@ -310,6 +355,8 @@ func (r *Report) toAppstudioReport() TestReport {
 		result.Failures += component.TotalViolations
 		result.Warnings += component.TotalWarnings
 		result.Successes += component.TotalSuccesses
+		result.Exceptions += component.TotalExceptions
+		result.Skipped += component.TotalSkipped
 
 		if !component.Success {
 			// It is possible, although quite unusual, that a component has no
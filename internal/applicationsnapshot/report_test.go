@@ -234,6 +234,22 @@ func Test_GenerateMarkdownSummary(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "With exceptions and skipped",
+			components: []Component{
+				{
+					SuccessCount: 1,
+					Exceptions: []evaluator.Result{
+						{Message: "Exception1"},
+					},
+					Skipped: []evaluator.Result{
+						{Message: "Skipped1"},
+						{Message: "Skipped2"},
+					},
+					Success: true,
+				},
+			},
+		},
 		{
 			name:     "With Snapshot",
 			snapshot: "snappy",
@@ -317,6 +333,48 @@ func Test_ReportSummary(t *testing.T) {
 				Key:     utils.TestPublicKey,
 			},
 		},
+		{
+			name: "testing exceptions and skipped",
+			input: Component{
+				Exceptions: []evaluator.Result{
+					{
+						Message: "waived",
+						Metadata: map[string]interface{}{
+							"code": "waived_name",
+						},
+					},
+				},
+				Skipped: []evaluator.Result{
+					{
+						Message: "skipped",
+						Metadata: map[string]interface{}{
+							"code": "skipped_name",
+						},
+					},
+				},
+				Success: true,
+			},
+			want: summary{
+				Components: []componentSummary{
+					{
+						Violations: map[string][]string{},
+						Warnings:   map[string][]string{},
+						Successes:  map[string][]string{},
+						Exceptions: map[string][]string{
+							"waived_name": {"waived"},
+						},
+						Skipped: map[string][]string{
+							"skipped_name": {"skipped"},
+						},
+						TotalExceptions: 1,
+						TotalSkipped:    1,
+						Success:         true,
+					},
+				},
+				Success: false,
+				Key:     utils.TestPublicKey,
+			},
+		},
 		{
 			name: "testing no metadata",
 			input: Component{
@@ -538,6 +596,25 @@ func Test_ReportAppstudio(t *testing.T) {
 			},
 			success: true,
 		},
+		{
+			name: "exceptions and skipped",
+			expected: `
+			{
+				"exceptions": 1,
+				"failures": 0,
+				"namespace": "",
+				"result": "SUCCESS",
+				"skipped": 2,
+				"successes": 1,
+				"timestamp": "0",
+				"warnings": 0
+			}`,
+			components: []Component{
+				{Success: true, SuccessCount: 1, Exceptions: []evaluator.Result{{Message: "waived"}}},
+				{Success: true, Skipped: []evaluator.Result{{Message: "skipped 1"}, {Message: "skipped 2"}}},
+			},
+			success: true,
+		},
 		{
 			name: "warning",
 			expected: `
@@ -832,6 +909,97 @@ func Test_ReportPolicyInput(t *testing.T) {
 	matchesJSONLFile(t, fs, policyInput, "default")
 }
 
+func Test_ReportExplain(t *testing.T) {
+	explanation := evaluator.Explanation{
+		Location:   "policy.rego:10",
+		Steps:      []string{"11: input.x == 1"},
+		InputPaths: []string{"input.x"},
+	}
+
+	components := []Component{
+		{
+			SnapshotComponent: app.SnapshotComponent{Name: "first", ContainerImage: "registry.io/first:v1"},
+			Violations: []evaluator.Result{
+				{Message: "explained", Metadata: map[string]any{"code": "pkg.rule", "explanation": explanation}},
+				{Message: "not explained", Metadata: map[string]any{"code": "pkg.other"}},
+			},
+			Warnings: []evaluator.Result{
+				{Message: "explained warning", Metadata: map[string]any{"code": "pkg.warn", "explanation": explanation}},
+			},
+		},
+		{
+			SnapshotComponent: app.SnapshotComponent{Name: "second", ContainerImage: "registry.io/second:v1"},
+			Violations: []evaluator.Result{
+				{Message: "not explained", Metadata: map[string]any{"code": "pkg.other"}},
+			},
+		},
+	}
+
+	ctx := context.Background()
+	report, err := NewReport("snapshot", components, createTestPolicy(t, ctx), nil, nil)
+	require.NoError(t, err)
+
+	data, err := report.toFormat(EXPLAIN)
+	require.NoError(t, err)
+	assert.Equal(t, `Component: first
+ImageRef: registry.io/first:v1
+
+violation pkg.rule: explained
+  rule at policy.rego:10
+  evaluated:
+    11: input.x == 1
+  input paths:
+    input.x
+
+warning pkg.warn: explained warning
+  rule at policy.rego:10
+  evaluated:
+    11: input.x == 1
+  input paths:
+    input.x
+
+`, string(data))
+
+	report, err = NewReport("snapshot", components[1:], createTestPolicy(t, ctx), nil, nil)
+	require.NoError(t, err)
+
+	data, err = report.toFormat(EXPLAIN)
+	require.NoError(t, err)
+	assert.Equal(t, "No explanations, use --explain to select the rules to explain\n", string(data))
+}
+
+func Test_ReportCoverage(t *testing.T) {
+	components := []Component{
+		{
+			SnapshotComponent: app.SnapshotComponent{Name: "first", ContainerImage: "registry.io/first:v1"},
+			Coverage: []evaluator.RuleCoverage{
+				{Code: "pkg.failure", Disposition: evaluator.DispositionFailed},
+				{Code: "pkg.excluded_rule", Disposition: evaluator.DispositionExcluded, Reason: "excluded by `exclude: [pkg.excluded_rule]` (score 110 vs 1)"},
+			},
+		},
+		{
+			SnapshotComponent: app.SnapshotComponent{Name: "second", ContainerImage: "registry.io/second:v1"},
+		},
+	}
+
+	ctx := context.Background()
+	report, err := NewReport("snapshot", components, createTestPolicy(t, ctx), nil, nil)
+	require.NoError(t, err)
+
+	data, err := report.toFormat(COVERAGE)
+	require.NoError(t, err)
+	assert.Equal(t, "Component: first\n"+
+		"ImageRef: registry.io/first:v1\n"+
+		"\n"+
+		"  pkg.failure        failed    \n"+
+		"  pkg.excluded_rule  excluded  excluded by `exclude: [pkg.excluded_rule]` (score 110 vs 1)\n"+
+		"\n"+
+		"Component: second\n"+
+		"ImageRef: registry.io/second:v1\n"+
+		"\n"+
+		"  No policy rules evaluated\n", string(data))
+}
+
 func matchesJSONLFile(t *testing.T, fs afero.Fs, expected [][]byte, filename string) {
 	f, err := fs.Open(filename)
 	require.NoError(t, err)
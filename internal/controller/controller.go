@@ -0,0 +1,477 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package controller validates AppStudio Snapshots in a Kubernetes cluster as they are
+// created, recording the outcome on the Snapshot and as a Kubernetes Event.
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	ecc "github.com/enterprise-contract/enterprise-contract-controller/api/v1alpha1"
+	app "github.com/redhat-appstudio/application-api/api/v1alpha1"
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+
+	"github.com/enterprise-contract/ec-cli/internal/kubernetes"
+	"github.com/enterprise-contract/ec-cli/internal/output"
+	"github.com/enterprise-contract/ec-cli/internal/policy"
+)
+
+const (
+	// PolicyAnnotation is the annotation of a Snapshot referencing the EnterpriseContractPolicy
+	// to validate it against, as [namespace/]name, when no policy is set in the Options. The
+	// policy needs to be in the namespace of the Snapshot.
+	PolicyAnnotation = "enterprisecontract.dev/policy"
+
+	// ConditionType is the type of the status condition recording the outcome of the
+	// validation of a Snapshot. The condition is true when all of its components pass.
+	ConditionType = "EnterpriseContractCompliant"
+
+	// Reasons of the status condition and of the Events.
+	ReasonPassed = "Passed"
+	ReasonFailed = "Failed"
+	ReasonError  = "Error"
+
+	// eventSource is the component reported as the source of the Events.
+	eventSource = "ec-controller"
+
+	// maxRetries is the number of times the validation of a Snapshot is retried when the
+	// outcome is an error or could not be recorded.
+	maxRetries = 5
+)
+
+// errValidation is returned by reconcile when the outcome of the validation of a Snapshot is
+// an error, e.g. an image could not be fetched, so the validation is retried.
+var errValidation = errors.New("unable to validate")
+
+var (
+	snapshotsResource = app.GroupVersion.WithResource("snapshots")
+	policiesResource  = ecc.GroupVersion.WithResource("enterprisecontractpolicies")
+	eventsResource    = schema.GroupVersionResource{Version: "v1", Resource: "events"}
+)
+
+// ValidateFunc validates the image of a component of a Snapshot against the policy.
+type ValidateFunc func(context.Context, app.SnapshotComponent, policy.Policy, bool) (*output.Output, error)
+
+// Options configure which Snapshots are validated and how.
+type Options struct {
+	// Namespace of the Snapshots to validate, all namespaces if empty.
+	Namespace string
+	// LabelSelector selects the Snapshots to validate, all Snapshots if empty.
+	LabelSelector string
+	// Policy is the EnterpriseContractPolicy, as [namespace/]name, used for all Snapshots.
+	// References without a namespace refer to the namespace of the Snapshot. When empty, the
+	// PolicyAnnotation of the Snapshots is used.
+	Policy string
+	// EffectiveTime is the effective time of the policy, see policy.Options.
+	EffectiveTime string
+	// Workers is the number of Snapshots validated concurrently.
+	Workers int
+	// ResyncPeriod is how often all Snapshots are reconsidered, Snapshots are validated once
+	// per generation unless the outcome is an error.
+	ResyncPeriod time.Duration
+}
+
+// Controller validates the Snapshots selected by its Options as they are created or changed.
+type Controller struct {
+	client   dynamic.Interface
+	validate ValidateFunc
+	options  Options
+	queue    workqueue.RateLimitingInterface
+}
+
+// New constructs a Controller using the given dynamic client.
+func New(client dynamic.Interface, validate ValidateFunc, opts Options) (*Controller, error) {
+	if _, err := labels.Parse(opts.LabelSelector); err != nil {
+		return nil, fmt.Errorf("invalid label selector %q: %w", opts.LabelSelector, err)
+	}
+
+	if opts.Workers < 1 {
+		return nil, fmt.Errorf("invalid number of workers %d, expecting at least 1", opts.Workers)
+	}
+
+	return &Controller{
+		client:   client,
+		validate: validate,
+		options:  opts,
+		queue:    workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+	}, nil
+}
+
+// Run watches the Snapshots and validates them until the context is done.
+func (c *Controller) Run(ctx context.Context) error {
+	defer c.queue.ShutDown()
+
+	factory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(c.client, c.options.ResyncPeriod, c.options.Namespace, func(o *v1.ListOptions) {
+		o.LabelSelector = c.options.LabelSelector
+	})
+	defer factory.Shutdown()
+
+	informer := factory.ForResource(snapshotsResource).Informer()
+	if _, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: c.enqueue,
+		UpdateFunc: func(old, obj any) {
+			// updates of the status, e.g. recording the outcome, do not change the generation
+			// and are skipped, periodic resyncs do not change the resource version
+			o, err := meta.Accessor(old)
+			if err != nil {
+				return
+			}
+			n, err := meta.Accessor(obj)
+			if err != nil {
+				return
+			}
+			if o.GetGeneration() != n.GetGeneration() || o.GetResourceVersion() == n.GetResourceVersion() {
+				c.enqueue(obj)
+			}
+		},
+	}); err != nil {
+		return err
+	}
+
+	factory.Start(ctx.Done())
+	if !cache.WaitForCacheSync(ctx.Done(), informer.HasSynced) {
+		return errors.New("unable to list the snapshots")
+	}
+	log.Infof("Watching snapshots in %s", c.scope())
+
+	var wg sync.WaitGroup
+	for i := 0; i < c.options.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for c.processNext(ctx) {
+			}
+		}()
+	}
+
+	<-ctx.Done()
+	c.queue.ShutDown()
+	wg.Wait()
+
+	return nil
+}
+
+func (c *Controller) scope() string {
+	scope := "all namespaces"
+	if c.options.Namespace != "" {
+		scope = fmt.Sprintf("namespace %q", c.options.Namespace)
+	}
+
+	if c.options.LabelSelector != "" {
+		scope += fmt.Sprintf(" matching %q", c.options.LabelSelector)
+	}
+
+	return scope
+}
+
+func (c *Controller) enqueue(obj any) {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		log.Warnf("Unable to determine the key of %v: %v", obj, err)
+		return
+	}
+
+	c.queue.Add(key)
+}
+
+func (c *Controller) processNext(ctx context.Context) bool {
+	key, shutdown := c.queue.Get()
+	if shutdown {
+		return false
+	}
+	defer c.queue.Done(key)
+
+	if err := c.reconcile(ctx, key.(string)); err != nil {
+		if c.queue.NumRequeues(key) < maxRetries {
+			log.Warnf("Unable to reconcile snapshot %s, retrying: %v", key, err)
+			c.queue.AddRateLimited(key)
+			return true
+		}
+		log.Errorf("Unable to reconcile snapshot %s: %v", key, err)
+	}
+
+	c.queue.Forget(key)
+	return true
+}
+
+// reconcile validates the Snapshot with the given key, unless the current generation of the
+// Snapshot was validated already, and records the outcome. Errors recording the outcome are
+// returned, as well as errValidation when the outcome is an error so the validation is
+// retried. Generations with an error outcome are not considered validated.
+func (c *Controller) reconcile(ctx context.Context, key string) error {
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return err
+	}
+
+	snapshots := c.client.Resource(snapshotsResource).Namespace(namespace)
+	u, err := snapshots.Get(ctx, name, v1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		// deleted in the meantime
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	snapshot := app.Snapshot{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.UnstructuredContent(), &snapshot); err != nil {
+		return err
+	}
+
+	current := meta.FindStatusCondition(snapshot.Status.Conditions, ConditionType)
+	if current != nil && current.ObservedGeneration == snapshot.Generation && current.Reason != ReasonError {
+		log.Debugf("Snapshot %s was validated already", key)
+		return nil
+	}
+
+	log.Infof("Validating snapshot %s", key)
+	o := c.validateSnapshot(ctx, &snapshot)
+	log.Infof("Validated snapshot %s: %s", key, o.message)
+
+	// retrying a validation with the same outcome does not create another event
+	repeated := current != nil && current.ObservedGeneration == snapshot.Generation && current.Reason == o.reason && current.Message == o.message
+
+	meta.SetStatusCondition(&snapshot.Status.Conditions, o.condition(snapshot.Generation))
+	conditions := make([]any, 0, len(snapshot.Status.Conditions))
+	for i := range snapshot.Status.Conditions {
+		condition, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&snapshot.Status.Conditions[i])
+		if err != nil {
+			return err
+		}
+		conditions = append(conditions, condition)
+	}
+	if err := unstructured.SetNestedSlice(u.Object, conditions, "status", "conditions"); err != nil {
+		return err
+	}
+
+	if _, err := snapshots.UpdateStatus(ctx, u, v1.UpdateOptions{}); err != nil {
+		return err
+	}
+
+	// the outcome is recorded, so failing to create the event is not retried
+	if !repeated {
+		if err := c.recordEvent(ctx, &snapshot, o); err != nil {
+			log.Warnf("Unable to create the event for snapshot %s: %v", key, err)
+		}
+	}
+
+	if o.reason == ReasonError {
+		return fmt.Errorf("%w: %s", errValidation, o.message)
+	}
+
+	return nil
+}
+
+type outcome struct {
+	reason  string
+	message string
+}
+
+func (o outcome) condition(generation int64) v1.Condition {
+	status := v1.ConditionFalse
+	if o.reason == ReasonPassed {
+		status = v1.ConditionTrue
+	}
+
+	return v1.Condition{
+		Type:               ConditionType,
+		Status:             status,
+		ObservedGeneration: generation,
+		Reason:             o.reason,
+		Message:            o.message,
+	}
+}
+
+// policyRef returns the reference to the EnterpriseContractPolicy of the Snapshot, in the
+// namespace of the Snapshot unless given. The policy of the Options takes precedence over the
+// PolicyAnnotation, which cannot reference policies in other namespaces. An empty reference is
+// returned when neither is set.
+func (c *Controller) policyRef(snapshot *app.Snapshot) (string, error) {
+	if c.options.Policy != "" {
+		return qualifiedRef(snapshot.Namespace, c.options.Policy), nil
+	}
+
+	ref := snapshot.Annotations[PolicyAnnotation]
+	if ref == "" {
+		return "", nil
+	}
+
+	ref = qualifiedRef(snapshot.Namespace, ref)
+	if namespace, _, _ := strings.Cut(ref, "/"); namespace != snapshot.Namespace {
+		return ref, fmt.Errorf("the %s annotation can only reference policies in the namespace %s", PolicyAnnotation, snapshot.Namespace)
+	}
+
+	return ref, nil
+}
+
+// qualifiedRef returns the reference with the given namespace unless it has one.
+func qualifiedRef(namespace, ref string) string {
+	if strings.Contains(ref, "/") {
+		return ref
+	}
+
+	return namespace + "/" + ref
+}
+
+// policyDocument fetches the EnterpriseContractPolicy, referenced as namespace/name, as a JSON
+// document. Unlike the policy fetched by reference, the document keeps the policy.Extensions
+// of its spec, so that Snapshots are validated as with the same policy provided as a file.
+// The extensions are only present if the EnterpriseContractPolicy CRD of the cluster keeps
+// fields it does not define, the API server prunes them otherwise.
+func (c *Controller) policyDocument(ctx context.Context, ref string) (string, error) {
+	namespace, name, _ := strings.Cut(ref, "/")
+	u, err := c.client.Resource(policiesResource).Namespace(namespace).Get(ctx, name, v1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("unable to fetch EnterpriseContractPolicy: %w", err)
+	}
+
+	document, err := u.MarshalJSON()
+	if err != nil {
+		return "", err
+	}
+
+	return string(document), nil
+}
+
+func (c *Controller) validateSnapshot(ctx context.Context, snapshot *app.Snapshot) outcome {
+	ref, err := c.policyRef(snapshot)
+	if err != nil {
+		return outcome{
+			reason:  ReasonError,
+			message: fmt.Sprintf("Unable to use the EnterpriseContractPolicy %s: %v", ref, err),
+		}
+	}
+	if ref == "" {
+		return outcome{
+			reason:  ReasonError,
+			message: fmt.Sprintf("No EnterpriseContractPolicy given, set the %s annotation", PolicyAnnotation),
+		}
+	}
+
+	// the policy, and the keys it references, are fetched from the cluster the snapshot is in
+	ctx = kubernetes.WithClient(ctx, kubernetes.NewClientFor(c.client))
+	document, err := c.policyDocument(ctx, ref)
+	if err != nil {
+		return outcome{
+			reason:  ReasonError,
+			message: fmt.Sprintf("Unable to use the EnterpriseContractPolicy %s: %v", ref, err),
+		}
+	}
+
+	p, err := policy.NewPolicy(ctx, policy.Options{
+		EffectiveTime: c.options.EffectiveTime,
+		PolicyRef:     document,
+	})
+	if err != nil {
+		return outcome{
+			reason:  ReasonError,
+			message: fmt.Sprintf("Unable to use the EnterpriseContractPolicy %s: %v", ref, err),
+		}
+	}
+
+	passed, violations, warnings := 0, 0, 0
+	var errs []string
+	for _, comp := range snapshot.Spec.Components {
+		out, err := c.validate(ctx, comp, p, false)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("unable to validate component %s: %v", comp.Name, err))
+			continue
+		}
+
+		v := len(out.Violations())
+		if v == 0 {
+			passed++
+		}
+		violations += v
+		warnings += len(out.Warnings())
+	}
+
+	o := outcome{
+		reason: ReasonPassed,
+		message: fmt.Sprintf("%d of %d components passed the EnterpriseContractPolicy %s with %d violations and %d warnings",
+			passed, len(snapshot.Spec.Components), ref, violations, warnings),
+	}
+	if passed < len(snapshot.Spec.Components) {
+		o.reason = ReasonFailed
+	}
+	if len(errs) > 0 {
+		o.reason = ReasonError
+		o.message += ", " + strings.Join(errs, ", ")
+	}
+
+	return o
+}
+
+func (c *Controller) recordEvent(ctx context.Context, snapshot *app.Snapshot, o outcome) error {
+	eventType := corev1.EventTypeNormal
+	if o.reason != ReasonPassed {
+		eventType = corev1.EventTypeWarning
+	}
+
+	now := v1.Now()
+	event := corev1.Event{
+		TypeMeta: v1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Event",
+		},
+		ObjectMeta: v1.ObjectMeta{
+			Name:      fmt.Sprintf("%s.%x", snapshot.Name, now.UnixNano()),
+			Namespace: snapshot.Namespace,
+		},
+		InvolvedObject: corev1.ObjectReference{
+			APIVersion:      app.GroupVersion.String(),
+			Kind:            "Snapshot",
+			Name:            snapshot.Name,
+			Namespace:       snapshot.Namespace,
+			UID:             snapshot.UID,
+			ResourceVersion: snapshot.ResourceVersion,
+		},
+		Reason:         o.reason,
+		Message:        o.message,
+		Type:           eventType,
+		Source:         corev1.EventSource{Component: eventSource},
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          1,
+	}
+
+	u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&event)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.client.Resource(eventsResource).Namespace(snapshot.Namespace).Create(ctx, &unstructured.Unstructured{Object: u}, v1.CreateOptions{})
+	return err
+}
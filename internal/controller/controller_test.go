@@ -0,0 +1,443 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unit
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	ecc "github.com/enterprise-contract/enterprise-contract-controller/api/v1alpha1"
+	app "github.com/redhat-appstudio/application-api/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/fake"
+
+	"github.com/enterprise-contract/ec-cli/internal/evaluator"
+	"github.com/enterprise-contract/ec-cli/internal/output"
+	"github.com/enterprise-contract/ec-cli/internal/policy"
+	"github.com/enterprise-contract/ec-cli/internal/utils"
+)
+
+func testPolicy(namespace, name string) *ecc.EnterpriseContractPolicy {
+	return &ecc.EnterpriseContractPolicy{
+		TypeMeta: v1.TypeMeta{
+			Kind:       "EnterpriseContractPolicy",
+			APIVersion: ecc.GroupVersion.String(),
+		},
+		ObjectMeta: v1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Spec: ecc.EnterpriseContractPolicySpec{
+			PublicKey: utils.TestPublicKey,
+		},
+	}
+}
+
+func testSnapshot(name string, annotations map[string]string, images ...string) *app.Snapshot {
+	s := &app.Snapshot{
+		TypeMeta: v1.TypeMeta{
+			Kind:       "Snapshot",
+			APIVersion: app.GroupVersion.String(),
+		},
+		ObjectMeta: v1.ObjectMeta{
+			Name:        name,
+			Namespace:   "test",
+			Generation:  1,
+			Annotations: annotations,
+			Labels:      map[string]string{"release": "true"},
+		},
+	}
+	for _, image := range images {
+		s.Spec.Components = append(s.Spec.Components, app.SnapshotComponent{Name: image, ContainerImage: image})
+	}
+
+	return s
+}
+
+func fakeClient(t *testing.T, objects ...runtime.Object) dynamic.Interface {
+	scheme := runtime.NewScheme()
+	require.NoError(t, ecc.AddToScheme(scheme))
+	require.NoError(t, app.AddToScheme(scheme))
+	require.NoError(t, corev1.AddToScheme(scheme))
+
+	return fake.NewSimpleDynamicClient(scheme, objects...)
+}
+
+// fakeValidate fails the images starting with "bad", warns about the ones starting with
+// "old" and errors on the ones starting with "missing".
+func fakeValidate(_ context.Context, comp app.SnapshotComponent, _ policy.Policy, _ bool) (*output.Output, error) {
+	out := &output.Output{ImageURL: comp.ContainerImage}
+	outcome := evaluator.Outcome{}
+	switch comp.ContainerImage[0:3] {
+	case "bad":
+		outcome.Failures = []evaluator.Result{{Message: "bad image"}}
+	case "old":
+		outcome.Warnings = []evaluator.Result{{Message: "old image"}}
+	case "mis":
+		return nil, errors.New("image not found")
+	}
+	out.PolicyCheck = []evaluator.Outcome{outcome}
+
+	return out, nil
+}
+
+func snapshotCondition(t *testing.T, client dynamic.Interface, name string) *v1.Condition {
+	u, err := client.Resource(snapshotsResource).Namespace("test").Get(context.Background(), name, v1.GetOptions{})
+	require.NoError(t, err)
+
+	snapshot := app.Snapshot{}
+	require.NoError(t, runtime.DefaultUnstructuredConverter.FromUnstructured(u.UnstructuredContent(), &snapshot))
+
+	return meta.FindStatusCondition(snapshot.Status.Conditions, ConditionType)
+}
+
+func events(t *testing.T, client dynamic.Interface) []corev1.Event {
+	list, err := client.Resource(eventsResource).Namespace("test").List(context.Background(), v1.ListOptions{})
+	require.NoError(t, err)
+
+	events := make([]corev1.Event, 0, len(list.Items))
+	for _, u := range list.Items {
+		event := corev1.Event{}
+		require.NoError(t, runtime.DefaultUnstructuredConverter.FromUnstructured(u.UnstructuredContent(), &event))
+		events = append(events, event)
+	}
+
+	return events
+}
+
+func TestReconcile(t *testing.T) {
+	cases := []struct {
+		name      string
+		snapshot  *app.Snapshot
+		policy    string
+		status    v1.ConditionStatus
+		reason    string
+		message   string
+		eventType string
+	}{
+		{
+			name:      "passed",
+			snapshot:  testSnapshot("snapshot", nil, "good1", "old1"),
+			policy:    "policy",
+			status:    v1.ConditionTrue,
+			reason:    ReasonPassed,
+			message:   "2 of 2 components passed the EnterpriseContractPolicy test/policy with 0 violations and 1 warnings",
+			eventType: corev1.EventTypeNormal,
+		},
+		{
+			name:      "failed",
+			snapshot:  testSnapshot("snapshot", map[string]string{PolicyAnnotation: "policy"}, "good1", "bad1", "bad2"),
+			status:    v1.ConditionFalse,
+			reason:    ReasonFailed,
+			message:   "1 of 3 components passed the EnterpriseContractPolicy test/policy with 2 violations and 0 warnings",
+			eventType: corev1.EventTypeWarning,
+		},
+		{
+			name:      "policy flag over annotation",
+			snapshot:  testSnapshot("snapshot", map[string]string{PolicyAnnotation: "missing"}, "good1"),
+			policy:    "other/policy",
+			status:    v1.ConditionTrue,
+			reason:    ReasonPassed,
+			message:   "1 of 1 components passed the EnterpriseContractPolicy other/policy with 0 violations and 0 warnings",
+			eventType: corev1.EventTypeNormal,
+		},
+		{
+			name:      "annotation with namespace of the snapshot",
+			snapshot:  testSnapshot("snapshot", map[string]string{PolicyAnnotation: "test/policy"}, "good1"),
+			status:    v1.ConditionTrue,
+			reason:    ReasonPassed,
+			message:   "1 of 1 components passed the EnterpriseContractPolicy test/policy with 0 violations and 0 warnings",
+			eventType: corev1.EventTypeNormal,
+		},
+		{
+			name:      "annotation with other namespace",
+			snapshot:  testSnapshot("snapshot", map[string]string{PolicyAnnotation: "other/policy"}, "good1"),
+			status:    v1.ConditionFalse,
+			reason:    ReasonError,
+			message:   "Unable to use the EnterpriseContractPolicy other/policy: the enterprisecontract.dev/policy annotation can only reference policies in the namespace test",
+			eventType: corev1.EventTypeWarning,
+		},
+		{
+			name:      "validation error",
+			snapshot:  testSnapshot("snapshot", map[string]string{PolicyAnnotation: "policy"}, "good1", "missing1"),
+			status:    v1.ConditionFalse,
+			reason:    ReasonError,
+			message:   "1 of 2 components passed the EnterpriseContractPolicy test/policy with 0 violations and 0 warnings, unable to validate component missing1: image not found",
+			eventType: corev1.EventTypeWarning,
+		},
+		{
+			name:      "no policy",
+			snapshot:  testSnapshot("snapshot", nil, "good1"),
+			status:    v1.ConditionFalse,
+			reason:    ReasonError,
+			message:   "No EnterpriseContractPolicy given, set the enterprisecontract.dev/policy annotation",
+			eventType: corev1.EventTypeWarning,
+		},
+		{
+			name:      "unknown policy",
+			snapshot:  testSnapshot("snapshot", nil, "good1"),
+			policy:    "missing",
+			status:    v1.ConditionFalse,
+			reason:    ReasonError,
+			message:   `Unable to use the EnterpriseContractPolicy test/missing: unable to fetch EnterpriseContractPolicy: enterprisecontractpolicies.appstudio.redhat.com "missing" not found`,
+			eventType: corev1.EventTypeWarning,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			utils.SetTestRekorPublicKey(t)
+			client := fakeClient(t, testPolicy("test", "policy"), testPolicy("other", "policy"), c.snapshot)
+
+			controller, err := New(client, fakeValidate, Options{Policy: c.policy, EffectiveTime: policy.Now, Workers: 1})
+			require.NoError(t, err)
+
+			err = controller.reconcile(context.Background(), "test/snapshot")
+			if c.reason == ReasonError {
+				assert.ErrorIs(t, err, errValidation)
+			} else {
+				require.NoError(t, err)
+			}
+
+			condition := snapshotCondition(t, client, "snapshot")
+			require.NotNil(t, condition)
+			assert.Equal(t, c.status, condition.Status)
+			assert.Equal(t, c.reason, condition.Reason)
+			assert.Equal(t, c.message, condition.Message)
+			assert.Equal(t, int64(1), condition.ObservedGeneration)
+
+			events := events(t, client)
+			require.Len(t, events, 1)
+			assert.Equal(t, c.eventType, events[0].Type)
+			assert.Equal(t, c.reason, events[0].Reason)
+			assert.Equal(t, c.message, events[0].Message)
+			assert.Equal(t, "Snapshot", events[0].InvolvedObject.Kind)
+			assert.Equal(t, "snapshot", events[0].InvolvedObject.Name)
+			assert.Equal(t, "ec-controller", events[0].Source.Component)
+		})
+	}
+}
+
+func TestReconcileValidatedOnce(t *testing.T) {
+	utils.SetTestRekorPublicKey(t)
+	client := fakeClient(t, testPolicy("test", "policy"), testSnapshot("snapshot", nil, "good1"))
+
+	validations := 0
+	validate := func(ctx context.Context, comp app.SnapshotComponent, p policy.Policy, detailed bool) (*output.Output, error) {
+		validations++
+		return fakeValidate(ctx, comp, p, detailed)
+	}
+
+	controller, err := New(client, validate, Options{Policy: "policy", EffectiveTime: policy.Now, Workers: 1})
+	require.NoError(t, err)
+
+	require.NoError(t, controller.reconcile(context.Background(), "test/snapshot"))
+	require.NoError(t, controller.reconcile(context.Background(), "test/snapshot"))
+	assert.Equal(t, 1, validations)
+
+	// a new generation is validated again
+	u, err := client.Resource(snapshotsResource).Namespace("test").Get(context.Background(), "snapshot", v1.GetOptions{})
+	require.NoError(t, err)
+	u.SetGeneration(2)
+	_, err = client.Resource(snapshotsResource).Namespace("test").Update(context.Background(), u, v1.UpdateOptions{})
+	require.NoError(t, err)
+
+	require.NoError(t, controller.reconcile(context.Background(), "test/snapshot"))
+	assert.Equal(t, 2, validations)
+	assert.Equal(t, int64(2), snapshotCondition(t, client, "snapshot").ObservedGeneration)
+	assert.Len(t, events(t, client), 2)
+}
+
+// policyWithExtensions returns the EnterpriseContractPolicy with the extensions added to its
+// spec, as kept by a cluster whose CRD preserves unknown fields.
+func policyWithExtensions(t *testing.T, extensions map[string]any) *unstructured.Unstructured {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(testPolicy("test", "policy"))
+	require.NoError(t, err)
+	u := &unstructured.Unstructured{Object: content}
+	for k, v := range extensions {
+		require.NoError(t, unstructured.SetNestedField(u.Object, v, "spec", k))
+	}
+
+	return u
+}
+
+func TestReconcilePolicyExtensions(t *testing.T) {
+	utils.SetTestRekorPublicKey(t)
+	client := fakeClient(t, policyWithExtensions(t, map[string]any{
+		"exceptions": []any{
+			map[string]any{"value": "pkg.rule", "reason": "known issue", "expiresOn": "2099-01-01"},
+		},
+	}), testSnapshot("snapshot", nil, "good1"))
+
+	var extensions policy.Extensions
+	validate := func(ctx context.Context, comp app.SnapshotComponent, p policy.Policy, detailed bool) (*output.Output, error) {
+		extensions = p.Extensions()
+		return fakeValidate(ctx, comp, p, detailed)
+	}
+
+	controller, err := New(client, validate, Options{Policy: "policy", EffectiveTime: policy.Now, Workers: 1})
+	require.NoError(t, err)
+
+	require.NoError(t, controller.reconcile(context.Background(), "test/snapshot"))
+	assert.Equal(t, []policy.Exception{{Value: "pkg.rule", Reason: "known issue", ExpiresOn: "2099-01-01"}}, extensions.Exceptions)
+}
+
+func TestReconcileInvalidPolicyExtensions(t *testing.T) {
+	utils.SetTestRekorPublicKey(t)
+	client := fakeClient(t, policyWithExtensions(t, map[string]any{
+		"sources": []any{map[string]any{"policy": []any{"registry.io/policy:latest"}, "rulePrecedence": "random"}},
+	}), testSnapshot("snapshot", nil, "good1"))
+
+	controller, err := New(client, fakeValidate, Options{Policy: "policy", EffectiveTime: policy.Now, Workers: 1})
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, controller.reconcile(context.Background(), "test/snapshot"), errValidation)
+
+	condition := snapshotCondition(t, client, "snapshot")
+	require.NotNil(t, condition)
+	assert.Equal(t, ReasonError, condition.Reason)
+	assert.Contains(t, condition.Message, `source group at index 0 has an invalid rulePrecedence "random"`)
+}
+
+func TestReconcileRetriesErrors(t *testing.T) {
+	utils.SetTestRekorPublicKey(t)
+	client := fakeClient(t, testPolicy("test", "policy"), testSnapshot("snapshot", nil, "good1"))
+
+	validations := 0
+	validate := func(ctx context.Context, comp app.SnapshotComponent, p policy.Policy, detailed bool) (*output.Output, error) {
+		validations++
+		if validations == 1 {
+			return nil, errors.New("registry unavailable")
+		}
+		return fakeValidate(ctx, comp, p, detailed)
+	}
+
+	controller, err := New(client, validate, Options{Policy: "policy", EffectiveTime: policy.Now, Workers: 1})
+	require.NoError(t, err)
+
+	err = controller.reconcile(context.Background(), "test/snapshot")
+	assert.ErrorIs(t, err, errValidation)
+	assert.Equal(t, ReasonError, snapshotCondition(t, client, "snapshot").Reason)
+
+	require.NoError(t, controller.reconcile(context.Background(), "test/snapshot"))
+	condition := snapshotCondition(t, client, "snapshot")
+	assert.Equal(t, ReasonPassed, condition.Reason)
+	assert.Equal(t, v1.ConditionTrue, condition.Status)
+	assert.Equal(t, int64(1), condition.ObservedGeneration)
+
+	// passed, so it is not validated again
+	require.NoError(t, controller.reconcile(context.Background(), "test/snapshot"))
+	assert.Equal(t, 2, validations)
+
+	events := events(t, client)
+	require.Len(t, events, 2)
+	reasons := []string{events[0].Reason, events[1].Reason}
+	assert.ElementsMatch(t, []string{ReasonError, ReasonPassed}, reasons)
+}
+
+func TestReconcileRepeatedErrorRecordedOnce(t *testing.T) {
+	client := fakeClient(t, testSnapshot("snapshot", nil, "good1"))
+
+	controller, err := New(client, fakeValidate, Options{Workers: 1})
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, controller.reconcile(context.Background(), "test/snapshot"), errValidation)
+	assert.ErrorIs(t, controller.reconcile(context.Background(), "test/snapshot"), errValidation)
+
+	assert.Len(t, events(t, client), 1)
+}
+
+func TestProcessNextRequeuesErrors(t *testing.T) {
+	client := fakeClient(t, testSnapshot("snapshot", nil, "good1"))
+
+	controller, err := New(client, fakeValidate, Options{Workers: 1})
+	require.NoError(t, err)
+	defer controller.queue.ShutDown()
+
+	controller.queue.Add("test/snapshot")
+	assert.True(t, controller.processNext(context.Background()))
+	assert.Equal(t, 1, controller.queue.NumRequeues("test/snapshot"))
+}
+
+func TestReconcileDeleted(t *testing.T) {
+	client := fakeClient(t)
+
+	controller, err := New(client, fakeValidate, Options{Workers: 1})
+	require.NoError(t, err)
+
+	assert.NoError(t, controller.reconcile(context.Background(), "test/snapshot"))
+}
+
+func TestRun(t *testing.T) {
+	utils.SetTestRekorPublicKey(t)
+	ignored := testSnapshot("ignored", nil, "good1")
+	ignored.Labels = nil
+	client := fakeClient(t, testPolicy("test", "policy"), testSnapshot("existing", nil, "good1"), ignored)
+
+	controller, err := New(client, fakeValidate, Options{
+		Namespace:     "test",
+		LabelSelector: "release=true",
+		Policy:        "policy",
+		EffectiveTime: policy.Now,
+		Workers:       2,
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- controller.Run(ctx)
+	}()
+
+	assert.Eventually(t, func() bool {
+		return snapshotCondition(t, client, "existing") != nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	created, err := runtime.DefaultUnstructuredConverter.ToUnstructured(testSnapshot("created", nil, "bad1"))
+	require.NoError(t, err)
+	_, err = client.Resource(snapshotsResource).Namespace("test").Create(ctx, &unstructured.Unstructured{Object: created}, v1.CreateOptions{})
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		c := snapshotCondition(t, client, "created")
+		return c != nil && c.Reason == ReasonFailed
+	}, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	assert.NoError(t, <-done)
+
+	assert.Nil(t, snapshotCondition(t, client, "ignored"))
+}
+
+func TestNew(t *testing.T) {
+	_, err := New(fakeClient(t), fakeValidate, Options{LabelSelector: "a b", Workers: 1})
+	assert.ErrorContains(t, err, `invalid label selector "a b"`)
+
+	_, err = New(fakeClient(t), fakeValidate, Options{})
+	assert.EqualError(t, err, "invalid number of workers 0, expecting at least 1")
+}
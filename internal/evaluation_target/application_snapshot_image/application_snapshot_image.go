@@ -91,6 +91,9 @@ func NewApplicationSnapshotImage(ctx context.Context, component app.SnapshotComp
 		return nil, err
 	}
 
+	// Policy exceptions are selected based on the component being evaluated
+	ctx = evaluator.WithComponent(ctx, component)
+
 	// Return an evaluator for each of these
 	for _, sourceGroup := range p.Spec().Sources {
 		// Todo: Make each fetch run concurrently
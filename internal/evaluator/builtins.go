@@ -0,0 +1,97 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package evaluator
+
+import (
+	"context"
+	"sort"
+
+	"github.com/open-policy-agent/opa/rego"
+	"github.com/open-policy-agent/opa/types"
+)
+
+// registered holds the declarations of the rego functions provided by ec.
+var registered []*rego.Function
+
+func registerBuiltin1(decl *rego.Function, impl rego.Builtin1) {
+	registered = append(registered, decl)
+	rego.RegisterBuiltin1(decl, impl)
+}
+
+func registerBuiltin2(decl *rego.Function, impl rego.Builtin2) {
+	registered = append(registered, decl)
+	rego.RegisterBuiltin2(decl, impl)
+}
+
+// Builtin describes a rego function provided by ec.
+type Builtin struct {
+	Name   string       `json:"name"`
+	Args   []BuiltinArg `json:"args"`
+	Result BuiltinArg   `json:"result"`
+	// Memoize is set if the function is evaluated only once per set of arguments during a
+	// policy evaluation.
+	Memoize bool `json:"memoize"`
+	// Nondeterministic is set if the function relies on external entities, e.g. an OCI
+	// registry, so its result can vary between evaluations.
+	Nondeterministic bool `json:"nondeterministic"`
+}
+
+// BuiltinArg describes an argument, or the result, of a rego function.
+type BuiltinArg struct {
+	Name        string `json:"name,omitempty"`
+	Type        string `json:"type"`
+	Description string `json:"description,omitempty"`
+}
+
+// Builtins returns the rego functions provided by ec, sorted by name.
+func Builtins() []Builtin {
+	builtins := make([]Builtin, 0, len(registered))
+	for _, f := range registered {
+		b := Builtin{
+			Name:             f.Name,
+			Args:             []BuiltinArg{},
+			Memoize:          f.Memoize,
+			Nondeterministic: f.Nondeterministic,
+		}
+		for _, a := range f.Decl.NamedFuncArgs().Args {
+			b.Args = append(b.Args, builtinArg(a))
+		}
+		b.Result = builtinArg(f.Decl.NamedResult())
+		builtins = append(builtins, b)
+	}
+
+	sort.Slice(builtins, func(i, j int) bool {
+		return builtins[i].Name < builtins[j].Name
+	})
+
+	return builtins
+}
+
+func builtinArg(t types.Type) BuiltinArg {
+	if n, ok := t.(*types.NamedType); ok {
+		return BuiltinArg{Name: n.Name, Type: types.Sprint(n.Type), Description: n.Descr}
+	}
+
+	return BuiltinArg{Type: types.Sprint(t)}
+}
+
+// Capabilities returns the OPA capabilities, in JSON, policies are evaluated with. They include
+// the rego functions provided by ec and exclude the OPA functions that are not allowed, e.g.
+// http.send.
+func Capabilities(ctx context.Context) (string, error) {
+	return strictCapabilities(ctx)
+}
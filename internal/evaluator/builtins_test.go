@@ -0,0 +1,82 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unit
+
+package evaluator
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuiltins(t *testing.T) {
+	builtins := Builtins()
+	require.Len(t, builtins, len(registered))
+
+	for i := 1; i < len(builtins); i++ {
+		assert.Less(t, builtins[i-1].Name, builtins[i].Name)
+	}
+
+	byName := map[string]Builtin{}
+	for _, b := range builtins {
+		byName[b.Name] = b
+	}
+
+	assert.Equal(t, Builtin{
+		Name: ociBlobName,
+		Args: []BuiltinArg{
+			{Name: "ref", Type: "string", Description: "OCI blob reference"},
+		},
+		Result:           BuiltinArg{Name: "blob", Type: "string", Description: "the OCI blob"},
+		Memoize:          true,
+		Nondeterministic: true,
+	}, byName[ociBlobName])
+
+	semver := byName[semverSatisfiesName]
+	assert.False(t, semver.Nondeterministic)
+	require.Len(t, semver.Args, 2)
+	assert.Equal(t, "version", semver.Args[0].Name)
+	assert.Equal(t, "constraint", semver.Args[1].Name)
+	assert.Equal(t, "boolean", semver.Result.Type)
+}
+
+func TestCapabilities(t *testing.T) {
+	caps, err := Capabilities(context.Background())
+	require.NoError(t, err)
+
+	var c struct {
+		Builtins []struct {
+			Name string `json:"name"`
+		} `json:"builtins"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(caps), &c))
+
+	names := map[string]bool{}
+	for _, b := range c.Builtins {
+		names[b.Name] = true
+	}
+
+	for _, b := range Builtins() {
+		assert.True(t, names[b.Name], "%s missing from the capabilities", b.Name)
+	}
+	assert.True(t, names["count"])
+	assert.False(t, names["http.send"])
+}
@@ -32,6 +32,7 @@ import (
 	"github.com/open-policy-agent/conftest/runner"
 	"github.com/open-policy-agent/opa/ast"
 	"github.com/open-policy-agent/opa/storage"
+	app "github.com/redhat-appstudio/application-api/api/v1alpha1"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/afero"
 	"k8s.io/apimachinery/pkg/util/sets"
@@ -49,54 +50,23 @@ const (
 	runnerKey        contextKey = "ec.evaluator.runner"
 	capabilitiesKey  contextKey = "ec.evaluator.capabilities"
 	effectiveTimeKey contextKey = "ec.evaluator.effective_time"
+	componentKey     contextKey = "ec.evaluator.component"
+	blobSizeLimitKey contextKey = "ec.evaluator.blob_size_limit"
+	policyKey        contextKey = "ec.evaluator.policy"
 )
 
-// trim removes all failure, warning, success or skipped results that depend on
-// a result reported as failure, warning or skipped. Dependencies are declared
-// by setting the metadata via metadataDependsOn.
-func trim(results *[]Outcome) {
-	// holds codes for all failures, warnings or skipped rules, as a map to ease
-	// the lookup, any rule that depends on a reported code will be removed from
-	// the results
-	reported := map[string]bool{}
-
-	for _, checks := range *results {
-		for _, results := range [][]Result{checks.Failures, checks.Warnings, checks.Skipped} {
-			for _, result := range results {
-				if code, ok := result.Metadata[metadataCode].(string); ok {
-					reported[code] = true
-				}
-			}
-		}
-	}
-
-	// helper function inlined for ecapsulation, removes any results that depend
-	// on a reported rule, by code
-	trimOutput := func(what []Result) []Result {
-		if what == nil {
-			// nil might get passed in, while this would not cause an issue, the
-			// function would return empty array and that would needlessly
-			// change the output
-			return nil
-		}
-
-		// holds leftover results, i.e. the ones that do not depend on a rule
-		// reported as failure, warning or skipped
-		trimmed := make([]Result, 0, len(what))
-		for _, result := range what {
-			if dependency, ok := result.Metadata[metadataDependsOn].([]string); ok {
-				for _, d := range dependency {
-					if !reported[d] {
-						trimmed = append(trimmed, result)
-					}
-				}
-			} else {
-				trimmed = append(trimmed, result)
-			}
-		}
+// WithComponent returns a context carrying the component being evaluated. Evaluators created
+// with the returned context only apply the policy exceptions matching the component.
+func WithComponent(ctx context.Context, comp app.SnapshotComponent) context.Context {
+	return context.WithValue(ctx, componentKey, comp)
+}
 
-		return trimmed
-	}
+// trim removes all failure, warning, success or skipped results that depend,
+// directly or transitively, on a result reported as failure, warning or
+// skipped, and returns by code the reason the results of a rule were trimmed.
+// Dependencies are declared by setting the metadata via metadataDependsOn.
+func trim(results *[]Outcome) map[string]string {
+	reasons := trimDependents(*results)
 
 	addNote := func(results []Result) []Result {
 		for i := range results {
@@ -120,25 +90,28 @@ func trim(results *[]Outcome) {
 	}
 
 	for i, checks := range *results {
-		(*results)[i].Failures = addNote(trimOutput(checks.Failures))
-		(*results)[i].Warnings = trimOutput(checks.Warnings)
-		(*results)[i].Skipped = trimOutput(checks.Skipped)
-		(*results)[i].Successes = trimOutput(checks.Successes)
+		(*results)[i].Failures = addNote(checks.Failures)
 	}
+
+	return reasons
 }
 
 type testRunner interface {
 	Run(context.Context, []string) ([]Outcome, Data, error)
 }
 
+// EffectiveOnFormat is the format of the effective_on annotation of the rules.
+const EffectiveOnFormat = "2006-01-02T15:04:05Z"
+
 const (
-	effectiveOnFormat   = "2006-01-02T15:04:05Z"
 	effectiveOnTimeout  = -90 * 24 * time.Hour // keep effective_on metadata up to 90 days
 	metadataCode        = "code"
 	metadataCollections = "collections"
 	metadataDependsOn   = "depends_on"
 	metadataDescription = "description"
+	metadataDocsUrl     = "documentation_url"
 	metadataEffectiveOn = "effective_on"
+	metadataException   = "exception"
 	metadataSolution    = "solution"
 	metadataTerm        = "term"
 	metadataTitle       = "title"
@@ -156,6 +129,9 @@ type conftestEvaluator struct {
 	exclude       []string
 	fs            afero.Fs
 	namespace     []string
+	exceptions    []policy.Exception
+	explain       []string
+	sourceOptions policy.SourceOptions
 }
 
 type conftestRunner struct {
@@ -254,6 +230,11 @@ func NewConftestEvaluatorWithNamespace(ctx context.Context, policySources []sour
 	}
 
 	c.include, c.exclude = computeIncludeExclude(source, p)
+	c.exceptions = applicableExceptions(ctx, p)
+	if p != nil {
+		c.sourceOptions = p.Extensions().SourceOptions(source)
+	}
+	c.explain = explainCodes(ctx)
 
 	dir, err := utils.CreateWorkDir(fs)
 	if err != nil {
@@ -318,11 +299,8 @@ func (r *policyRules) collect(a *ast.AnnotationsRef) error {
 func (c conftestEvaluator) Evaluate(ctx context.Context, inputs []string) ([]Outcome, Data, error) {
 	var results []Outcome
 
-	// hold all rule annotations from all policy sources
-	// NOTE: emphasis on _all rules from all sources_; meaning that if two rules
-	// exist with the same code in two separate sources the collected rule
-	// information is not deterministic
-	rules := policyRules{}
+	// rule annotations from each of the policy sources, in order
+	var sources []sourceRules
 	// Download all sources
 	for _, s := range c.policySources {
 		dir, err := s.GetPolicy(ctx, c.workDir, false)
@@ -338,16 +316,20 @@ func (c conftestEvaluator) Evaluate(ctx context.Context, inputs []string) ([]Out
 			return nil, nil, err
 		}
 
-		for _, a := range annotations {
-			if a.Annotations == nil {
-				continue
-			}
-			if err := rules.collect(a); err != nil {
-				return nil, nil, err
-			}
-		}
+		sources = append(sources, sourceRules{url: s.PolicyUrl(), dir: dir, annotations: annotations})
 	}
 
+	// hold all rule annotations from all policy sources, rules with the same code in more than
+	// one source are resolved using the rule precedence of the source group
+	rules, annotations, shadowed, err := collectRules(c.sourceOptions, sources)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := removeShadowedRules(utils.FS(ctx), shadowed); err != nil {
+		return nil, nil, err
+	}
+	codes := namespacedCodes(rules)
+
 	var r testRunner
 	var ok bool
 	if r, ok = ctx.Value(runnerKey).(testRunner); r == nil || !ok {
@@ -374,6 +356,13 @@ func (c conftestEvaluator) Evaluate(ctx context.Context, inputs []string) ([]Out
 	log.Debugf("runner: %#v", r)
 	log.Debugf("inputs: %#v", inputs)
 
+	if limit := c.sourceOptions.BlobLimit(); limit > 0 {
+		ctx = context.WithValue(ctx, blobSizeLimitKey, limit)
+	}
+
+	// The signature verification rego functions use the trusted material of the policy
+	ctx = context.WithValue(ctx, policyKey, c.policy)
+
 	runResults, data, err := r.Run(ctx, inputs)
 	if err != nil {
 		// TODO do we want to evaluate further policies instead of erroring out?
@@ -383,6 +372,15 @@ func (c conftestEvaluator) Evaluate(ctx context.Context, inputs []string) ([]Out
 	effectiveTime := c.policy.EffectiveTime()
 	ctx = context.WithValue(ctx, effectiveTimeKey, effectiveTime)
 
+	cov := newCoverage(rules)
+
+	var e *explainer
+	if len(c.explain) > 0 {
+		if e, err = c.newExplainer(annotations); err != nil {
+			return nil, nil, err
+		}
+	}
+
 	// Track how many rules have been processed. This is used later on to determine if anything
 	// at all was processed.
 	totalRules := 0
@@ -398,10 +396,17 @@ func (c conftestEvaluator) Evaluate(ctx context.Context, inputs []string) ([]Out
 
 		for i := range result.Warnings {
 			warning := result.Warnings[i]
+			namespaceResult(&warning, result.Namespace, codes)
 			addRuleMetadata(ctx, &warning, rules)
 
 			if !c.isResultIncluded(warning) {
 				log.Debugf("Skipping result warning: %#v", warning)
+				cov.exclude(result.FileName, warning, c.exclusionReason(warning))
+				continue
+			}
+
+			if c.applyExceptions(warning, effectiveTime, &exceptions, &warnings) {
+				log.Debugf("Waived result warning: %#v", warning)
 				continue
 			}
 			warnings = append(warnings, warning)
@@ -409,15 +414,23 @@ func (c conftestEvaluator) Evaluate(ctx context.Context, inputs []string) ([]Out
 
 		for i := range result.Failures {
 			failure := result.Failures[i]
+			namespaceResult(&failure, result.Namespace, codes)
 			addRuleMetadata(ctx, &failure, rules)
 
 			if !c.isResultIncluded(failure) {
 				log.Debugf("Skipping result failure: %#v", failure)
+				cov.exclude(result.FileName, failure, c.exclusionReason(failure))
+				continue
+			}
+
+			if c.applyExceptions(failure, effectiveTime, &exceptions, &warnings) {
+				log.Debugf("Waived result failure: %#v", failure)
 				continue
 			}
 
 			if !isResultEffective(failure, effectiveTime) {
 				// TODO: Instead of moving to warnings, create new attribute: "futureViolations"
+				cov.postpone(result.FileName, failure)
 				warnings = append(warnings, failure)
 			} else {
 				failures = append(failures, failure)
@@ -426,12 +439,14 @@ func (c conftestEvaluator) Evaluate(ctx context.Context, inputs []string) ([]Out
 
 		for i := range result.Exceptions {
 			exception := result.Exceptions[i]
+			namespaceResult(&exception, result.Namespace, codes)
 			addRuleMetadata(ctx, &exception, rules)
 			exceptions = append(exceptions, exception)
 		}
 
 		for i := range result.Skipped {
 			skip := result.Skipped[i]
+			namespaceResult(&skip, result.Namespace, codes)
 			addRuleMetadata(ctx, &skip, rules)
 			skipped = append(skipped, skip)
 		}
@@ -441,15 +456,25 @@ func (c conftestEvaluator) Evaluate(ctx context.Context, inputs []string) ([]Out
 		result.Exceptions = exceptions
 		result.Skipped = skipped
 
-		totalRules += len(result.Warnings) + len(result.Failures) + len(result.Successes)
+		totalRules += len(result.Warnings) + len(result.Failures) + len(result.Exceptions) + len(result.Successes)
 
 		// Replace the placeholder successes slice with the actual successes.
-		result.Successes = c.computeSuccesses(result, rules, effectiveTime)
+		result.Successes = c.computeSuccesses(result, rules, effectiveTime, cov)
+
+		if e != nil {
+			for _, r := range [][]Result{result.Failures, result.Warnings} {
+				if err := e.addExplanations(ctx, result.FileName, r); err != nil {
+					return nil, nil, err
+				}
+			}
+		}
 
 		results = append(results, result)
 	}
 
-	trim(&results)
+	cov.beforeTrim(results)
+	cov.trimmed = trim(&results)
+	cov.complete(results)
 
 	// If no rules were checked, then we have effectively failed, because no tests were actually
 	// ran due to input error, etc.
@@ -477,7 +502,7 @@ func toRules(results []output.Result) []Result {
 // computeSuccesses generates success results, these are not provided in the
 // Conftest results, so we reconstruct these from the parsed rules, any rule
 // that hasn't been touched by adding metadata must have succeeded
-func (c conftestEvaluator) computeSuccesses(result Outcome, rules policyRules, effectiveTime time.Time) []Result {
+func (c conftestEvaluator) computeSuccesses(result Outcome, rules policyRules, effectiveTime time.Time, cov *coverage) []Result {
 	// what rules, by code, have we seen in the Conftest results, use map to
 	// take advantage of hashing for quicker lookup
 	seenRules := map[string]bool{}
@@ -529,8 +554,13 @@ func (c conftestEvaluator) computeSuccesses(result Outcome, rules policyRules, e
 			success.Metadata[metadataDependsOn] = rule.DependsOn
 		}
 
+		if rule.Source != "" {
+			success.Metadata[metadataSource] = rule.Source
+		}
+
 		if !c.isResultIncluded(success) {
 			log.Debugf("Skipping result success: %#v", success)
+			cov.exclude(result.FileName, success, c.exclusionReason(success))
 			continue
 		}
 
@@ -593,18 +623,24 @@ func addMetadataToResults(ctx context.Context, r *Result, rule rule.Info) {
 	if rule.Solution != "" {
 		r.Metadata[metadataSolution] = rule.Solution
 	}
+	if rule.DocumentationUrl != "" {
+		r.Metadata[metadataDocsUrl] = rule.DocumentationUrl
+	}
 	if len(rule.Collections) > 0 {
 		r.Metadata[metadataCollections] = rule.Collections
 	}
 	if len(rule.DependsOn) > 0 {
 		r.Metadata[metadataDependsOn] = rule.DependsOn
 	}
+	if rule.Source != "" {
+		r.Metadata[metadataSource] = rule.Source
+	}
 
 	// If the rule has been effective for a long time, we'll consider
 	// the effective_on date not relevant and not bother including it
 	if effectiveTime, ok := ctx.Value(effectiveTimeKey).(time.Time); ok {
 		if effectiveOnString, ok := r.Metadata[metadataEffectiveOn].(string); ok {
-			effectiveOnTime, err := time.Parse(effectiveOnFormat, effectiveOnString)
+			effectiveOnTime, err := time.Parse(EffectiveOnFormat, effectiveOnString)
 			if err == nil {
 				if effectiveOnTime.Before(effectiveTime.Add(effectiveOnTimeout)) {
 					delete(r.Metadata, metadataEffectiveOn)
@@ -726,7 +762,7 @@ func isResultEffective(failure Result, now time.Time) bool {
 		log.Warnf("Ignoring non-string %q value %#v", metadataEffectiveOn, raw)
 		return true
 	}
-	effectiveOn, err := time.Parse(effectiveOnFormat, str)
+	effectiveOn, err := time.Parse(EffectiveOnFormat, str)
 	if err != nil {
 		log.Warnf("Invalid %q value %q", metadataEffectiveOn, failure.Metadata)
 		return true
@@ -743,14 +779,101 @@ func (c conftestEvaluator) isResultIncluded(result Result) bool {
 	return includeScore > excludeScore
 }
 
-// scoreMatches returns the combined score for every match between needles and haystack.
+// applicableExceptions returns the policy exceptions applying to the component found in the
+// context. When the context does not carry a component only the exceptions not restricted to
+// particular components or images apply.
+func applicableExceptions(ctx context.Context, p policy.Policy) []policy.Exception {
+	if p == nil {
+		return nil
+	}
+
+	comp, _ := ctx.Value(componentKey).(app.SnapshotComponent)
+
+	var exceptions []policy.Exception
+	for _, e := range p.Extensions().Exceptions {
+		if e.AppliesTo(comp) {
+			exceptions = append(exceptions, e)
+		}
+	}
+
+	return exceptions
+}
+
+// applyExceptions returns whether or not the result is waived by one of the policy exceptions.
+// A waived result, annotated with the exception, is appended to exceptions. When the result is
+// matched only by expired exceptions, a warning for each of those is appended to warnings.
+func (c conftestEvaluator) applyExceptions(result Result, at time.Time, exceptions, warnings *[]Result) bool {
+	if len(c.exceptions) == 0 {
+		return false
+	}
+
+	matchers := makeMatchers(result)
+	var expired []policy.Exception
+	for _, e := range c.exceptions {
+		if !matchesName(e.Value, matchers) {
+			continue
+		}
+
+		if e.Expired(at) {
+			expired = append(expired, e)
+			continue
+		}
+
+		if result.Metadata == nil {
+			result.Metadata = map[string]any{}
+		}
+		result.Metadata[metadataException] = exceptionMetadata(e)
+		*exceptions = append(*exceptions, result)
+		return true
+	}
+
+	for _, e := range expired {
+		*warnings = append(*warnings, Result{
+			Message: fmt.Sprintf("Exception %q for %q expired on %s, the result is no longer waived: %s",
+				e.Value, ExtractStringFromMetadata(result, metadataCode), e.ExpiresOn, result.Message),
+			Metadata: map[string]any{
+				metadataCode:      expiredExceptionCode,
+				metadataTitle:     "Policy exception expired",
+				metadataException: exceptionMetadata(e),
+			},
+		})
+	}
+
+	return false
+}
+
+// expiredExceptionCode is the code of the warnings reported for expired exceptions.
+const expiredExceptionCode = "builtin.policy.exception_expired"
+
+func exceptionMetadata(e policy.Exception) map[string]string {
+	m := map[string]string{
+		"value":     e.Value,
+		"reason":    e.Reason,
+		"expiresOn": e.ExpiresOn,
+	}
+	if e.Ticket != "" {
+		m["ticket"] = e.Ticket
+	}
+	if e.ApprovedBy != "" {
+		m["approvedBy"] = e.ApprovedBy
+	}
+	if e.Component != "" {
+		m["component"] = e.Component
+	}
+	if e.ImageRef != "" {
+		m["imageRef"] = e.ImageRef
+	}
+
+	return m
+}
+
+// scoreMatches returns the combined score for every item in the haystack matching any of the
+// needles. Glob and regular expression items are matched as patterns, see matchesName.
 func scoreMatches(needles, haystack []string) int {
 	var s int
-	for _, needle := range needles {
-		for _, hay := range haystack {
-			if hay == needle {
-				s += score(hay)
-			}
+	for _, hay := range haystack {
+		if matchesName(hay, needles) {
+			s += score(hay)
 		}
 	}
 	return s
@@ -764,24 +887,38 @@ func scoreMatches(needles, haystack []string) int {
 //  4. Add 100 if a term is used, e.g. "*:term", "pkg:term" or "pkg.rule:term"
 //  5. Add 100 if a rule is used, e.g. "pkg.rule", "pkg.rule:term"
 //
+// Globs score less than the literal names they stand for. A package name glob, e.g. "pkg_*",
+// adds 5 instead of 10, and a rule name or term glob, e.g. "pkg.rule_*" or "pkg:term_*", adds
+// 50 instead of 100. A regular expression, e.g. "/^pkg_.*\.rule_/", scores exactly 55, like
+// "pkg_*.rule_*".
+//
 // The score is cumulative. If a name is covered by multiple items in the guidelines, they
 // are added together. For example, "pkg.rule:term" scores at 210.
 func score(name string) int {
 	if strings.HasPrefix(name, "@") {
 		return 10
 	}
+	if isRegex(name) {
+		return regexScore
+	}
 	var value int
 	shortName, term, _ := strings.Cut(name, ":")
-	if term != "" {
+	if isGlob(term) {
+		value += termPatternScore
+	} else if term != "" {
 		value += 100
 	}
 	pkg, rule, _ := strings.Cut(shortName, ".")
 	if pkg == "*" {
 		value += 1
+	} else if isGlob(pkg) {
+		value += packagePatternScore
 	} else {
 		value += 10
 	}
-	if rule != "*" && rule != "" {
+	if isGlob(rule) && rule != "*" {
+		value += rulePatternScore
+	} else if rule != "*" && rule != "" {
 		value += 100
 	}
 	return value
@@ -31,12 +31,16 @@ import (
 	"sort"
 	"strings"
 	"testing"
+	"testing/fstest"
 	"time"
 
 	"github.com/MakeNowJust/heredoc"
 	ecc "github.com/enterprise-contract/enterprise-contract-controller/api/v1alpha1"
 	"github.com/gkampitakis/go-snaps/snaps"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
 	"github.com/open-policy-agent/opa/ast"
+	app "github.com/redhat-appstudio/application-api/api/v1alpha1"
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -44,6 +48,8 @@ import (
 	"k8s.io/kube-openapi/pkg/util/sets"
 
 	"github.com/enterprise-contract/ec-cli/internal/downloader"
+	"github.com/enterprise-contract/ec-cli/internal/fetchers/oci"
+	"github.com/enterprise-contract/ec-cli/internal/fetchers/oci/fake"
 	"github.com/enterprise-contract/ec-cli/internal/opa/rule"
 	"github.com/enterprise-contract/ec-cli/internal/policy"
 	"github.com/enterprise-contract/ec-cli/internal/policy/source"
@@ -189,7 +195,7 @@ func TestConftestEvaluatorEvaluateTimeBased(t *testing.T) {
 
 	ctx := setupTestContext(&r, &dl)
 
-	r.On("Run", ctx, inputs).Return(results, expectedData, nil)
+	r.On("Run", evaluationContext(ctx), inputs).Return(results, expectedData, nil)
 
 	pol, err := policy.NewOfflinePolicy(ctx, policy.Now)
 	assert.NoError(t, err)
@@ -206,6 +212,15 @@ func TestConftestEvaluatorEvaluateTimeBased(t *testing.T) {
 	assert.Equal(t, expectedData, data)
 }
 
+// evaluationContext matches the context the test runner is called with, i.e. the given context
+// with the policy being evaluated.
+func evaluationContext(ctx context.Context) any {
+	return mock.MatchedBy(func(c context.Context) bool {
+		_, ok := c.Value(policyKey).(policy.Policy)
+		return ok && c.Value(runnerKey) == ctx.Value(runnerKey)
+	})
+}
+
 func setupTestContext(r *mockTestRunner, dl *mockDownloader) context.Context {
 	ctx := withTestRunner(context.Background(), r)
 	ctx = downloader.WithDownloadImpl(ctx, dl)
@@ -279,7 +294,7 @@ func TestConftestEvaluatorEvaluateNoSuccessWarningsOrFailures(t *testing.T) {
 
 	ctx := setupTestContext(&r, &dl)
 
-	r.On("Run", ctx, inputs).Return(results, Data(nil), nil)
+	r.On("Run", evaluationContext(ctx), inputs).Return(results, Data(nil), nil)
 
 	p, err := policy.NewOfflinePolicy(ctx, policy.Now)
 	assert.NoError(t, err)
@@ -1071,6 +1086,88 @@ func TestConftestEvaluatorIncludeExclude(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "exclude by glob",
+			results: []Outcome{
+				{
+					Failures: []Result{
+						{Metadata: map[string]any{"code": "sbom_cyclonedx.allowed_package"}},
+						{Metadata: map[string]any{"code": "sbom_spdx.allowed_license", "term": "MIT"}},
+						{Metadata: map[string]any{"code": "sbom_spdx.found"}},
+						{Metadata: map[string]any{"code": "breakfast.allowed_spam"}},
+					},
+				},
+			},
+			config: &ecc.EnterpriseContractPolicyConfiguration{
+				Exclude: []string{"sbom_*.allowed_*"},
+			},
+			want: []Outcome{
+				{
+					Failures: []Result{
+						{Metadata: map[string]any{"code": "sbom_spdx.found"}},
+						{Metadata: map[string]any{"code": "breakfast.allowed_spam"}},
+					},
+					Warnings:   []Result{},
+					Skipped:    []Result{},
+					Exceptions: []Result{},
+				},
+			},
+		},
+		{
+			name: "exclude by term glob",
+			results: []Outcome{
+				{
+					Failures: []Result{
+						{Metadata: map[string]any{"code": "breakfast.spam", "term": "registry.example.com/eggs"}},
+						{Metadata: map[string]any{"code": "lunch.spam", "term": "registry.example.com/ham/bacon"}},
+						{Metadata: map[string]any{"code": "lunch.spam", "term": "registry.io/eggs"}},
+						{Metadata: map[string]any{"code": "lunch.ham"}},
+					},
+				},
+			},
+			config: &ecc.EnterpriseContractPolicyConfiguration{
+				Exclude: []string{"*:registry.example.com/*"},
+			},
+			want: []Outcome{
+				{
+					Failures: []Result{
+						{Metadata: map[string]any{"code": "lunch.spam", "term": "registry.io/eggs"}},
+						{Metadata: map[string]any{"code": "lunch.ham"}},
+					},
+					Warnings:   []Result{},
+					Skipped:    []Result{},
+					Exceptions: []Result{},
+				},
+			},
+		},
+		{
+			name: "exclude by regular expression with more specific include",
+			results: []Outcome{
+				{
+					Failures: []Result{
+						{Metadata: map[string]any{"code": "breakfast.spam"}},
+						{Metadata: map[string]any{"code": "breakfast.spam_and_eggs"}},
+						{Metadata: map[string]any{"code": "lunch.spam"}},
+						{Metadata: map[string]any{"code": "lunch.ham"}},
+					},
+				},
+			},
+			config: &ecc.EnterpriseContractPolicyConfiguration{
+				Include: []string{"*", "breakfast.spam"},
+				Exclude: []string{`/\.spam/`},
+			},
+			want: []Outcome{
+				{
+					Failures: []Result{
+						{Metadata: map[string]any{"code": "breakfast.spam"}},
+						{Metadata: map[string]any{"code": "lunch.ham"}},
+					},
+					Warnings:   []Result{},
+					Skipped:    []Result{},
+					Exceptions: []Result{},
+				},
+			},
+		},
 		{
 			name: "partial code",
 			results: []Outcome{
@@ -1097,7 +1194,7 @@ func TestConftestEvaluatorIncludeExclude(t *testing.T) {
 			dl := mockDownloader{}
 			inputs := []string{"inputs"}
 			ctx := setupTestContext(&r, &dl)
-			r.On("Run", ctx, inputs).Return(tt.results, Data(nil), nil)
+			r.On("Run", evaluationContext(ctx), inputs).Return(tt.results, Data(nil), nil)
 
 			p, err := policy.NewOfflinePolicy(ctx, policy.Now)
 			assert.NoError(t, err)
@@ -1119,6 +1216,159 @@ func TestConftestEvaluatorIncludeExclude(t *testing.T) {
 	}
 }
 
+func TestConftestEvaluatorExceptions(t *testing.T) {
+	results := []Outcome{
+		{
+			Failures: []Result{
+				{Message: "no spam", Metadata: map[string]any{"code": "breakfast.spam"}},
+				{Message: "no eggs", Metadata: map[string]any{"code": "breakfast.eggs"}},
+			},
+			Warnings: []Result{
+				{Message: "no ham", Metadata: map[string]any{"code": "lunch.ham", "term": "smoked"}},
+			},
+		},
+	}
+
+	exceptions := []policy.Exception{
+		{
+			Value:      "breakfast.spam",
+			ExpiresOn:  "3021-01-01",
+			Reason:     "spam is fine",
+			Ticket:     "https://issues.example/1",
+			ApprovedBy: "chef",
+		},
+		{
+			Value:     "breakfast.eggs",
+			ExpiresOn: "2021-01-01",
+			Reason:    "out of eggs",
+		},
+		{
+			Value:     "lunch.ham:smoked",
+			Component: "dinner-*",
+			ExpiresOn: "3021-01-01",
+			Reason:    "only for dinner",
+		},
+	}
+
+	r := mockTestRunner{}
+	dl := mockDownloader{}
+	inputs := []string{"inputs"}
+	ctx := setupTestContext(&r, &dl)
+	r.On("Run", evaluationContext(ctx), inputs).Return(results, Data(nil), nil)
+
+	p, err := policy.NewInertPolicy(ctx, toJSON(t, map[string]any{"exceptions": exceptions}))
+	assert.NoError(t, err)
+
+	evaluator, err := NewConftestEvaluator(WithComponent(ctx, app.SnapshotComponent{Name: "breakfast"}), []source.PolicySource{
+		testPolicySource{},
+	}, p, ecc.Source{})
+	assert.NoError(t, err)
+
+	got, _, err := evaluator.Evaluate(ctx, inputs)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []Outcome{
+		{
+			Failures: []Result{
+				{Message: "no eggs", Metadata: map[string]any{"code": "breakfast.eggs"}},
+			},
+			Warnings: []Result{
+				{Message: "no ham", Metadata: map[string]any{"code": "lunch.ham", "term": "smoked"}},
+				{
+					Message: `Exception "breakfast.eggs" for "breakfast.eggs" expired on 2021-01-01, the result is no longer waived: no eggs`,
+					Metadata: map[string]any{
+						"code":  "builtin.policy.exception_expired",
+						"title": "Policy exception expired",
+						"exception": map[string]string{
+							"value":     "breakfast.eggs",
+							"expiresOn": "2021-01-01",
+							"reason":    "out of eggs",
+						},
+					},
+				},
+			},
+			Exceptions: []Result{
+				{
+					Message: "no spam",
+					Metadata: map[string]any{
+						"code": "breakfast.spam",
+						"exception": map[string]string{
+							"value":      "breakfast.spam",
+							"expiresOn":  "3021-01-01",
+							"reason":     "spam is fine",
+							"ticket":     "https://issues.example/1",
+							"approvedBy": "chef",
+						},
+					},
+				},
+			},
+			Skipped: []Result{},
+		},
+	}, got)
+}
+
+func TestConftestEvaluatorAllExcepted(t *testing.T) {
+	results := []Outcome{
+		{
+			Failures: []Result{
+				{Message: "no spam", Metadata: map[string]any{"code": "breakfast.spam"}},
+			},
+		},
+	}
+
+	exceptions := []policy.Exception{
+		{
+			Value:     "breakfast.spam",
+			ExpiresOn: "3021-01-01",
+			Reason:    "spam is fine",
+		},
+	}
+
+	r := mockTestRunner{}
+	dl := mockDownloader{}
+	inputs := []string{"inputs"}
+	ctx := setupTestContext(&r, &dl)
+	r.On("Run", evaluationContext(ctx), inputs).Return(results, Data(nil), nil)
+
+	p, err := policy.NewInertPolicy(ctx, toJSON(t, map[string]any{"exceptions": exceptions}))
+	assert.NoError(t, err)
+
+	evaluator, err := NewConftestEvaluator(ctx, []source.PolicySource{
+		testPolicySource{},
+	}, p, ecc.Source{})
+	assert.NoError(t, err)
+
+	got, _, err := evaluator.Evaluate(ctx, inputs)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []Outcome{
+		{
+			Failures: []Result{},
+			Warnings: []Result{},
+			Exceptions: []Result{
+				{
+					Message: "no spam",
+					Metadata: map[string]any{
+						"code": "breakfast.spam",
+						"exception": map[string]string{
+							"value":     "breakfast.spam",
+							"expiresOn": "3021-01-01",
+							"reason":    "spam is fine",
+						},
+					},
+				},
+			},
+			Skipped: []Result{},
+		},
+	}, got)
+}
+
+func toJSON(t *testing.T, v any) string {
+	data, err := json.Marshal(v)
+	assert.NoError(t, err)
+	return string(data)
+}
+
 func TestMakeMatchers(t *testing.T) {
 	cases := []struct {
 		name string
@@ -1192,7 +1442,7 @@ func TestCollectAnnotationData(t *testing.T) {
 }
 
 func TestRuleMetadata(t *testing.T) {
-	effectiveOnTest := time.Now().Format(effectiveOnFormat)
+	effectiveOnTest := time.Now().Format(EffectiveOnFormat)
 
 	effectiveTimeTest := time.Now().Add(-24 * time.Hour)
 	ctx := context.TODO()
@@ -1367,6 +1617,34 @@ func TestNameScoring(t *testing.T) {
 			name:  "pkg.rule:term",
 			score: 210,
 		},
+		{
+			name:  "pkg_*",
+			score: 5,
+		},
+		{
+			name:  "pkg.rule_?",
+			score: 60,
+		},
+		{
+			name:  "pkg_*.rule_*",
+			score: 55,
+		},
+		{
+			name:  "pkg.rule:*",
+			score: 160,
+		},
+		{
+			name:  "*:registry.io/*",
+			score: 51,
+		},
+		{
+			name:  "/^pkg_.*\\.rule_/",
+			score: 55,
+		},
+		{
+			name:  "@pkg_*",
+			score: 10,
+		},
 	}
 
 	for _, c := range cases {
@@ -1516,6 +1794,14 @@ func TestCheckResultsTrim(t *testing.T) {
 								metadataCode: "a.failure",
 							},
 						},
+						// a failure depending on itself is not trimmed
+						{
+							Message: "Fails and depends",
+							Metadata: map[string]interface{}{
+								metadataCode:      "a.failure",
+								metadataDependsOn: []string{"a.failure"},
+							},
+						},
 					},
 					Warnings:  []Result{},
 					Successes: []Result{},
@@ -1850,6 +2136,72 @@ func TestNewConftestEvaluatorComputeIncludeExclude(t *testing.T) {
 
 }
 
+func TestBlobSizeLimit(t *testing.T) {
+	rules, err := rulesArchive(t, fstest.MapFS{
+		"blob.rego": &fstest.MapFile{Data: []byte(`package blob
+
+import future.keywords.contains
+import future.keywords.if
+
+# METADATA
+# custom:
+#   short_name: missing
+deny contains result if {
+	not ec.oci.blob("registry.local/spam@sha256:4bbf56a3a9231f752d3b9c174637975f0f83ed2b15e65799837c571e4ef3374b")
+	result := {"code": "blob.missing", "msg": "blob could not be read"}
+}
+`)},
+	})
+	require.NoError(t, err)
+
+	client := fake.FakeClient{}
+	client.On("Layer", mock.Anything, mock.Anything).Return(static.NewLayer([]byte(`{"spam": "maps"}`), types.OCIUncompressedLayer), nil)
+
+	cases := []struct {
+		name     string
+		limit    string
+		failures int
+	}{
+		{name: "default limit"},
+		{name: "within limit", limit: "16"},
+		{name: "exceeds limit", limit: "15", failures: 1},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			dir := t.TempDir()
+			require.NoError(t, os.MkdirAll(path.Join(dir, "inputs"), 0755))
+			require.NoError(t, os.WriteFile(path.Join(dir, "inputs", "data.json"), []byte("{}"), 0600))
+
+			group := map[string]any{"policy": []string{rules}}
+			if c.limit != "" {
+				group["blobSizeLimit"] = c.limit
+			}
+			spec, err := json.Marshal(map[string]any{"sources": []any{group}})
+			require.NoError(t, err)
+
+			ctx := withCapabilities(context.Background(), testCapabilities)
+			ctx = oci.WithClient(ctx, &client)
+			p, err := policy.NewInertPolicy(ctx, string(spec))
+			require.NoError(t, err)
+
+			evaluator, err := NewConftestEvaluator(ctx, []source.PolicySource{
+				&source.PolicyUrl{Url: rules, Kind: source.PolicyKind},
+			}, p, p.Spec().Sources[0])
+			require.NoError(t, err)
+
+			results, _, err := evaluator.Evaluate(ctx, []string{path.Join(dir, "inputs")})
+			require.NoError(t, err)
+
+			failures := 0
+			for _, o := range results {
+				failures += len(o.Failures)
+			}
+			assert.Equal(t, c.failures, failures)
+		})
+	}
+}
+
 var testCapabilities string
 
 func init() {
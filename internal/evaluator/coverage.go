@@ -0,0 +1,220 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package evaluator
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Dispositions of the rules reported in the coverage, in the order of precedence. When a rule
+// produces multiple results, e.g. one for each term, the disposition with the highest
+// precedence is reported.
+const (
+	DispositionFailed       = "failed"
+	DispositionNotEffective = "not yet effective"
+	DispositionWarned       = "warned"
+	DispositionWaived       = "waived"
+	DispositionSkipped      = "skipped"
+	DispositionPassed       = "passed"
+	DispositionTrimmed      = "trimmed"
+	DispositionExcluded     = "excluded"
+	DispositionNotEvaluated = "not evaluated"
+)
+
+// RuleCoverage records the final disposition of a rule collected from the policy sources, and
+// the reason for it when the rule did not produce a result.
+type RuleCoverage struct {
+	Code        string `json:"code"`
+	Disposition string `json:"disposition"`
+	Reason      string `json:"reason,omitempty"`
+}
+
+// coverage tracks why rules did, or didn't, produce a result while the conftest results are
+// processed.
+type coverage struct {
+	rules policyRules
+	// excluded holds, by file name and code, the reason a rule was excluded
+	excluded map[string]map[string]string
+	// notEffective holds, by file name and code, the effective_on date of the rules whose
+	// failures were reported as warnings
+	notEffective map[string]map[string]string
+	// untrimmed holds, by file name, the codes reported before depends_on trimming
+	untrimmed map[string]map[string]bool
+	// trimmed holds, by code, the reason the results of a rule were trimmed
+	trimmed map[string]string
+}
+
+func newCoverage(rules policyRules) *coverage {
+	return &coverage{
+		rules:        rules,
+		excluded:     map[string]map[string]string{},
+		notEffective: map[string]map[string]string{},
+		untrimmed:    map[string]map[string]bool{},
+		trimmed:      map[string]string{},
+	}
+}
+
+func record(m map[string]map[string]string, fileName, code, value string) {
+	if m[fileName] == nil {
+		m[fileName] = map[string]string{}
+	}
+	if _, ok := m[fileName][code]; !ok {
+		m[fileName][code] = value
+	}
+}
+
+func (c *coverage) exclude(fileName string, result Result, reason string) {
+	record(c.excluded, fileName, ExtractStringFromMetadata(result, metadataCode), reason)
+}
+
+func (c *coverage) postpone(fileName string, result Result) {
+	record(c.notEffective, fileName, ExtractStringFromMetadata(result, metadataCode), ExtractStringFromMetadata(result, metadataEffectiveOn))
+}
+
+// beforeTrim records the rules reported before the results depending on failures, warnings
+// or skipped results are trimmed.
+func (c *coverage) beforeTrim(results []Outcome) {
+	for _, o := range results {
+		if c.untrimmed[o.FileName] == nil {
+			c.untrimmed[o.FileName] = map[string]bool{}
+		}
+		for _, rs := range [][]Result{o.Failures, o.Warnings, o.Skipped} {
+			for _, r := range rs {
+				c.untrimmed[o.FileName][ExtractStringFromMetadata(r, metadataCode)] = true
+			}
+		}
+		for _, r := range o.Successes {
+			c.untrimmed[o.FileName][ExtractStringFromMetadata(r, metadataCode)] = true
+		}
+	}
+}
+
+// complete sets the coverage of every rule on the outcome for the package of the rule. The
+// coverage of the rules whose package was not evaluated is set on the first outcome of the
+// input file.
+func (c *coverage) complete(results []Outcome) {
+	if len(c.rules) == 0 {
+		return
+	}
+
+	first := map[string]int{}
+	namespaces := map[string]map[string]int{}
+	dispositions := map[string]map[string]string{}
+	reasons := map[string]map[string]string{}
+	precedence := []string{DispositionFailed, DispositionNotEffective, DispositionWarned, DispositionWaived, DispositionSkipped, DispositionPassed}
+
+	for i, o := range results {
+		if _, ok := first[o.FileName]; !ok {
+			first[o.FileName] = i
+			namespaces[o.FileName] = map[string]int{}
+			dispositions[o.FileName] = map[string]string{}
+			reasons[o.FileName] = map[string]string{}
+		}
+		if _, ok := namespaces[o.FileName][o.Namespace]; !ok {
+			namespaces[o.FileName][o.Namespace] = i
+		}
+
+		for _, d := range []struct {
+			disposition string
+			results     []Result
+		}{
+			{DispositionFailed, o.Failures},
+			{DispositionWarned, o.Warnings},
+			{DispositionWaived, o.Exceptions},
+			{DispositionSkipped, o.Skipped},
+			{DispositionPassed, o.Successes},
+		} {
+			for _, r := range d.results {
+				code := ExtractStringFromMetadata(r, metadataCode)
+				if _, ok := c.rules[code]; !ok {
+					continue
+				}
+
+				disposition, reason := d.disposition, ""
+				switch disposition {
+				case DispositionWarned:
+					if effectiveOn, ok := c.notEffective[o.FileName][code]; ok {
+						disposition, reason = DispositionNotEffective, fmt.Sprintf("effective on %s", effectiveOn)
+					}
+				case DispositionWaived:
+					if e, ok := r.Metadata[metadataException].(map[string]string); ok {
+						reason = fmt.Sprintf("by exception %q: %s", e["value"], e["reason"])
+					}
+				}
+
+				if current, ok := dispositions[o.FileName][code]; ok && slices.Index(precedence, current) <= slices.Index(precedence, disposition) {
+					continue
+				}
+				dispositions[o.FileName][code] = disposition
+				reasons[o.FileName][code] = reason
+			}
+		}
+	}
+
+	codes := make([]string, 0, len(c.rules))
+	for code := range c.rules {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	for fileName, i := range first {
+		for _, code := range codes {
+			rc := RuleCoverage{Code: code}
+			if d, ok := dispositions[fileName][code]; ok {
+				rc.Disposition, rc.Reason = d, reasons[fileName][code]
+			} else if c.untrimmed[fileName][code] {
+				rc.Disposition, rc.Reason = DispositionTrimmed, c.trimmed[code]
+			} else if reason, ok := c.excluded[fileName][code]; ok {
+				rc.Disposition, rc.Reason = DispositionExcluded, reason
+			} else if _, ok := namespaces[fileName][c.rules[code].Package]; ok {
+				rc.Disposition, rc.Reason = DispositionNotEvaluated, "the rule did not produce a result"
+			} else {
+				rc.Disposition, rc.Reason = DispositionNotEvaluated, fmt.Sprintf("package %s was not evaluated", c.rules[code].Package)
+			}
+
+			at := i
+			if j, ok := namespaces[fileName][c.rules[code].Package]; ok {
+				at = j
+			}
+			results[at].Coverage = append(results[at].Coverage, rc)
+		}
+	}
+}
+
+// exclusionReason describes why the result was excluded by the include and exclude lists of
+// the policy configuration.
+func (c conftestEvaluator) exclusionReason(result Result) string {
+	matchers := makeMatchers(result)
+	includeScore := scoreMatches(matchers, c.include)
+	excludeScore := scoreMatches(matchers, c.exclude)
+
+	var excludedBy []string
+	for _, e := range c.exclude {
+		if matchesName(e, matchers) {
+			excludedBy = append(excludedBy, e)
+		}
+	}
+
+	if len(excludedBy) == 0 {
+		return fmt.Sprintf("not matched by `include: [%s]` (score %d vs %d)", strings.Join(c.include, ", "), includeScore, excludeScore)
+	}
+
+	return fmt.Sprintf("excluded by `exclude: [%s]` (score %d vs %d)", strings.Join(excludedBy, ", "), excludeScore, includeScore)
+}
@@ -0,0 +1,122 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unit
+
+package evaluator
+
+import (
+	"context"
+	"os"
+	"path"
+	"testing"
+	"testing/fstest"
+
+	"github.com/MakeNowJust/heredoc"
+	ecc "github.com/enterprise-contract/enterprise-contract-controller/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/enterprise-contract/ec-cli/internal/policy"
+	"github.com/enterprise-contract/ec-cli/internal/policy/source"
+)
+
+func TestConftestEvaluatorCoverage(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(path.Join(dir, "inputs"), 0755))
+	require.NoError(t, os.WriteFile(path.Join(dir, "inputs", "data.json"), []byte("{}"), 0600))
+
+	rules, err := rulesArchive(t, fstest.MapFS{
+		"coverage.rego": &fstest.MapFile{Data: []byte(heredoc.Doc(`
+			package coverage
+
+			import future.keywords.contains
+			import future.keywords.if
+
+			# METADATA
+			# custom:
+			#   short_name: failed
+			deny contains result if {
+				result := {"code": "coverage.failed", "msg": "Failed"}
+			}
+
+			# METADATA
+			# custom:
+			#   short_name: excluded
+			deny contains result if {
+				result := {"code": "coverage.excluded", "msg": "Excluded"}
+			}
+
+			# METADATA
+			# custom:
+			#   short_name: future
+			#   effective_on: 2099-01-01T00:00:00Z
+			deny contains result if {
+				result := {"code": "coverage.future", "msg": "Not yet effective"}
+			}
+
+			# METADATA
+			# custom:
+			#   short_name: dependent
+			#   depends_on:
+			#   - coverage.failed
+			deny contains result if {
+				false
+				result := {"code": "coverage.dependent", "msg": "Dependent"}
+			}
+
+			# METADATA
+			# custom:
+			#   short_name: passed
+			deny contains result if {
+				false
+				result := {"code": "coverage.passed", "msg": "Passed"}
+			}
+		`))},
+	})
+	require.NoError(t, err)
+
+	ctx := withCapabilities(context.Background(), testCapabilities)
+
+	p, err := policy.NewOfflinePolicy(ctx, "2014-05-31")
+	require.NoError(t, err)
+
+	p = p.WithSpec(ecc.EnterpriseContractPolicySpec{
+		Configuration: &ecc.EnterpriseContractPolicyConfiguration{
+			Exclude: []string{"coverage.excluded"},
+		},
+	})
+
+	evaluator, err := NewConftestEvaluator(ctx, []source.PolicySource{
+		&source.PolicyUrl{
+			Url:  rules,
+			Kind: source.PolicyKind,
+		},
+	}, p, ecc.Source{})
+	require.NoError(t, err)
+
+	results, _, err := evaluator.Evaluate(ctx, []string{path.Join(dir, "inputs")})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+
+	assert.Equal(t, []RuleCoverage{
+		{Code: "coverage.dependent", Disposition: DispositionTrimmed, Reason: "trimmed because dependency coverage.failed did not pass"},
+		{Code: "coverage.excluded", Disposition: DispositionExcluded, Reason: "excluded by `exclude: [coverage.excluded]` (score 110 vs 1)"},
+		{Code: "coverage.failed", Disposition: DispositionFailed},
+		{Code: "coverage.future", Disposition: DispositionNotEffective, Reason: "effective on 2099-01-01T00:00:00Z"},
+		{Code: "coverage.passed", Disposition: DispositionPassed},
+	}, results[0].Coverage)
+}
@@ -0,0 +1,237 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package evaluator
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/enterprise-contract/ec-cli/internal/opa/rule"
+)
+
+// dependencies is the graph of the depends_on relationships between the rules that produced
+// results, used to trim the results of rules depending, directly or transitively, on a rule
+// that did not pass.
+type dependencies struct {
+	// dependsOn holds, by code, the codes the rule depends on
+	dependsOn map[string][]string
+	// reported holds the codes of the failures, warnings and skipped results
+	reported map[string]bool
+	// chains holds, by code, the computed chain of dependencies leading to a reported rule
+	chains map[string][]string
+	// path holds the codes being visited, to stop at cycles
+	path []string
+	// components holds, by code, the index of the strongly connected component of the rules
+	// depending on each other in a cycle the rule is part of
+	components map[string]int
+}
+
+func newDependencies(results []Outcome) *dependencies {
+	d := &dependencies{
+		dependsOn: map[string][]string{},
+		reported:  map[string]bool{},
+		chains:    map[string][]string{},
+	}
+
+	for _, o := range results {
+		for _, rs := range [][]Result{o.Failures, o.Warnings, o.Skipped, o.Successes} {
+			for _, r := range rs {
+				code, ok := r.Metadata[metadataCode].(string)
+				if !ok {
+					continue
+				}
+				if deps, ok := r.Metadata[metadataDependsOn].([]string); ok && d.dependsOn[code] == nil {
+					d.dependsOn[code] = deps
+				}
+			}
+		}
+
+		for _, rs := range [][]Result{o.Failures, o.Warnings, o.Skipped} {
+			for _, r := range rs {
+				if code, ok := r.Metadata[metadataCode].(string); ok {
+					d.reported[code] = true
+				}
+			}
+		}
+	}
+
+	d.components = d.findCycles()
+
+	return d
+}
+
+// findCycles finds the rules depending on each other in a cycle, logs each cycle and returns
+// the index of the cycle by code of the rules in them.
+func (d *dependencies) findCycles() map[string]int {
+	byCode := map[string]int{}
+	for i, cycle := range rule.DependencyCycles(d.dependsOn) {
+		for _, code := range cycle.Codes {
+			byCode[code] = i
+		}
+		log.Warnf("Rules depend on each other in a cycle: %s", strings.Join(cycle.Path, " -> "))
+	}
+
+	return byCode
+}
+
+// inCycle reports whether all the codes are in the same cycle as the rule.
+func (d *dependencies) inCycle(code string, codes []string) bool {
+	component, ok := d.components[code]
+	if !ok {
+		return false
+	}
+
+	for _, c := range codes {
+		if i, ok := d.components[c]; !ok || i != component {
+			return false
+		}
+	}
+
+	return true
+}
+
+// chain returns the chain of dependencies from the rule, included, to a rule reported as a
+// failure, warning or skipped, or nil if the rule does not depend on one. The second value is
+// false if the chain could not be determined because of a dependency cycle, in which case it
+// is not memoized, as it depends on where the cycle was entered.
+func (d *dependencies) chain(code string) ([]string, bool) {
+	if d.reported[code] {
+		return []string{code}, true
+	}
+
+	if c, ok := d.chains[code]; ok {
+		return c, true
+	}
+
+	if slices.Contains(d.path, code) {
+		// cycles are logged when building the dependencies
+		return nil, false
+	}
+
+	d.path = append(d.path, code)
+	defer func() { d.path = d.path[:len(d.path)-1] }()
+
+	complete := true
+	for _, dep := range d.dependsOn[code] {
+		c, ok := d.chain(dep)
+		if c != nil {
+			found := append([]string{code}, c...)
+			d.chains[code] = found
+			return found, true
+		}
+		complete = complete && ok
+	}
+
+	if complete {
+		d.chains[code] = nil
+	}
+
+	return nil, complete
+}
+
+// trimReason returns why the results of the rule, depending on the given rules, are trimmed,
+// or an empty string if none of the rules was, directly or transitively, reported as a
+// failure, warning or skipped.
+func (d *dependencies) trimReason(code string, dependsOn []string) string {
+	var chains []string
+	for _, dep := range dependsOn {
+		c, _ := d.chain(dep)
+		// a chain leading back to the rule itself, or staying in its cycle, is not the
+		// reason, the rules reported in the cycle are reported as well, so trimming would
+		// hide them
+		if c == nil || slices.Contains(c, code) || d.inCycle(code, c) {
+			continue
+		}
+		chains = append(chains, strings.Join(c, " -> "))
+	}
+
+	if len(chains) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("trimmed because dependency %s did not pass", strings.Join(chains, ", "))
+}
+
+// trimDependents removes the results that depend, directly or transitively, on a rule reported
+// as a failure, warning or skipped, and returns by code the reason the results of a rule were
+// trimmed. Dependencies are declared by setting the metadata via metadataDependsOn.
+func trimDependents(results []Outcome) map[string]string {
+	d := newDependencies(results)
+	reasons := map[string]string{}
+
+	trimOutput := func(what []Result) []Result {
+		if what == nil {
+			// nil might get passed in, while this would not cause an issue, the
+			// function would return empty array and that would needlessly
+			// change the output
+			return nil
+		}
+
+		// holds leftover results, i.e. the ones that do not depend on a rule
+		// that did not pass
+		trimmed := make([]Result, 0, len(what))
+		for _, result := range what {
+			code := ExtractStringFromMetadata(result, metadataCode)
+			dependsOn, _ := result.Metadata[metadataDependsOn].([]string)
+			reason := d.trimReason(code, dependsOn)
+			if reason == "" {
+				trimmed = append(trimmed, result)
+				continue
+			}
+
+			if _, ok := reasons[code]; !ok {
+				reasons[code] = reason
+			}
+			log.Debugf("Result of rule %q %s", code, reason)
+		}
+
+		return trimmed
+	}
+
+	for i, checks := range results {
+		results[i].Failures = trimOutput(checks.Failures)
+		results[i].Warnings = trimOutput(checks.Warnings)
+		results[i].Skipped = trimOutput(checks.Skipped)
+		results[i].Successes = trimOutput(checks.Successes)
+	}
+
+	return reasons
+}
+
+// TrimDependents removes the results that depend, directly or transitively, on a rule reported
+// as a failure, warning or skipped, across the outcomes of all the evaluators of a component,
+// i.e. across source groups. Each evaluator trims its own results, so this only trims the
+// results depending on rules of other source groups. The coverage of the rules whose results
+// were trimmed is updated with the reason.
+func TrimDependents(results []Outcome) {
+	reasons := trimDependents(results)
+	if len(reasons) == 0 {
+		return
+	}
+
+	for i := range results {
+		for j, rc := range results[i].Coverage {
+			if reason, ok := reasons[rc.Code]; ok {
+				results[i].Coverage[j].Disposition = DispositionTrimmed
+				results[i].Coverage[j].Reason = reason
+			}
+		}
+	}
+}
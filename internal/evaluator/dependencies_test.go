@@ -0,0 +1,198 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unit
+
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func dependentResult(code string, dependsOn ...string) Result {
+	r := Result{Message: code, Metadata: map[string]interface{}{metadataCode: code}}
+	if len(dependsOn) > 0 {
+		r.Metadata[metadataDependsOn] = dependsOn
+	}
+
+	return r
+}
+
+func TestTrimDependentsMultipleDependencies(t *testing.T) {
+	results := []Outcome{
+		{
+			Failures: []Result{dependentResult("a.failure")},
+			Successes: []Result{
+				dependentResult("a.passed"),
+				dependentResult("a.one_failed", "a.passed", "a.failure", "a.unknown"),
+				dependentResult("a.none_failed", "a.passed", "a.unknown"),
+			},
+		},
+	}
+
+	reasons := trimDependents(results)
+
+	assert.Equal(t, []Result{dependentResult("a.failure")}, results[0].Failures)
+	assert.Equal(t, []Result{
+		dependentResult("a.passed"),
+		dependentResult("a.none_failed", "a.passed", "a.unknown"),
+	}, results[0].Successes)
+	assert.Equal(t, map[string]string{
+		"a.one_failed": "trimmed because dependency a.failure did not pass",
+	}, reasons)
+}
+
+func TestTrimDependentsTransitive(t *testing.T) {
+	results := []Outcome{
+		{
+			Warnings: []Result{dependentResult("a.c")},
+			Skipped:  []Result{dependentResult("a.x")},
+			Successes: []Result{
+				dependentResult("a.b", "a.c"),
+				dependentResult("a.a", "a.b"),
+				dependentResult("a.top", "a.a", "a.x"),
+				dependentResult("a.other"),
+			},
+		},
+	}
+
+	reasons := trimDependents(results)
+
+	assert.Equal(t, []Result{dependentResult("a.other")}, results[0].Successes)
+	assert.Equal(t, map[string]string{
+		"a.b":   "trimmed because dependency a.c did not pass",
+		"a.a":   "trimmed because dependency a.b -> a.c did not pass",
+		"a.top": "trimmed because dependency a.a -> a.b -> a.c, a.x did not pass",
+	}, reasons)
+}
+
+func TestTrimDependentsCycle(t *testing.T) {
+	results := []Outcome{
+		{
+			Failures: []Result{dependentResult("a.failure")},
+			Successes: []Result{
+				// a.one and a.two depend on each other, a.two also depends on a.failure
+				dependentResult("a.one", "a.two"),
+				dependentResult("a.two", "a.one", "a.failure"),
+				// a.three and a.four depend on each other only
+				dependentResult("a.three", "a.four"),
+				dependentResult("a.four", "a.three"),
+			},
+		},
+	}
+
+	reasons := trimDependents(results)
+
+	assert.Equal(t, []Result{
+		dependentResult("a.three", "a.four"),
+		dependentResult("a.four", "a.three"),
+	}, results[0].Successes)
+	assert.Equal(t, map[string]string{
+		"a.one": "trimmed because dependency a.two -> a.failure did not pass",
+		"a.two": "trimmed because dependency a.failure did not pass",
+	}, reasons)
+}
+
+func TestTrimDependentsCycleFailing(t *testing.T) {
+	results := []Outcome{
+		{
+			Failures: []Result{
+				// a.one and a.two depend on each other and both fail
+				dependentResult("a.one", "a.two"),
+				dependentResult("a.two", "a.one"),
+			},
+			Warnings: []Result{
+				// a.three depends on the cycle from outside of it
+				dependentResult("a.three", "a.one"),
+			},
+		},
+	}
+
+	reasons := trimDependents(results)
+
+	assert.Equal(t, []Result{
+		dependentResult("a.one", "a.two"),
+		dependentResult("a.two", "a.one"),
+	}, results[0].Failures)
+	assert.Empty(t, results[0].Warnings)
+	assert.Equal(t, map[string]string{
+		"a.three": "trimmed because dependency a.one did not pass",
+	}, reasons)
+}
+
+func TestTrimDependentsSelfDependency(t *testing.T) {
+	results := []Outcome{
+		{
+			Failures: []Result{dependentResult("a.self", "a.self")},
+		},
+	}
+
+	reasons := trimDependents(results)
+
+	assert.Equal(t, []Result{dependentResult("a.self", "a.self")}, results[0].Failures)
+	assert.Empty(t, reasons)
+}
+
+func TestFindCycles(t *testing.T) {
+	d := newDependencies([]Outcome{
+		{
+			Successes: []Result{
+				dependentResult("a.one", "a.two"),
+				dependentResult("a.two", "a.three", "a.other"),
+				dependentResult("a.three", "a.one"),
+				dependentResult("a.self", "a.self"),
+				dependentResult("a.other"),
+			},
+		},
+	})
+
+	assert.Equal(t, d.components["a.one"], d.components["a.two"])
+	assert.Equal(t, d.components["a.one"], d.components["a.three"])
+	assert.NotEqual(t, d.components["a.one"], d.components["a.self"])
+	assert.NotContains(t, d.components, "a.other")
+}
+
+func TestTrimDependentsAcrossSourceGroups(t *testing.T) {
+	results := []Outcome{
+		{
+			Namespace: "a",
+			Failures:  []Result{dependentResult("a.failure")},
+			Coverage:  []RuleCoverage{{Code: "a.failure", Disposition: DispositionFailed}},
+		},
+		{
+			Namespace: "b",
+			Successes: []Result{
+				dependentResult("b.dependent", "a.failure"),
+				dependentResult("b.independent"),
+			},
+			Coverage: []RuleCoverage{
+				{Code: "b.dependent", Disposition: DispositionPassed},
+				{Code: "b.independent", Disposition: DispositionPassed},
+			},
+		},
+	}
+
+	TrimDependents(results)
+
+	assert.Equal(t, []Result{dependentResult("b.independent")}, results[1].Successes)
+	assert.Equal(t, []RuleCoverage{
+		{Code: "b.dependent", Disposition: DispositionTrimmed, Reason: "trimmed because dependency a.failure did not pass"},
+		{Code: "b.independent", Disposition: DispositionPassed},
+	}, results[1].Coverage)
+	assert.Equal(t, []RuleCoverage{{Code: "a.failure", Disposition: DispositionFailed}}, results[0].Coverage)
+}
@@ -40,6 +40,8 @@ type Outcome struct {
 	Warnings   []Result `json:"warnings,omitempty"`
 	Failures   []Result `json:"failures,omitempty"`
 	Exceptions []Result `json:"exceptions,omitempty"`
+	// Coverage holds the disposition of the rules in the namespace, see RuleCoverage.
+	Coverage []RuleCoverage `json:"-"`
 }
 
 type Result struct {
@@ -0,0 +1,278 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package evaluator
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/open-policy-agent/conftest/parser"
+	conftest "github.com/open-policy-agent/conftest/policy"
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/open-policy-agent/opa/rego"
+	"github.com/open-policy-agent/opa/topdown"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	explainKey contextKey = "ec.evaluator.explain"
+
+	metadataExplanation = "explanation"
+)
+
+// WithExplain returns a context requesting explanations for the rules with the given codes.
+// Evaluators created with the returned context attach an Explanation to the results of those
+// rules.
+func WithExplain(ctx context.Context, codes []string) context.Context {
+	return context.WithValue(ctx, explainKey, codes)
+}
+
+func explainCodes(ctx context.Context) []string {
+	codes, _ := ctx.Value(explainKey).([]string)
+	return codes
+}
+
+// Explanation describes how a rule was evaluated against an input. It is gathered by tracing
+// the evaluation of the rule's body only, i.e. the evaluation of functions and rules called
+// from the body is not included.
+type Explanation struct {
+	// Location of the rule in the policy, as file:row.
+	Location string `json:"location"`
+	// Steps are the lines of the rule's body in the order they were evaluated, prefixed with
+	// their row number. A line evaluated multiple times is listed once. A step is marked as
+	// failed if an expression on the line was false at least once.
+	Steps []string `json:"steps,omitempty"`
+	// InputPaths are the references to the input made by the rule's body.
+	InputPaths []string `json:"inputPaths,omitempty"`
+	// Notes are the messages from calls to the trace built-in function in the rule's body.
+	Notes []string `json:"notes,omitempty"`
+}
+
+// String renders the explanation in a human readable format.
+func (e Explanation) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "rule at %s\n", e.Location)
+	if len(e.Steps) > 0 {
+		b.WriteString("evaluated:\n")
+		for _, s := range e.Steps {
+			fmt.Fprintf(&b, "  %s\n", s)
+		}
+	}
+	if len(e.InputPaths) > 0 {
+		b.WriteString("input paths:\n")
+		for _, p := range e.InputPaths {
+			fmt.Fprintf(&b, "  %s\n", p)
+		}
+	}
+	if len(e.Notes) > 0 {
+		b.WriteString("notes:\n")
+		for _, n := range e.Notes {
+			fmt.Fprintf(&b, "  %s\n", n)
+		}
+	}
+
+	return b.String()
+}
+
+// explainer evaluates rules again, this time tracing the evaluation, to produce explanations.
+type explainer struct {
+	engine *conftest.Engine
+	// rules holds the annotations, by code, of the rules to explain
+	rules map[string]*ast.AnnotationsRef
+	// inputs caches the parsed inputs by file name
+	inputs map[string]any
+	// explanations caches the explanations by file name and code
+	explanations map[string]map[string]*Explanation
+}
+
+func (c conftestEvaluator) newExplainer(annotations map[string]*ast.AnnotationsRef) (*explainer, error) {
+	rules := map[string]*ast.AnnotationsRef{}
+	for _, code := range c.explain {
+		if a, ok := annotations[code]; ok {
+			rules[code] = a
+		} else {
+			log.Debugf("No rule with code %q to explain in the policy sources", code)
+		}
+	}
+
+	if len(rules) == 0 {
+		return nil, nil
+	}
+
+	engine, err := conftest.LoadWithData([]string{c.policyDir}, []string{c.dataDir}, c.CapabilitiesPath(), false)
+	if err != nil {
+		return nil, fmt.Errorf("loading policies to explain: %w", err)
+	}
+
+	return &explainer{
+		engine:       engine,
+		rules:        rules,
+		inputs:       map[string]any{},
+		explanations: map[string]map[string]*Explanation{},
+	}, nil
+}
+
+// addExplanations adds an explanation to each of the results of a rule to explain.
+func (e *explainer) addExplanations(ctx context.Context, fileName string, results []Result) error {
+	for i := range results {
+		code := ExtractStringFromMetadata(results[i], metadataCode)
+		if _, ok := e.rules[code]; !ok {
+			continue
+		}
+
+		explanation, err := e.explanation(ctx, fileName, code)
+		if err != nil {
+			return err
+		}
+
+		if results[i].Metadata == nil {
+			results[i].Metadata = map[string]any{}
+		}
+		results[i].Metadata[metadataExplanation] = *explanation
+	}
+
+	return nil
+}
+
+func (e *explainer) explanation(ctx context.Context, fileName, code string) (*Explanation, error) {
+	if explanation, ok := e.explanations[fileName][code]; ok {
+		return explanation, nil
+	}
+
+	input, ok := e.inputs[fileName]
+	if !ok {
+		configurations, err := parser.ParseConfigurations([]string{fileName})
+		if err != nil {
+			return nil, fmt.Errorf("parsing input to explain: %w", err)
+		}
+		input = configurations[fileName]
+		e.inputs[fileName] = input
+	}
+
+	a := e.rules[code]
+	tracer := topdown.NewBufferTracer()
+	r := rego.New(
+		rego.Query(a.Path.String()),
+		rego.Compiler(e.engine.Compiler()),
+		rego.Store(e.engine.Store()),
+		rego.Input(input),
+		rego.QueryTracer(tracer),
+	)
+	if _, err := r.Eval(ctx); err != nil {
+		return nil, fmt.Errorf("evaluating rule %q to explain: %w", code, err)
+	}
+
+	explanation := explain(a, *tracer)
+	if e.explanations[fileName] == nil {
+		e.explanations[fileName] = map[string]*Explanation{}
+	}
+	e.explanations[fileName][code] = &explanation
+
+	return &explanation, nil
+}
+
+// explain builds the explanation of the annotated rule from the trace events. Only the events
+// located within the rule are considered.
+func explain(a *ast.AnnotationsRef, events []*topdown.Event) Explanation {
+	explanation := Explanation{}
+
+	rule := a.GetRule()
+	if rule == nil || rule.Location == nil {
+		return explanation
+	}
+
+	file := rule.Location.File
+	first := rule.Location.Row
+	lines := strings.Split(string(rule.Location.Text), "\n")
+	last := first + len(lines) - 1
+	explanation.Location = fmt.Sprintf("%s:%d", file, first)
+
+	// The location of the annotated rule is relative to the policy source while the location of
+	// the trace events is where the source was downloaded to
+	within := func(l *ast.Location) bool {
+		if l == nil || l.Row < first || l.Row > last {
+			return false
+		}
+		return l.File == file || strings.HasSuffix(l.File, "/"+file)
+	}
+
+	// The compiler may rewrite an expression into several, e.g. nested function calls, so the
+	// steps are tracked by row using the text of the rule from the policy source
+	rows := []int{}
+	failed := map[int]bool{}
+	inputPaths := map[string]bool{}
+	for _, event := range events {
+		if !within(event.Location) {
+			continue
+		}
+
+		switch event.Op {
+		case topdown.NoteOp:
+			explanation.Notes = append(explanation.Notes, event.Message)
+		case topdown.EvalOp, topdown.FailOp:
+			expr, ok := event.Node.(*ast.Expr)
+			if !ok {
+				continue
+			}
+			row := event.Location.Row
+			if !slices.Contains(rows, row) {
+				rows = append(rows, row)
+			}
+			if event.Op == topdown.FailOp {
+				failed[row] = true
+			}
+
+			ast.WalkRefs(expr, func(ref ast.Ref) bool {
+				if ref.HasPrefix(ast.InputRootRef) {
+					inputPaths[inputPath(ref)] = true
+				}
+				return false
+			})
+		}
+	}
+
+	for _, row := range rows {
+		step := fmt.Sprintf("%d: %s", row, strings.TrimSpace(lines[row-first]))
+		if failed[row] {
+			step += " (failed)"
+		}
+		explanation.Steps = append(explanation.Steps, step)
+	}
+
+	for p := range inputPaths {
+		explanation.InputPaths = append(explanation.InputPaths, p)
+	}
+	sort.Strings(explanation.InputPaths)
+
+	return explanation
+}
+
+// inputPath renders the reference to the input replacing the variables, which are renamed by the
+// compiler, with the wildcard.
+func inputPath(ref ast.Ref) string {
+	path := ref.Copy()
+	for i := 1; i < len(path); i++ {
+		if _, ok := path[i].Value.(ast.Var); ok {
+			path[i] = ast.VarTerm("_")
+		}
+	}
+
+	return path.String()
+}
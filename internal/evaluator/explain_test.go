@@ -0,0 +1,132 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unit
+
+package evaluator
+
+import (
+	"context"
+	"os"
+	"path"
+	"testing"
+	"testing/fstest"
+
+	"github.com/MakeNowJust/heredoc"
+	ecc "github.com/enterprise-contract/enterprise-contract-controller/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/enterprise-contract/ec-cli/internal/policy"
+	"github.com/enterprise-contract/ec-cli/internal/policy/source"
+)
+
+func TestConftestEvaluatorExplain(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(path.Join(dir, "inputs"), 0755))
+	require.NoError(t, os.WriteFile(path.Join(dir, "inputs", "data.json"), []byte(`{"image": {"labels": {"vendor": "acme"}}}`), 0600))
+
+	rules, err := rulesArchive(t, fstest.MapFS{
+		"explain.rego": &fstest.MapFile{Data: []byte(heredoc.Doc(`
+			package explain
+
+			import future.keywords.contains
+			import future.keywords.if
+
+			# METADATA
+			# title: Vendor
+			# custom:
+			#   short_name: vendor
+			deny contains result if {
+				vendor := input.image.labels.vendor
+				trace(sprintf("vendor is %s", [vendor]))
+				vendor != "redhat"
+				result := {"code": "explain.vendor", "msg": "Unexpected vendor"}
+			}
+
+			# METADATA
+			# title: Name
+			# custom:
+			#   short_name: name
+			deny contains result if {
+				not input.image.labels.name
+				result := {"code": "explain.name", "msg": "Missing name"}
+			}
+		`))},
+	})
+	require.NoError(t, err)
+
+	ctx := withCapabilities(context.Background(), testCapabilities)
+	ctx = WithExplain(ctx, []string{"explain.vendor", "explain.unknown"})
+
+	p, err := policy.NewOfflinePolicy(ctx, "2014-05-31")
+	require.NoError(t, err)
+
+	evaluator, err := NewConftestEvaluator(ctx, []source.PolicySource{
+		&source.PolicyUrl{
+			Url:  rules,
+			Kind: source.PolicyKind,
+		},
+	}, p, ecc.Source{})
+	require.NoError(t, err)
+
+	results, _, err := evaluator.Evaluate(ctx, []string{path.Join(dir, "inputs")})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	require.Len(t, results[0].Failures, 2)
+
+	for _, failure := range results[0].Failures {
+		switch failure.Metadata[metadataCode] {
+		case "explain.vendor":
+			assert.Equal(t, Explanation{
+				Location: "explain.rego:10",
+				Steps: []string{
+					"11: vendor := input.image.labels.vendor",
+					`12: trace(sprintf("vendor is %s", [vendor]))`,
+					`13: vendor != "redhat"`,
+					`14: result := {"code": "explain.vendor", "msg": "Unexpected vendor"}`,
+				},
+				InputPaths: []string{"input.image.labels.vendor"},
+				Notes:      []string{"vendor is acme"},
+			}, failure.Metadata[metadataExplanation])
+		case "explain.name":
+			assert.NotContains(t, failure.Metadata, metadataExplanation)
+		default:
+			t.Errorf("unexpected failure: %v", failure)
+		}
+	}
+}
+
+func TestExplanationString(t *testing.T) {
+	e := Explanation{
+		Location:   "policy.rego:10",
+		Steps:      []string{"11: input.x == 1 (failed)"},
+		InputPaths: []string{"input.x"},
+		Notes:      []string{"x is 2"},
+	}
+
+	assert.Equal(t, heredoc.Doc(`
+		rule at policy.rego:10
+		evaluated:
+		  11: input.x == 1 (failed)
+		input paths:
+		  input.x
+		notes:
+		  x is 2
+	`), e.String())
+
+	assert.Equal(t, "rule at policy.rego:10\n", Explanation{Location: "policy.rego:10"}.String())
+}
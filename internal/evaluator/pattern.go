@@ -0,0 +1,170 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package evaluator
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Scores of the patterns, see score. A pattern is less specific than a literal name, but more
+// specific than a wildcard covering everything.
+const (
+	packagePatternScore = 5
+	rulePatternScore    = 50
+	termPatternScore    = 50
+	// A regular expression cannot be split into a package, a rule and a term, so it is scored
+	// as a glob of both the package and the rule name, e.g. "pkg_*.rule_*".
+	regexScore = packagePatternScore + rulePatternScore
+)
+
+// compiledPatterns caches the regular expressions compiled from the include and exclude
+// entries, as the same entries are matched against every result. Entries that are not valid
+// patterns are stored as a nil *regexp.Regexp.
+var compiledPatterns sync.Map
+
+// isRegex returns true if the name is a regular expression, i.e. it is enclosed in slashes,
+// e.g. "/^sbom_.*\.allowed_/".
+func isRegex(name string) bool {
+	return len(name) > 2 && strings.HasPrefix(name, "/") && strings.HasSuffix(name, "/")
+}
+
+// isGlob returns true if the given part of a name contains any of the glob wildcards "*" or
+// "?".
+func isGlob(part string) bool {
+	return strings.ContainsAny(part, "*?")
+}
+
+// isPattern returns true if the name is a regular expression, or a glob that needs to be
+// matched against the rule. The "*" name covers everything and is matched literally.
+func isPattern(name string) bool {
+	return isRegex(name) || (name != "*" && !strings.HasPrefix(name, "@") && isGlob(name))
+}
+
+// ValidatePattern returns an error if the name is a regular expression that cannot be
+// compiled.
+func ValidatePattern(name string) error {
+	if !isRegex(name) {
+		return nil
+	}
+
+	_, err := regexp.Compile(name[1 : len(name)-1])
+	return err
+}
+
+// matchesName returns true if the include or exclude name matches any of the matchers, as
+// returned by makeMatchers. Names that are not patterns need to equal one of the matchers.
+func matchesName(name string, matchers []string) bool {
+	if !isPattern(name) {
+		for _, m := range matchers {
+			if m == name {
+				return true
+			}
+		}
+		return false
+	}
+
+	re := compilePattern(name)
+	if re == nil {
+		return false
+	}
+
+	for _, m := range matchers {
+		if isRuleMatcher(m) && re.MatchString(m) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isRuleMatcher returns true if the matcher names a particular rule, e.g. "pkg.rule" or
+// "pkg.rule:term". Patterns are matched only against these, as the other matchers are
+// less specific forms of the same.
+func isRuleMatcher(matcher string) bool {
+	name, _, _ := strings.Cut(matcher, ":")
+	_, rule, found := strings.Cut(name, ".")
+	return found && rule != "*"
+}
+
+func compilePattern(name string) *regexp.Regexp {
+	if re, ok := compiledPatterns.Load(name); ok {
+		return re.(*regexp.Regexp)
+	}
+
+	var expr string
+	if isRegex(name) {
+		expr = name[1 : len(name)-1]
+	} else {
+		expr = globExpression(name)
+	}
+
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		log.Warnf("Ignoring invalid pattern %q: %v", name, err)
+		re = nil
+	}
+	compiledPatterns.Store(name, re)
+
+	return re
+}
+
+// globExpression converts a glob name into a regular expression matching "pkg.rule" and
+// "pkg.rule:term" matchers. The wildcards within the package and the rule name do not cross
+// the "." and ":" separators, the wildcards within the term match any character. A name
+// without a rule name covers every rule in the matching packages.
+func globExpression(name string) string {
+	shortName, term, hasTerm := strings.Cut(name, ":")
+	pkg, rule, hasRule := strings.Cut(shortName, ".")
+	if !hasRule || rule == "" {
+		rule = "*"
+	}
+
+	var b strings.Builder
+	b.WriteString("^")
+	b.WriteString(globPart(pkg, "[^.:]"))
+	b.WriteString(`\.`)
+	b.WriteString(globPart(rule, "[^.:]"))
+	if hasTerm {
+		b.WriteString(":")
+		b.WriteString(globPart(term, "."))
+	}
+	b.WriteString("$")
+
+	return b.String()
+}
+
+// globPart converts the glob into a regular expression, where "?" matches a single character
+// matched by the char expression, and "*" matches any sequence of those.
+func globPart(glob, char string) string {
+	var b strings.Builder
+	for _, r := range glob {
+		switch r {
+		case '*':
+			b.WriteString(char + "*")
+		case '?':
+			b.WriteString(char)
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+
+	return b.String()
+}
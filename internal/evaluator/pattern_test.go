@@ -0,0 +1,78 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unit
+
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchesName(t *testing.T) {
+	withTerm := makeMatchers(Result{Metadata: map[string]any{
+		metadataCode:        "sbom_spdx.allowed_license",
+		metadataTerm:        "registry.io/repo:tag",
+		metadataCollections: []string{"minimal"},
+	}})
+	withoutTerm := makeMatchers(Result{Metadata: map[string]any{
+		metadataCode: "sbom_spdx.allowed_license",
+	}})
+
+	cases := []struct {
+		name        string
+		withTerm    bool
+		withoutTerm bool
+	}{
+		{name: "*", withTerm: true, withoutTerm: true},
+		{name: "@minimal", withTerm: true},
+		{name: "sbom_spdx", withTerm: true, withoutTerm: true},
+		{name: "sbom_spdx.allowed_license", withTerm: true, withoutTerm: true},
+		{name: "sbom_*", withTerm: true, withoutTerm: true},
+		{name: "sbom_*.*", withTerm: true, withoutTerm: true},
+		{name: "sbom_????", withTerm: true, withoutTerm: true},
+		{name: "sbom_???"},
+		{name: "sbom*.allowed_*", withTerm: true, withoutTerm: true},
+		{name: "*.allowed_license", withTerm: true, withoutTerm: true},
+		{name: "*.allowed"},
+		{name: "sbom_*.allowed_*:registry.io/*", withTerm: true},
+		{name: "*:registry.io/*", withTerm: true},
+		{name: "sbom_spdx:*", withTerm: true},
+		{name: "*:registry.example.com/*"},
+		{name: "sbom_*.allowed_*:repo"},
+		// wildcards within a package name do not cross into the rule name
+		{name: "sbom*license"},
+		{name: "/^sbom_.*\\.allowed_/", withTerm: true, withoutTerm: true},
+		{name: "/:registry\\.io/", withTerm: true},
+		{name: "/^sbom_cyclonedx/"},
+		{name: "/(/"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.withTerm, matchesName(c.name, withTerm), "with term")
+			assert.Equal(t, c.withoutTerm, matchesName(c.name, withoutTerm), "without term")
+		})
+	}
+}
+
+func TestValidatePattern(t *testing.T) {
+	assert.NoError(t, ValidatePattern("pkg_*"))
+	assert.NoError(t, ValidatePattern("/^pkg_.*/"))
+	assert.Error(t, ValidatePattern("/(/"))
+}
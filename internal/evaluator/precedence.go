@@ -0,0 +1,368 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package evaluator
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/open-policy-agent/opa/format"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+
+	"github.com/enterprise-contract/ec-cli/internal/opa/rule"
+	"github.com/enterprise-contract/ec-cli/internal/policy"
+)
+
+// metadataSource holds the URL of the policy source the rule producing the result was found
+// in.
+const metadataSource = "source"
+
+// sourceRules holds the annotations of the rules found in a policy source.
+type sourceRules struct {
+	url string
+	// dir is the directory the policy source was downloaded to, the annotations hold file
+	// paths relative to it.
+	dir         string
+	annotations []*ast.AnnotationsRef
+}
+
+// shadowedRule is a rule not used because a rule with the same code, from another policy
+// source, takes precedence.
+type shadowedRule struct {
+	dir        string
+	annotation *ast.AnnotationsRef
+}
+
+// collectRules collects the rules from the policy sources, given in the order they are listed
+// in the source group. When there is more than one policy source, each rule records the one it
+// was found in. The codes of rules from policy sources with a namespace are prefixed with it.
+// Rules with the same code, or defined in the same package with the same code, which cannot be
+// told apart in the results, are duplicates. The rule precedence of the source group
+// determines which of the duplicates is used, the other rules are returned as shadowed. The
+// annotations of the rules used are returned by code. Rules without their own documentation
+// URL or code prefixes use the ones of the source group.
+func collectRules(options policy.SourceOptions, sources []sourceRules) (policyRules, map[string]*ast.AnnotationsRef, []shadowedRule, error) {
+	rules := policyRules{}
+	// annotations of the collected rules, by code, and the directory they were found in
+	collected := map[string]shadowedRule{}
+	// codes of the collected rules, by the package and code reported in their results
+	keys := map[ruleKey]string{}
+	var shadowed []shadowedRule
+
+	for _, s := range sources {
+		rule.DefaultConventions(s.annotations, options.DocumentationUrl, options.CodePrefixes)
+
+		found := policyRules{}
+		annotations := map[string]*ast.AnnotationsRef{}
+		for _, a := range s.annotations {
+			if a.Annotations == nil {
+				continue
+			}
+			if err := found.collect(a); err != nil {
+				return nil, nil, nil, err
+			}
+			annotations[rule.RuleInfo(a).Code] = a
+		}
+
+		namespace := options.RuleNamespaces[s.url]
+		for plain, info := range found {
+			code := plain
+			if namespace != "" {
+				code = namespace + "/" + plain
+				info.Code = code
+			}
+			info.Source = s.url
+			key := ruleKey{pkg: info.Package, code: plain}
+			current := shadowedRule{dir: s.dir, annotation: annotations[plain]}
+
+			existingCode := code
+			_, duplicate := rules[code]
+			if !duplicate {
+				existingCode, duplicate = keys[key]
+			}
+
+			if !duplicate {
+				rules[code] = info
+				collected[code] = current
+				keys[key] = code
+				continue
+			}
+
+			existing := rules[existingCode]
+			replace, err := PreferDuplicate(options, existing, info)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+
+			if !replace {
+				log.Debugf("Rule %q from %q is shadowed by the rule %q from %q", code, s.url, existingCode, existing.Source)
+				shadowed = append(shadowed, current)
+				continue
+			}
+
+			log.Debugf("Rule %q from %q is shadowed by the rule %q from %q", existingCode, existing.Source, code, s.url)
+			shadowed = append(shadowed, collected[existingCode])
+			delete(rules, existingCode)
+			delete(collected, existingCode)
+			rules[code] = info
+			collected[code] = current
+			delete(keys, ruleKey{pkg: existing.Package, code: unnamespaced(existingCode)})
+			keys[key] = code
+		}
+	}
+
+	// the source is only worth recording when there is more than one
+	if len(sources) == 1 {
+		for code, info := range rules {
+			info.Source = ""
+			rules[code] = info
+		}
+	}
+
+	used := make(map[string]*ast.AnnotationsRef, len(collected))
+	for code, c := range collected {
+		used[code] = c.annotation
+	}
+
+	return rules, used, shadowed, nil
+}
+
+// CollectRules returns the information of the rules used when the source group is evaluated,
+// by code, given their annotations by policy source URL. The policy sources are taken in the
+// order of the URLs, URLs without annotations are skipped. The same code namespacing and rule
+// precedence logic used when evaluating is applied.
+func CollectRules(options policy.SourceOptions, urls []string, annotations map[string][]*ast.AnnotationsRef) (map[string]rule.Info, error) {
+	sources := make([]sourceRules, 0, len(urls))
+	for _, url := range urls {
+		if a, ok := annotations[url]; ok {
+			sources = append(sources, sourceRules{url: url, annotations: a})
+		}
+	}
+
+	rules, _, _, err := collectRules(options, sources)
+	return rules, err
+}
+
+// PreferDuplicate returns whether, by the rule precedence of the source group, the duplicate
+// rule, found in a later policy source, is used instead of the existing rule with the same
+// code. An error is returned if the rule precedence does not allow duplicates.
+func PreferDuplicate(options policy.SourceOptions, existing, duplicate rule.Info) (bool, error) {
+	switch precedence := options.Precedence(); precedence {
+	case policy.RulePrecedenceFirst:
+		return false, nil
+	case policy.RulePrecedenceLast:
+		return true, nil
+	case policy.RulePrecedenceError:
+		return false, fmt.Errorf("found a second rule with the same code: `%s`, defined in %q and %q", duplicate.Code, existing.Source, duplicate.Source)
+	default:
+		return false, fmt.Errorf("unknown rule precedence %q", precedence)
+	}
+}
+
+// unnamespaced returns the code without the namespace prefix.
+func unnamespaced(code string) string {
+	if _, c, found := strings.Cut(code, "/"); found {
+		return c
+	}
+
+	return code
+}
+
+// removeShadowedRules removes the shadowed rules, including their annotations, from the
+// parsed modules of the policy sources, so that they are not evaluated, and writes the
+// modules back formatted. The policy sources with shadowed rules are detached first, the
+// files of the policy sources themselves are never changed.
+func removeShadowedRules(fs afero.Fs, shadowed []shadowedRule) error {
+	detached := map[string]bool{}
+	// annotations of the shadowed rules by the file they are defined in
+	byFile := map[string][]*ast.AnnotationsRef{}
+	var files []string
+	for _, s := range shadowed {
+		r := s.annotation.GetRule()
+		if r == nil || r.Location == nil {
+			continue
+		}
+
+		if !detached[s.dir] {
+			if err := detachSource(fs, s.dir); err != nil {
+				return err
+			}
+			detached[s.dir] = true
+		}
+
+		file := filepath.Join(s.dir, r.Location.File)
+		if _, ok := byFile[file]; !ok {
+			files = append(files, file)
+		}
+		byFile[file] = append(byFile[file], s.annotation)
+	}
+
+	for _, file := range files {
+		content, err := afero.ReadFile(fs, file)
+		if err != nil {
+			return err
+		}
+
+		module, err := ast.ParseModuleWithOpts(file, string(content), ast.ParserOptions{ProcessAnnotation: true})
+		if err != nil {
+			return err
+		}
+
+		for _, a := range byFile[file] {
+			dropRule(module, a)
+		}
+
+		formatted, err := format.Ast(module)
+		if err != nil {
+			return err
+		}
+
+		if err := afero.WriteFile(fs, file, formatted, 0644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// dropRule removes the rule, with its annotations and the comments in it, from the module. The
+// module is parsed from the same source the rule was, so the rule is found by its location.
+func dropRule(module *ast.Module, a *ast.AnnotationsRef) {
+	r := a.GetRule()
+	first := r.Location.Row
+	if l := a.Annotations.Location; l != nil && l.Row < first {
+		first = l.Row
+	}
+	last := r.Location.Row + strings.Count(string(r.Location.Text), "\n")
+	within := func(l *ast.Location) bool {
+		return l != nil && l.Row >= first && l.Row <= last
+	}
+
+	module.Rules = slices.DeleteFunc(module.Rules, func(m *ast.Rule) bool {
+		return within(m.Location)
+	})
+	module.Annotations = slices.DeleteFunc(module.Annotations, func(m *ast.Annotations) bool {
+		return within(m.Location)
+	})
+	module.Comments = slices.DeleteFunc(module.Comments, func(c *ast.Comment) bool {
+		return within(c.Location)
+	})
+}
+
+// detachSource replaces the directory a policy source was downloaded to with a copy of its
+// files. Policy sources in local directories are symlinked to, not copied, when downloaded,
+// and the files of a policy source can be symlinks, so the files are only safe to change once
+// detached.
+func detachSource(fs afero.Fs, dir string) error {
+	files := map[string][]byte{}
+	if err := readSourceFiles(fs, dir, "", files); err != nil {
+		return err
+	}
+
+	// removes the symlink, not the directory it points to
+	if err := fs.RemoveAll(dir); err != nil {
+		return err
+	}
+
+	for name, content := range files {
+		file := filepath.Join(dir, name)
+		if err := fs.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			return err
+		}
+		if err := afero.WriteFile(fs, file, content, 0644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// readSourceFiles reads the files in the directory, by path relative to the root directory,
+// following symlinks. Version control directories are skipped.
+func readSourceFiles(fs afero.Fs, root, dir string, files map[string][]byte) error {
+	entries, err := afero.ReadDir(fs, filepath.Join(root, dir))
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		name := filepath.Join(dir, e.Name())
+		info, err := fs.Stat(filepath.Join(root, name))
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			if e.Name() == ".git" {
+				continue
+			}
+			if err := readSourceFiles(fs, root, name, files); err != nil {
+				return err
+			}
+			continue
+		}
+
+		content, err := afero.ReadFile(fs, filepath.Join(root, name))
+		if err != nil {
+			return err
+		}
+		files[name] = content
+	}
+
+	return nil
+}
+
+// ruleKey identifies a rule by the package it is defined in and its code, as reported in the
+// results of the rule.
+type ruleKey struct {
+	pkg  string
+	code string
+}
+
+// namespacedCodes returns the codes of the rules from policy sources with a namespace, by the
+// package and code reported in their results.
+func namespacedCodes(rules policyRules) map[ruleKey]string {
+	codes := map[ruleKey]string{}
+	for code, info := range rules {
+		if plain := unnamespaced(code); plain != code {
+			codes[ruleKey{pkg: info.Package, code: plain}] = code
+		}
+	}
+
+	return codes
+}
+
+// namespaceResult replaces the code of the result with the namespaced code of the rule
+// producing it, if the rule comes from a policy source with a namespace.
+func namespaceResult(result *Result, pkg string, codes map[ruleKey]string) {
+	if len(codes) == 0 {
+		return
+	}
+
+	code, ok := result.Metadata[metadataCode].(string)
+	if !ok {
+		return
+	}
+
+	if namespaced, ok := codes[ruleKey{pkg: pkg, code: code}]; ok {
+		result.Metadata[metadataCode] = namespaced
+	}
+}
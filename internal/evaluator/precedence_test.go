@@ -0,0 +1,330 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unit
+
+package evaluator
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path"
+	"sort"
+	"testing"
+	"testing/fstest"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/enterprise-contract/ec-cli/internal/opa"
+	"github.com/enterprise-contract/ec-cli/internal/opa/rule"
+	"github.com/enterprise-contract/ec-cli/internal/policy"
+	"github.com/enterprise-contract/ec-cli/internal/policy/source"
+)
+
+func precedenceRules(pkg, msg string) fstest.MapFS {
+	return fstest.MapFS{
+		"rules.rego": &fstest.MapFile{Data: []byte(`package ` + pkg + `
+
+import future.keywords.contains
+import future.keywords.if
+
+# METADATA
+# title: ` + msg + `
+# custom:
+#   short_name: rule
+deny contains result if {
+	result := {"code": "foo.rule", "msg": "` + msg + `"}
+}
+
+# METADATA
+# custom:
+#   short_name: other_` + msg + `
+deny contains result if {
+	false
+	result := "never"
+}
+`)},
+	}
+}
+
+func TestRulePrecedence(t *testing.T) {
+	upstream, err := rulesArchive(t, precedenceRules("policy.release.foo", "upstream"))
+	require.NoError(t, err)
+	overlay, err := rulesArchive(t, precedenceRules("policy.release.foo", "overlay"))
+	require.NoError(t, err)
+	pipeline, err := rulesArchive(t, precedenceRules("policy.pipeline.foo", "pipeline"))
+	require.NoError(t, err)
+
+	cases := []struct {
+		name     string
+		policy   []string
+		options  map[string]any
+		failures map[string]string
+		err      string
+	}{
+		{
+			name:   "error by default",
+			policy: []string{upstream, overlay},
+			err:    "found a second rule with the same code: `foo.rule`",
+		},
+		{
+			name:     "first wins",
+			policy:   []string{upstream, overlay},
+			options:  map[string]any{"rulePrecedence": "first"},
+			failures: map[string]string{"foo.rule": "upstream"},
+		},
+		{
+			name:     "last wins",
+			policy:   []string{upstream, overlay},
+			options:  map[string]any{"rulePrecedence": "last"},
+			failures: map[string]string{"foo.rule": "overlay"},
+		},
+		{
+			name:     "namespaced in different packages",
+			policy:   []string{upstream, pipeline},
+			options:  map[string]any{"ruleNamespaces": map[string]string{pipeline: "org"}},
+			failures: map[string]string{"foo.rule": "upstream", "org/foo.rule": "pipeline"},
+		},
+		{
+			name:    "namespaced in the same package",
+			policy:  []string{upstream, overlay},
+			options: map[string]any{"ruleNamespaces": map[string]string{overlay: "org"}},
+			err:     "found a second rule with the same code: `org/foo.rule`",
+		},
+		{
+			name:     "namespaced in the same package with precedence",
+			policy:   []string{upstream, overlay},
+			options:  map[string]any{"ruleNamespaces": map[string]string{overlay: "org"}, "rulePrecedence": "last"},
+			failures: map[string]string{"org/foo.rule": "overlay"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			dir := t.TempDir()
+			require.NoError(t, os.MkdirAll(path.Join(dir, "inputs"), 0755))
+			require.NoError(t, os.WriteFile(path.Join(dir, "inputs", "data.json"), []byte("{}"), 0600))
+
+			group := map[string]any{"policy": c.policy}
+			for k, v := range c.options {
+				group[k] = v
+			}
+			spec, err := json.Marshal(map[string]any{"sources": []any{group}})
+			require.NoError(t, err)
+
+			ctx := withCapabilities(context.Background(), testCapabilities)
+			p, err := policy.NewInertPolicy(ctx, string(spec))
+			require.NoError(t, err)
+
+			var sources []source.PolicySource
+			for _, url := range c.policy {
+				sources = append(sources, &source.PolicyUrl{Url: url, Kind: source.PolicyKind})
+			}
+
+			evaluator, err := NewConftestEvaluator(ctx, sources, p, p.Spec().Sources[0])
+			require.NoError(t, err)
+
+			results, _, err := evaluator.Evaluate(ctx, []string{path.Join(dir, "inputs")})
+			if c.err != "" {
+				assert.ErrorContains(t, err, c.err)
+				return
+			}
+			require.NoError(t, err)
+
+			failures := map[string]string{}
+			var sourcesOf []string
+			for _, o := range results {
+				for _, f := range o.Failures {
+					code := f.Metadata[metadataCode].(string)
+					failures[code] = f.Message
+					assert.Equal(t, f.Message, f.Metadata[metadataTitle])
+					sourcesOf = append(sourcesOf, f.Metadata[metadataSource].(string))
+				}
+			}
+			assert.Equal(t, c.failures, failures)
+
+			var expectedSources []string
+			for _, msg := range c.failures {
+				expectedSources = append(expectedSources, map[string]string{
+					"upstream": upstream,
+					"overlay":  overlay,
+					"pipeline": pipeline,
+				}[msg])
+			}
+			sort.Strings(sourcesOf)
+			sort.Strings(expectedSources)
+			assert.Equal(t, expectedSources, sourcesOf)
+		})
+	}
+}
+
+func TestPreferDuplicate(t *testing.T) {
+	existing := rule.Info{Code: "foo.rule", Source: "upstream"}
+	duplicate := rule.Info{Code: "foo.rule", Source: "overlay"}
+
+	replace, err := PreferDuplicate(policy.SourceOptions{RulePrecedence: policy.RulePrecedenceFirst}, existing, duplicate)
+	assert.NoError(t, err)
+	assert.False(t, replace)
+
+	replace, err = PreferDuplicate(policy.SourceOptions{RulePrecedence: policy.RulePrecedenceLast}, existing, duplicate)
+	assert.NoError(t, err)
+	assert.True(t, replace)
+
+	_, err = PreferDuplicate(policy.SourceOptions{}, existing, duplicate)
+	assert.EqualError(t, err, "found a second rule with the same code: `foo.rule`, defined in \"upstream\" and \"overlay\"")
+
+	_, err = PreferDuplicate(policy.SourceOptions{RulePrecedence: "random"}, existing, duplicate)
+	assert.EqualError(t, err, `unknown rule precedence "random"`)
+}
+
+func TestSourceGroupConventions(t *testing.T) {
+	rules, err := rulesArchive(t, fstest.MapFS{
+		"rules.rego": &fstest.MapFile{Data: []byte(`package org.policy.foo
+
+import future.keywords.contains
+import future.keywords.if
+
+# METADATA
+# title: Rule
+# custom:
+#   short_name: rule
+deny contains result if {
+	result := {"code": "foo.rule", "msg": "failure"}
+}
+`)},
+	})
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(path.Join(dir, "inputs"), 0755))
+	require.NoError(t, os.WriteFile(path.Join(dir, "inputs", "data.json"), []byte("{}"), 0600))
+
+	spec, err := json.Marshal(map[string]any{"sources": []any{map[string]any{
+		"policy":           []string{rules},
+		"codePrefixes":     []string{"org.policy"},
+		"documentationUrl": "https://docs.example.com/{{ .CodePackage }}.html#{{ .ShortName }}",
+	}}})
+	require.NoError(t, err)
+
+	ctx := withCapabilities(context.Background(), testCapabilities)
+	p, err := policy.NewInertPolicy(ctx, string(spec))
+	require.NoError(t, err)
+
+	evaluator, err := NewConftestEvaluator(ctx, []source.PolicySource{&source.PolicyUrl{Url: rules, Kind: source.PolicyKind}}, p, p.Spec().Sources[0])
+	require.NoError(t, err)
+
+	results, _, err := evaluator.Evaluate(ctx, []string{path.Join(dir, "inputs")})
+	require.NoError(t, err)
+
+	require.Len(t, results, 1)
+	require.Len(t, results[0].Failures, 1)
+	failure := results[0].Failures[0]
+	assert.Equal(t, "Rule", failure.Metadata[metadataTitle])
+	assert.Equal(t, "https://docs.example.com/foo.html#rule", failure.Metadata[metadataDocsUrl])
+}
+
+func TestRulePrecedenceLocalSources(t *testing.T) {
+	contents := map[string][]byte{}
+	localSource := func(pkg, msg string) string {
+		dir := t.TempDir()
+		file := path.Join(dir, "rules.rego")
+		contents[file] = precedenceRules(pkg, msg)["rules.rego"].Data
+		require.NoError(t, os.WriteFile(file, contents[file], 0600))
+		return dir
+	}
+	upstream := localSource("policy.release.foo", "upstream")
+	overlay := localSource("policy.release.foo", "overlay")
+
+	for _, precedence := range []string{policy.RulePrecedenceFirst, policy.RulePrecedenceLast} {
+		t.Run(precedence, func(t *testing.T) {
+			dir := t.TempDir()
+			require.NoError(t, os.MkdirAll(path.Join(dir, "inputs"), 0755))
+			require.NoError(t, os.WriteFile(path.Join(dir, "inputs", "data.json"), []byte("{}"), 0600))
+
+			spec, err := json.Marshal(map[string]any{"sources": []any{map[string]any{
+				"policy":         []string{upstream, overlay},
+				"rulePrecedence": precedence,
+			}}})
+			require.NoError(t, err)
+
+			ctx := withCapabilities(context.Background(), testCapabilities)
+			p, err := policy.NewInertPolicy(ctx, string(spec))
+			require.NoError(t, err)
+
+			sources := []source.PolicySource{
+				&source.PolicyUrl{Url: upstream, Kind: source.PolicyKind},
+				&source.PolicyUrl{Url: overlay, Kind: source.PolicyKind},
+			}
+			evaluator, err := NewConftestEvaluator(ctx, sources, p, p.Spec().Sources[0])
+			require.NoError(t, err)
+
+			results, _, err := evaluator.Evaluate(ctx, []string{path.Join(dir, "inputs")})
+			require.NoError(t, err)
+
+			var messages []string
+			for _, o := range results {
+				for _, f := range o.Failures {
+					messages = append(messages, f.Message)
+				}
+			}
+			expected := map[string]string{policy.RulePrecedenceFirst: "upstream", policy.RulePrecedenceLast: "overlay"}[precedence]
+			assert.Equal(t, []string{expected}, messages)
+
+			// the shadowed rules are not removed from the policy sources themselves
+			for file, content := range contents {
+				actual, err := os.ReadFile(file)
+				require.NoError(t, err)
+				assert.Equal(t, string(content), string(actual), file)
+			}
+		})
+	}
+}
+
+func TestRemoveShadowedRules(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/policy/rules.rego", precedenceRules("policy.release.foo", "upstream")["rules.rego"].Data, 0644))
+
+	annotations, err := opa.InspectDir(fs, "/policy")
+	require.NoError(t, err)
+
+	var shadowed []shadowedRule
+	for _, a := range annotations {
+		if rule.RuleInfo(a).ShortName == "rule" {
+			shadowed = append(shadowed, shadowedRule{dir: "/policy", annotation: a})
+		}
+	}
+	require.Len(t, shadowed, 1)
+
+	require.NoError(t, removeShadowedRules(fs, shadowed))
+
+	remaining, err := opa.InspectDir(fs, "/policy")
+	require.NoError(t, err)
+
+	var names []string
+	for _, a := range remaining {
+		if a.Annotations != nil && a.GetRule() != nil {
+			names = append(names, rule.RuleInfo(a).ShortName)
+		}
+	}
+	assert.Equal(t, []string{"other_upstream"}, names)
+
+	content, err := afero.ReadFile(fs, "/policy/rules.rego")
+	require.NoError(t, err)
+	assert.NotContains(t, string(content), "title: upstream")
+}
@@ -22,10 +22,13 @@ package evaluator
 
 import (
 	"bytes"
+	"context"
 	"crypto/sha256"
 	"fmt"
 	"io"
+	"strings"
 
+	"github.com/blang/semver"
 	"github.com/google/go-containerregistry/pkg/authn"
 	"github.com/google/go-containerregistry/pkg/name"
 	"github.com/google/go-containerregistry/pkg/v1/remote"
@@ -39,8 +42,17 @@ import (
 )
 
 const ociBlobName = "ec.oci.blob"
+const ociImageManifestName = "ec.oci.image_manifest"
+const ociImageConfigName = "ec.oci.image_config"
+const ociImageIndexName = "ec.oci.image_index"
 const purlIsValidName = "ec.purl.is_valid"
 const purlParseName = "ec.purl.parse"
+const spdxParseLicenseExpressionName = "ec.spdx.parse_license_expression"
+const spdxLicenseSatisfiesName = "ec.spdx.license_satisfies"
+const rpmVercmpName = "ec.rpm.vercmp"
+const rpmParseEVRName = "ec.rpm.parse_evr"
+const semverCompareName = "ec.semver.compare"
+const semverSatisfiesName = "ec.semver.satisfies"
 
 func registerOCIBlob() {
 	decl := rego.Function{
@@ -58,7 +70,40 @@ func registerOCIBlob() {
 		Nondeterministic: true,
 	}
 
-	rego.RegisterBuiltin1(&decl, ociBlob)
+	registerBuiltin1(&decl, ociBlob)
+}
+
+func registerOCIImageManifest() {
+	registerOCIDocument(ociImageManifestName, "the image manifest", ociImageManifest)
+}
+
+func registerOCIImageConfig() {
+	registerOCIDocument(ociImageConfigName, "the image config", ociImageConfig)
+}
+
+func registerOCIImageIndex() {
+	registerOCIDocument(ociImageIndexName, "the image index", ociImageIndex)
+}
+
+// registerOCIDocument registers a builtin returning a JSON document, e.g. the manifest, read
+// from the OCI registry for the given digest-pinned reference.
+func registerOCIDocument(name, description string, fn rego.Builtin1) {
+	decl := rego.Function{
+		Name: name,
+		Decl: types.NewFunction(
+			types.Args(
+				types.Named("ref", types.S).Description("OCI image reference, including the digest"),
+			),
+			types.Named("object", types.NewObject(nil, types.NewDynamicProperty(types.S, types.A))).Description(description),
+		),
+		// As per the documentation, enable memoization to ensure function evaluation is
+		// deterministic. But also mark it as non-deterministic because it does rely on external
+		// entities, i.e. OCI registry. https://www.openpolicyagent.org/docs/latest/extensions/
+		Memoize:          true,
+		Nondeterministic: true,
+	}
+
+	registerBuiltin1(&decl, fn)
 }
 
 func registerPURLIsValid() {
@@ -76,7 +121,7 @@ func registerPURLIsValid() {
 		Nondeterministic: false,
 	}
 
-	rego.RegisterBuiltin1(&decl, purlIsValid)
+	registerBuiltin1(&decl, purlIsValid)
 }
 
 func registerPURLParse() {
@@ -114,11 +159,132 @@ func registerPURLParse() {
 		Nondeterministic: false,
 	}
 
-	rego.RegisterBuiltin1(&decl, purlParse)
+	registerBuiltin1(&decl, purlParse)
+}
+
+func registerSPDXParseLicenseExpression() {
+	decl := rego.Function{
+		Name: spdxParseLicenseExpressionName,
+		Decl: types.NewFunction(
+			types.Args(
+				types.Named("expression", types.S).Description("SPDX license expression, e.g. (MIT OR Apache-2.0) AND BSD-3-Clause"),
+			),
+			// The expression is recursive, the nested expressions are not typed any further.
+			types.Named("object", types.NewObject(nil, types.NewDynamicProperty(types.S, types.A))).Description(
+				"the parsed expression, either a license, with license, or_later and exception, or an operator with operator and expressions"),
+		),
+		// As per the documentation, enable memoization to ensure function evaluation is
+		// deterministic.
+		Memoize:          true,
+		Nondeterministic: false,
+	}
+
+	registerBuiltin1(&decl, spdxParseLicenseExpression)
+}
+
+func registerSPDXLicenseSatisfies() {
+	decl := rego.Function{
+		Name: spdxLicenseSatisfiesName,
+		Decl: types.NewFunction(
+			types.Args(
+				types.Named("expression", types.S).Description("SPDX license expression, e.g. (MIT OR Apache-2.0) AND BSD-3-Clause"),
+				types.Named("allowed", types.NewArray(nil, types.S)).Description("the allowed license identifiers"),
+			),
+			types.Named("result", types.B).Description("true if the expression can be complied with using only the allowed licenses"),
+		),
+		// As per the documentation, enable memoization to ensure function evaluation is
+		// deterministic.
+		Memoize:          true,
+		Nondeterministic: false,
+	}
+
+	registerBuiltin2(&decl, spdxLicenseSatisfies)
+}
+
+// registerVersionCompare registers a builtin comparing two versions, returning -1, 0 or 1.
+func registerVersionCompare(name, description string, fn rego.Builtin2) {
+	decl := rego.Function{
+		Name: name,
+		Decl: types.NewFunction(
+			types.Args(
+				types.Named("a", types.S).Description(description),
+				types.Named("b", types.S).Description(description),
+			),
+			types.Named("result", types.N).Description("-1 if a is older than b, 0 if they are the same, 1 if a is newer than b"),
+		),
+		// As per the documentation, enable memoization to ensure function evaluation is
+		// deterministic.
+		Memoize:          true,
+		Nondeterministic: false,
+	}
+
+	registerBuiltin2(&decl, fn)
+}
+
+func registerRPMVercmp() {
+	registerVersionCompare(rpmVercmpName, "RPM epoch, version and release, e.g. 1:2.3-4.el9", rpmVercmp)
+}
+
+func registerRPMParseEVR() {
+	decl := rego.Function{
+		Name: rpmParseEVRName,
+		Decl: types.NewFunction(
+			types.Args(
+				types.Named("evr", types.S).Description("RPM epoch, version and release, e.g. 1:2.3-4.el9"),
+			),
+			types.Named("object", types.NewObject(
+				[]*types.StaticProperty{
+					{Key: "epoch", Value: types.N},
+					{Key: "version", Value: types.S},
+					{Key: "release", Value: types.S},
+				},
+				nil,
+			)).Description("the parsed epoch, version and release"),
+		),
+		// As per the documentation, enable memoization to ensure function evaluation is
+		// deterministic.
+		Memoize:          true,
+		Nondeterministic: false,
+	}
+
+	registerBuiltin1(&decl, rpmParseEVR)
+}
+
+func registerSemverCompare() {
+	registerVersionCompare(semverCompareName, "semantic version, e.g. 1.2.3", semverCompare)
+}
+
+func registerSemverSatisfies() {
+	decl := rego.Function{
+		Name: semverSatisfiesName,
+		Decl: types.NewFunction(
+			types.Args(
+				types.Named("version", types.S).Description("semantic version, e.g. 1.2.3"),
+				types.Named("constraint", types.S).Description("version range, e.g. >=1.2.0 <2.0.0 || >=3.0.0"),
+			),
+			types.Named("result", types.B).Description("true if the version is within the range"),
+		),
+		// As per the documentation, enable memoization to ensure function evaluation is
+		// deterministic.
+		Memoize:          true,
+		Nondeterministic: false,
+	}
+
+	registerBuiltin2(&decl, semverSatisfies)
 }
 
 const maxBytes = 10 * 1024 * 1024 // 10 MB
 
+// blobSizeLimit returns the maximum size of the blobs read by ec.oci.blob, as configured for the
+// source group being evaluated, or maxBytes.
+func blobSizeLimit(ctx context.Context) int64 {
+	if limit, ok := ctx.Value(blobSizeLimitKey).(int64); ok && limit > 0 {
+		return limit
+	}
+
+	return maxBytes
+}
+
 func ociBlob(bctx rego.BuiltinContext, a *ast.Term) (*ast.Term, error) {
 	uri, ok := a.Value.(ast.String)
 	if !ok {
@@ -131,13 +297,7 @@ func ociBlob(bctx rego.BuiltinContext, a *ast.Term) (*ast.Term, error) {
 		return nil, nil
 	}
 
-	opts := []remote.Option{
-		remote.WithTransport(remote.DefaultTransport),
-		remote.WithContext(bctx.Context),
-		remote.WithAuthFromKeychain(authn.DefaultKeychain),
-	}
-
-	rawLayer, err := oci.NewClient(bctx.Context).Layer(ref, opts...)
+	rawLayer, err := oci.NewClient(bctx.Context).Layer(ref, remoteOptions(bctx)...)
 	if err != nil {
 		log.Errorf("%s fetch layer: %s", ociBlobName, err)
 		return nil, nil
@@ -156,7 +316,8 @@ func ociBlob(bctx rego.BuiltinContext, a *ast.Term) (*ast.Term, error) {
 	hasher := sha256.New()
 	// Setup some safeguards. First, use LimitReader to avoid an unbounded amount of data from being
 	// read. Second, use TeeReader so we can compute the digest of the content read.
-	reader := io.TeeReader(io.LimitReader(layer, maxBytes), hasher)
+	limit := blobSizeLimit(bctx.Context)
+	reader := io.TeeReader(io.LimitReader(layer, limit), hasher)
 
 	var blob bytes.Buffer
 	if _, err := io.Copy(&blob, reader); err != nil {
@@ -170,13 +331,141 @@ func ociBlob(bctx rego.BuiltinContext, a *ast.Term) (*ast.Term, error) {
 	if sum != ref.DigestStr() {
 		log.Errorf(
 			"%s computed digest, %q, not as expected, %q. Content may have been truncated at %d bytes",
-			ociBlobName, sum, ref.DigestStr(), maxBytes)
+			ociBlobName, sum, ref.DigestStr(), limit)
 		return nil, nil
 	}
 
 	return ast.StringTerm(blob.String()), nil
 }
 
+func remoteOptions(bctx rego.BuiltinContext) []remote.Option {
+	return []remote.Option{
+		remote.WithTransport(remote.DefaultTransport),
+		remote.WithContext(bctx.Context),
+		remote.WithAuthFromKeychain(authn.DefaultKeychain),
+	}
+}
+
+func ociImageManifest(bctx rego.BuiltinContext, a *ast.Term) (*ast.Term, error) {
+	ref, ok := digestReference(ociImageManifestName, a)
+	if !ok {
+		return nil, nil
+	}
+
+	image, err := oci.NewClient(bctx.Context).Image(ref, remoteOptions(bctx)...)
+	if err != nil {
+		log.Errorf("%s fetch image: %s", ociImageManifestName, err)
+		return nil, nil
+	}
+
+	raw, err := image.RawManifest()
+	if err != nil {
+		log.Errorf("%s fetch manifest: %s", ociImageManifestName, err)
+		return nil, nil
+	}
+
+	return documentTerm(ociImageManifestName, raw, ref.DigestStr())
+}
+
+func ociImageConfig(bctx rego.BuiltinContext, a *ast.Term) (*ast.Term, error) {
+	ref, ok := digestReference(ociImageConfigName, a)
+	if !ok {
+		return nil, nil
+	}
+
+	image, err := oci.NewClient(bctx.Context).Image(ref, remoteOptions(bctx)...)
+	if err != nil {
+		log.Errorf("%s fetch image: %s", ociImageConfigName, err)
+		return nil, nil
+	}
+
+	// The config is pinned by the digest in the manifest, which in turn needs to be the one
+	// referenced.
+	manifest, err := image.RawManifest()
+	if err != nil {
+		log.Errorf("%s fetch manifest: %s", ociImageConfigName, err)
+		return nil, nil
+	}
+	if sum := fmt.Sprintf("sha256:%x", sha256.Sum256(manifest)); sum != ref.DigestStr() {
+		log.Errorf("%s manifest digest, %q, not as expected, %q", ociImageConfigName, sum, ref.DigestStr())
+		return nil, nil
+	}
+
+	configName, err := image.ConfigName()
+	if err != nil {
+		log.Errorf("%s config digest: %s", ociImageConfigName, err)
+		return nil, nil
+	}
+
+	raw, err := image.RawConfigFile()
+	if err != nil {
+		log.Errorf("%s fetch config: %s", ociImageConfigName, err)
+		return nil, nil
+	}
+
+	return documentTerm(ociImageConfigName, raw, configName.String())
+}
+
+func ociImageIndex(bctx rego.BuiltinContext, a *ast.Term) (*ast.Term, error) {
+	ref, ok := digestReference(ociImageIndexName, a)
+	if !ok {
+		return nil, nil
+	}
+
+	index, err := oci.NewClient(bctx.Context).Index(ref, remoteOptions(bctx)...)
+	if err != nil {
+		log.Errorf("%s fetch index: %s", ociImageIndexName, err)
+		return nil, nil
+	}
+
+	raw, err := index.RawManifest()
+	if err != nil {
+		log.Errorf("%s fetch manifest: %s", ociImageIndexName, err)
+		return nil, nil
+	}
+
+	return documentTerm(ociImageIndexName, raw, ref.DigestStr())
+}
+
+// digestReference parses the reference given to the builtin. Only references pinned to a
+// digest are accepted, so that the content returned is deterministic.
+func digestReference(builtin string, a *ast.Term) (name.Digest, bool) {
+	uri, ok := a.Value.(ast.String)
+	if !ok {
+		return name.Digest{}, false
+	}
+
+	ref, err := name.NewDigest(string(uri))
+	if err != nil {
+		log.Errorf("%s new digest: %s", builtin, err)
+		return name.Digest{}, false
+	}
+
+	return ref, true
+}
+
+// documentTerm converts the JSON document into a term, after checking that it is within the
+// size limit, and that its digest is the expected one.
+func documentTerm(builtin string, raw []byte, digest string) (*ast.Term, error) {
+	if len(raw) > maxBytes {
+		log.Errorf("%s document size, %d bytes, exceeds the limit of %d bytes", builtin, len(raw), maxBytes)
+		return nil, nil
+	}
+
+	if sum := fmt.Sprintf("sha256:%x", sha256.Sum256(raw)); sum != digest {
+		log.Errorf("%s computed digest, %q, not as expected, %q", builtin, sum, digest)
+		return nil, nil
+	}
+
+	value, err := ast.ValueFromReader(bytes.NewReader(raw))
+	if err != nil {
+		log.Errorf("%s parse document: %s", builtin, err)
+		return nil, nil
+	}
+
+	return ast.NewTerm(value), nil
+}
+
 func purlIsValid(bctx rego.BuiltinContext, a *ast.Term) (*ast.Term, error) {
 	uri, ok := a.Value.(ast.String)
 	if !ok {
@@ -219,8 +508,157 @@ func purlParse(bctx rego.BuiltinContext, a *ast.Term) (*ast.Term, error) {
 	), nil
 }
 
+func spdxParseLicenseExpression(bctx rego.BuiltinContext, a *ast.Term) (*ast.Term, error) {
+	e, ok := licenseExpressionArg(a)
+	if !ok {
+		return nil, nil
+	}
+
+	return e.term(), nil
+}
+
+func spdxLicenseSatisfies(bctx rego.BuiltinContext, a, b *ast.Term) (*ast.Term, error) {
+	e, ok := licenseExpressionArg(a)
+	if !ok {
+		return nil, nil
+	}
+
+	licenses, ok := b.Value.(*ast.Array)
+	if !ok {
+		return nil, nil
+	}
+
+	allowed := make(map[string]bool, licenses.Len())
+	for i := 0; i < licenses.Len(); i++ {
+		license, ok := licenses.Elem(i).Value.(ast.String)
+		if !ok {
+			return nil, nil
+		}
+		allowed[strings.ToLower(strings.Join(strings.Fields(string(license)), " "))] = true
+	}
+
+	return ast.BooleanTerm(e.satisfiedBy(allowed)), nil
+}
+
+func licenseExpressionArg(a *ast.Term) (*licenseExpression, bool) {
+	s, ok := a.Value.(ast.String)
+	if !ok {
+		return nil, false
+	}
+
+	e, err := parseLicenseExpression(string(s))
+	if err != nil {
+		log.Errorf("Parsing SPDX license expression %s failed: %s", s, err)
+		return nil, false
+	}
+
+	return e, true
+}
+
+func rpmVercmp(bctx rego.BuiltinContext, a, b *ast.Term) (*ast.Term, error) {
+	evrA, ok := evrArg(a)
+	if !ok {
+		return nil, nil
+	}
+	evrB, ok := evrArg(b)
+	if !ok {
+		return nil, nil
+	}
+
+	return ast.IntNumberTerm(compareEVR(evrA, evrB)), nil
+}
+
+func rpmParseEVR(bctx rego.BuiltinContext, a *ast.Term) (*ast.Term, error) {
+	e, ok := evrArg(a)
+	if !ok {
+		return nil, nil
+	}
+
+	return ast.ObjectTerm(
+		ast.Item(ast.StringTerm("epoch"), ast.IntNumberTerm(e.epoch)),
+		ast.Item(ast.StringTerm("version"), ast.StringTerm(e.version)),
+		ast.Item(ast.StringTerm("release"), ast.StringTerm(e.release)),
+	), nil
+}
+
+func evrArg(a *ast.Term) (evr, bool) {
+	s, ok := a.Value.(ast.String)
+	if !ok {
+		return evr{}, false
+	}
+
+	e, err := parseEVR(string(s))
+	if err != nil {
+		log.Errorf("Parsing RPM EVR %s failed: %s", s, err)
+		return evr{}, false
+	}
+
+	return e, true
+}
+
+func semverCompare(bctx rego.BuiltinContext, a, b *ast.Term) (*ast.Term, error) {
+	versionA, ok := semverArg(a)
+	if !ok {
+		return nil, nil
+	}
+	versionB, ok := semverArg(b)
+	if !ok {
+		return nil, nil
+	}
+
+	return ast.IntNumberTerm(versionA.Compare(versionB)), nil
+}
+
+func semverSatisfies(bctx rego.BuiltinContext, a, b *ast.Term) (*ast.Term, error) {
+	version, ok := semverArg(a)
+	if !ok {
+		return nil, nil
+	}
+
+	constraint, ok := b.Value.(ast.String)
+	if !ok {
+		return nil, nil
+	}
+
+	r, err := semver.ParseRange(string(constraint))
+	if err != nil {
+		log.Errorf("Parsing semantic version range %s failed: %s", constraint, err)
+		return nil, nil
+	}
+
+	return ast.BooleanTerm(r(version)), nil
+}
+
+// semverArg parses the semantic version, tolerating a "v" prefix and missing minor or patch
+// versions, e.g. "v1.2".
+func semverArg(a *ast.Term) (semver.Version, bool) {
+	s, ok := a.Value.(ast.String)
+	if !ok {
+		return semver.Version{}, false
+	}
+
+	v, err := semver.ParseTolerant(string(s))
+	if err != nil {
+		log.Errorf("Parsing semantic version %s failed: %s", s, err)
+		return semver.Version{}, false
+	}
+
+	return v, true
+}
+
 func init() {
 	registerOCIBlob()
+	registerOCIImageManifest()
+	registerOCIImageConfig()
+	registerOCIImageIndex()
 	registerPURLIsValid()
 	registerPURLParse()
+	registerSPDXParseLicenseExpression()
+	registerSPDXLicenseSatisfies()
+	registerRPMVercmp()
+	registerRPMParseEVR()
+	registerSemverCompare()
+	registerSemverSatisfies()
+	registerSigstoreVerifyImage()
+	registerSigstoreVerifyAttestation()
 }
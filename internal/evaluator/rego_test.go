@@ -23,6 +23,9 @@ import (
 	"errors"
 	"testing"
 
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
 	"github.com/google/go-containerregistry/pkg/v1/static"
 	"github.com/google/go-containerregistry/pkg/v1/types"
 	"github.com/open-policy-agent/opa/ast"
@@ -41,6 +44,7 @@ func TestOCIBlob(t *testing.T) {
 		uri       *ast.Term
 		err       bool
 		remoteErr error
+		sizeLimit int64
 	}{
 		{
 			name: "success",
@@ -72,6 +76,19 @@ func TestOCIBlob(t *testing.T) {
 			remoteErr: errors.New("boom!"),
 			err:       true,
 		},
+		{
+			name:      "within size limit",
+			data:      `{"spam": "maps"}`,
+			uri:       ast.StringTerm("registry.local/spam@sha256:4bbf56a3a9231f752d3b9c174637975f0f83ed2b15e65799837c571e4ef3374b"),
+			sizeLimit: 16,
+		},
+		{
+			name:      "exceeds size limit",
+			data:      `{"spam": "maps"}`,
+			uri:       ast.StringTerm("registry.local/spam@sha256:4bbf56a3a9231f752d3b9c174637975f0f83ed2b15e65799837c571e4ef3374b"),
+			sizeLimit: 15,
+			err:       true,
+		},
 		{
 			name: "unexpected digest",
 			data: `{"spam": "mapssssss"}`,
@@ -90,6 +107,9 @@ func TestOCIBlob(t *testing.T) {
 				client.On("Layer", mock.Anything, mock.Anything).Return(layer, nil)
 			}
 			ctx := oci.WithClient(context.Background(), &client)
+			if c.sizeLimit > 0 {
+				ctx = context.WithValue(ctx, blobSizeLimitKey, c.sizeLimit)
+			}
 			bctx := rego.BuiltinContext{Context: ctx}
 
 			blob, err := ociBlob(bctx, c.uri)
@@ -106,6 +126,194 @@ func TestOCIBlob(t *testing.T) {
 	}
 }
 
+func TestOCIImageManifest(t *testing.T) {
+	image, err := random.Image(1024, 2)
+	require.NoError(t, err)
+	digest, err := image.Digest()
+	require.NoError(t, err)
+	manifest, err := image.Manifest()
+	require.NoError(t, err)
+
+	cases := []struct {
+		name      string
+		uri       *ast.Term
+		remoteErr error
+		err       bool
+	}{
+		{
+			name: "success",
+			uri:  ast.StringTerm("registry.local/spam@" + digest.String()),
+		},
+		{
+			name: "unexpected uri type",
+			uri:  ast.IntNumberTerm(42),
+			err:  true,
+		},
+		{
+			name: "missing digest",
+			uri:  ast.StringTerm("registry.local/spam:latest"),
+			err:  true,
+		},
+		{
+			name:      "remote error",
+			uri:       ast.StringTerm("registry.local/spam@" + digest.String()),
+			remoteErr: errors.New("boom!"),
+			err:       true,
+		},
+		{
+			name: "unexpected digest",
+			uri:  ast.StringTerm("registry.local/spam@sha256:4bbf56a3a9231f752d3b9c174637975f0f83ed2b15e65799837c571e4ef3374b"),
+			err:  true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			client := fake.FakeClient{}
+			if c.remoteErr != nil {
+				client.On("Image", mock.Anything, mock.Anything).Return(nil, c.remoteErr)
+			} else {
+				client.On("Image", mock.Anything, mock.Anything).Return(image, nil)
+			}
+			ctx := oci.WithClient(context.Background(), &client)
+			bctx := rego.BuiltinContext{Context: ctx}
+
+			result, err := ociImageManifest(bctx, c.uri)
+			require.NoError(t, err)
+			if c.err {
+				require.Nil(t, result)
+				return
+			}
+
+			require.NotNil(t, result)
+			layers := result.Value.(ast.Object).Get(ast.StringTerm("layers")).Value.(*ast.Array)
+			require.Equal(t, 2, layers.Len())
+			configDigest := result.Value.(ast.Object).Get(ast.StringTerm("config")).Value.(ast.Object).Get(ast.StringTerm("digest"))
+			require.Equal(t, ast.StringTerm(manifest.Config.Digest.String()), configDigest)
+		})
+	}
+}
+
+func TestOCIImageConfig(t *testing.T) {
+	image, err := random.Image(1024, 1)
+	require.NoError(t, err)
+	image, err = mutate.Config(image, v1.Config{Labels: map[string]string{"spam": "maps"}})
+	require.NoError(t, err)
+	digest, err := image.Digest()
+	require.NoError(t, err)
+
+	cases := []struct {
+		name      string
+		uri       *ast.Term
+		remoteErr error
+		err       bool
+	}{
+		{
+			name: "success",
+			uri:  ast.StringTerm("registry.local/spam@" + digest.String()),
+		},
+		{
+			name: "missing digest",
+			uri:  ast.StringTerm("registry.local/spam:latest"),
+			err:  true,
+		},
+		{
+			name:      "remote error",
+			uri:       ast.StringTerm("registry.local/spam@" + digest.String()),
+			remoteErr: errors.New("boom!"),
+			err:       true,
+		},
+		{
+			name: "unexpected digest",
+			uri:  ast.StringTerm("registry.local/spam@sha256:4bbf56a3a9231f752d3b9c174637975f0f83ed2b15e65799837c571e4ef3374b"),
+			err:  true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			client := fake.FakeClient{}
+			if c.remoteErr != nil {
+				client.On("Image", mock.Anything, mock.Anything).Return(nil, c.remoteErr)
+			} else {
+				client.On("Image", mock.Anything, mock.Anything).Return(image, nil)
+			}
+			ctx := oci.WithClient(context.Background(), &client)
+			bctx := rego.BuiltinContext{Context: ctx}
+
+			result, err := ociImageConfig(bctx, c.uri)
+			require.NoError(t, err)
+			if c.err {
+				require.Nil(t, result)
+				return
+			}
+
+			require.NotNil(t, result)
+			labels := result.Value.(ast.Object).Get(ast.StringTerm("config")).Value.(ast.Object).Get(ast.StringTerm("Labels"))
+			require.Equal(t, ast.ObjectTerm(ast.Item(ast.StringTerm("spam"), ast.StringTerm("maps"))), labels)
+		})
+	}
+}
+
+func TestOCIImageIndex(t *testing.T) {
+	index, err := random.Index(1024, 1, 3)
+	require.NoError(t, err)
+	digest, err := index.Digest()
+	require.NoError(t, err)
+
+	cases := []struct {
+		name      string
+		uri       *ast.Term
+		remoteErr error
+		err       bool
+	}{
+		{
+			name: "success",
+			uri:  ast.StringTerm("registry.local/spam@" + digest.String()),
+		},
+		{
+			name: "missing digest",
+			uri:  ast.StringTerm("registry.local/spam:latest"),
+			err:  true,
+		},
+		{
+			name:      "remote error",
+			uri:       ast.StringTerm("registry.local/spam@" + digest.String()),
+			remoteErr: errors.New("boom!"),
+			err:       true,
+		},
+		{
+			name: "unexpected digest",
+			uri:  ast.StringTerm("registry.local/spam@sha256:4bbf56a3a9231f752d3b9c174637975f0f83ed2b15e65799837c571e4ef3374b"),
+			err:  true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			client := fake.FakeClient{}
+			if c.remoteErr != nil {
+				client.On("Index", mock.Anything, mock.Anything).Return(nil, c.remoteErr)
+			} else {
+				client.On("Index", mock.Anything, mock.Anything).Return(index, nil)
+			}
+			ctx := oci.WithClient(context.Background(), &client)
+			bctx := rego.BuiltinContext{Context: ctx}
+
+			result, err := ociImageIndex(bctx, c.uri)
+			require.NoError(t, err)
+			if c.err {
+				require.Nil(t, result)
+				return
+			}
+
+			require.NotNil(t, result)
+			manifests := result.Value.(ast.Object).Get(ast.StringTerm("manifests")).Value.(*ast.Array)
+			require.Equal(t, 3, manifests.Len())
+		})
+	}
+}
+
 func TestPURLIsValid(t *testing.T) {
 	cases := []struct {
 		name     string
@@ -182,11 +390,238 @@ func TestPURLParse(t *testing.T) {
 	}
 }
 
+func TestSPDXBuiltins(t *testing.T) {
+	bctx := rego.BuiltinContext{Context: context.Background()}
+
+	parsed, err := spdxParseLicenseExpression(bctx, ast.StringTerm("(MIT OR Apache-2.0+) AND GPL-2.0-only WITH Classpath-exception-2.0"))
+	require.NoError(t, err)
+	require.Equal(t, ast.MustParseTerm(`{
+		"operator": "AND",
+		"expressions": [
+			{
+				"operator": "OR",
+				"expressions": [
+					{"license": "MIT", "or_later": false, "exception": ""},
+					{"license": "Apache-2.0", "or_later": true, "exception": ""}
+				]
+			},
+			{"license": "GPL-2.0-only", "or_later": false, "exception": "Classpath-exception-2.0"}
+		]
+	}`).String(), parsed.String())
+
+	parsed, err = spdxParseLicenseExpression(bctx, ast.StringTerm("MIT OR"))
+	require.NoError(t, err)
+	require.Nil(t, parsed)
+
+	cases := []struct {
+		name       string
+		expression *ast.Term
+		allowed    *ast.Term
+		expected   *ast.Term
+	}{
+		{
+			name:       "satisfied",
+			expression: ast.StringTerm("(MIT OR GPL-3.0-only) AND BSD-3-Clause"),
+			allowed:    ast.ArrayTerm(ast.StringTerm("MIT"), ast.StringTerm("BSD-3-Clause")),
+			expected:   ast.BooleanTerm(true),
+		},
+		{
+			name:       "exception",
+			expression: ast.StringTerm("GPL-2.0-only WITH Classpath-exception-2.0"),
+			allowed:    ast.ArrayTerm(ast.StringTerm("GPL-2.0-only  WITH Classpath-exception-2.0")),
+			expected:   ast.BooleanTerm(true),
+		},
+		{
+			name:       "not satisfied",
+			expression: ast.StringTerm("MIT AND GPL-3.0-only"),
+			allowed:    ast.ArrayTerm(ast.StringTerm("MIT")),
+			expected:   ast.BooleanTerm(false),
+		},
+		{
+			name:       "invalid expression",
+			expression: ast.StringTerm("MIT AND"),
+			allowed:    ast.ArrayTerm(ast.StringTerm("MIT")),
+		},
+		{
+			name:       "unexpected allowed type",
+			expression: ast.StringTerm("MIT"),
+			allowed:    ast.ArrayTerm(ast.IntNumberTerm(42)),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			result, err := spdxLicenseSatisfies(bctx, c.expression, c.allowed)
+			require.NoError(t, err)
+			require.Equal(t, c.expected, result)
+		})
+	}
+}
+
+func TestRPMBuiltins(t *testing.T) {
+	bctx := rego.BuiltinContext{Context: context.Background()}
+
+	cases := []struct {
+		name     string
+		a        *ast.Term
+		b        *ast.Term
+		expected *ast.Term
+	}{
+		{
+			name:     "older",
+			a:        ast.StringTerm("1.2.3-1.el9"),
+			b:        ast.StringTerm("1.2.10-1.el9"),
+			expected: ast.IntNumberTerm(-1),
+		},
+		{
+			name:     "newer epoch",
+			a:        ast.StringTerm("1:1.0-1"),
+			b:        ast.StringTerm("2.0-1"),
+			expected: ast.IntNumberTerm(1),
+		},
+		{
+			name:     "any release",
+			a:        ast.StringTerm("1.2.3"),
+			b:        ast.StringTerm("1.2.3-5"),
+			expected: ast.IntNumberTerm(0),
+		},
+		{
+			name: "invalid epoch",
+			a:    ast.StringTerm("x:1.2.3"),
+			b:    ast.StringTerm("1.2.3"),
+		},
+		{
+			name: "unexpected type",
+			a:    ast.IntNumberTerm(42),
+			b:    ast.StringTerm("1.2.3"),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			result, err := rpmVercmp(bctx, c.a, c.b)
+			require.NoError(t, err)
+			require.Equal(t, c.expected, result)
+		})
+	}
+
+	evr, err := rpmParseEVR(bctx, ast.StringTerm("2:1.2.3-4.el9"))
+	require.NoError(t, err)
+	require.Equal(t, ast.MustParseTerm(`{"epoch": 2, "version": "1.2.3", "release": "4.el9"}`).String(), evr.String())
+
+	evr, err = rpmParseEVR(bctx, ast.StringTerm("x:1.2.3"))
+	require.NoError(t, err)
+	require.Nil(t, evr)
+}
+
+func TestSemverBuiltins(t *testing.T) {
+	bctx := rego.BuiltinContext{Context: context.Background()}
+
+	compareCases := []struct {
+		name     string
+		a        *ast.Term
+		b        *ast.Term
+		expected *ast.Term
+	}{
+		{
+			name:     "older",
+			a:        ast.StringTerm("1.2.3"),
+			b:        ast.StringTerm("1.10.0"),
+			expected: ast.IntNumberTerm(-1),
+		},
+		{
+			name:     "pre-release",
+			a:        ast.StringTerm("1.0.0"),
+			b:        ast.StringTerm("1.0.0-rc.1"),
+			expected: ast.IntNumberTerm(1),
+		},
+		{
+			name:     "tolerant",
+			a:        ast.StringTerm("v1.2"),
+			b:        ast.StringTerm("1.2.0"),
+			expected: ast.IntNumberTerm(0),
+		},
+		{
+			name: "invalid",
+			a:    ast.StringTerm("one"),
+			b:    ast.StringTerm("1.2.0"),
+		},
+		{
+			name: "unexpected type",
+			a:    ast.StringTerm("1.2.0"),
+			b:    ast.IntNumberTerm(42),
+		},
+	}
+
+	for _, c := range compareCases {
+		t.Run("compare "+c.name, func(t *testing.T) {
+			result, err := semverCompare(bctx, c.a, c.b)
+			require.NoError(t, err)
+			require.Equal(t, c.expected, result)
+		})
+	}
+
+	satisfiesCases := []struct {
+		name       string
+		version    *ast.Term
+		constraint *ast.Term
+		expected   *ast.Term
+	}{
+		{
+			name:       "within range",
+			version:    ast.StringTerm("1.5.0"),
+			constraint: ast.StringTerm(">=1.2.0 <2.0.0"),
+			expected:   ast.BooleanTerm(true),
+		},
+		{
+			name:       "outside range",
+			version:    ast.StringTerm("2.0.0"),
+			constraint: ast.StringTerm(">=1.2.0 <2.0.0"),
+			expected:   ast.BooleanTerm(false),
+		},
+		{
+			name:       "alternative range",
+			version:    ast.StringTerm("v3.1.0"),
+			constraint: ast.StringTerm("<2.0.0 || >=3.0.0"),
+			expected:   ast.BooleanTerm(true),
+		},
+		{
+			name:       "invalid constraint",
+			version:    ast.StringTerm("1.0.0"),
+			constraint: ast.StringTerm("~> 1.0"),
+		},
+		{
+			name:       "invalid version",
+			version:    ast.StringTerm("one"),
+			constraint: ast.StringTerm(">=1.0.0"),
+		},
+	}
+
+	for _, c := range satisfiesCases {
+		t.Run("satisfies "+c.name, func(t *testing.T) {
+			result, err := semverSatisfies(bctx, c.version, c.constraint)
+			require.NoError(t, err)
+			require.Equal(t, c.expected, result)
+		})
+	}
+}
+
 func TestFunctionsRegistered(t *testing.T) {
 	names := []string{
 		ociBlobName,
+		ociImageManifestName,
+		ociImageConfigName,
+		ociImageIndexName,
 		purlIsValidName,
 		purlParseName,
+		spdxParseLicenseExpressionName,
+		spdxLicenseSatisfiesName,
+		rpmVercmpName,
+		rpmParseEVRName,
+		semverCompareName,
+		semverSatisfiesName,
+		sigstoreVerifyImageName,
+		sigstoreVerifyAttestationName,
 	}
 	for _, name := range names {
 		t.Run(name, func(t *testing.T) {
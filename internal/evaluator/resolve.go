@@ -0,0 +1,154 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package evaluator
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	ecc "github.com/enterprise-contract/enterprise-contract-controller/api/v1alpha1"
+	"github.com/open-policy-agent/opa/ast"
+
+	"github.com/enterprise-contract/ec-cli/internal/opa"
+	"github.com/enterprise-contract/ec-cli/internal/opa/rule"
+	"github.com/enterprise-contract/ec-cli/internal/policy"
+	"github.com/enterprise-contract/ec-cli/internal/policy/source"
+	"github.com/enterprise-contract/ec-cli/internal/utils"
+)
+
+// Resolutions of the rules of a source group.
+const (
+	ResolutionEnforced = "enforced"
+	ResolutionWarned   = "warned"
+	ResolutionIgnored  = "ignored"
+)
+
+// RuleResolution records how a rule is going to be treated when a source group of the policy
+// is evaluated.
+type RuleResolution struct {
+	Code       string `json:"code"`
+	Resolution string `json:"resolution"`
+	Reason     string `json:"reason,omitempty"`
+}
+
+// FetchRules downloads the policy sources of the source group into the work directory and
+// returns the annotations of the rules found in them, by policy source URL.
+func FetchRules(ctx context.Context, src ecc.Source, workDir string) (map[string][]*ast.AnnotationsRef, error) {
+	fs := utils.FS(ctx)
+	rules := map[string][]*ast.AnnotationsRef{}
+	for _, url := range src.Policy {
+		s := &source.PolicyUrl{Url: url, Kind: source.PolicyKind}
+
+		dir, err := s.GetPolicy(ctx, workDir, false)
+		if err != nil {
+			return nil, err
+		}
+
+		annotations, err := opa.InspectDir(fs, dir)
+		if err != nil {
+			return nil, err
+		}
+
+		rules[s.PolicyUrl()] = annotations
+	}
+
+	return rules, nil
+}
+
+// ResolveRules determines which of the rules, given by their annotations by policy source URL,
+// are enforced, warned about or ignored when the source group is evaluated at the effective
+// time of the policy. The same include and exclude, collection, rule precedence and volatile
+// configuration logic used when evaluating is applied. Results for particular terms cannot be
+// predicted, so the rules are resolved as if they produce results without a term.
+func ResolveRules(p policy.Policy, src ecc.Source, annotations map[string][]*ast.AnnotationsRef) ([]RuleResolution, error) {
+	c := conftestEvaluator{policy: p}
+	c.include, c.exclude = computeIncludeExclude(src, p)
+
+	sources := make([]sourceRules, 0, len(src.Policy))
+	for _, url := range src.Policy {
+		sources = append(sources, sourceRules{url: url, annotations: annotations[url]})
+	}
+
+	rules, _, _, err := collectRules(p.Extensions().SourceOptions(src), sources)
+	if err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, 0, len(rules))
+	for code := range rules {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	effectiveTime := p.EffectiveTime()
+	resolutions := make([]RuleResolution, 0, len(codes))
+	for _, code := range codes {
+		info := rules[code]
+		if info.Kind != rule.Deny && info.Kind != rule.Warn {
+			continue
+		}
+
+		result := ruleResult(info)
+		resolution := RuleResolution{Code: code}
+		switch {
+		case !c.isResultIncluded(result):
+			resolution.Resolution, resolution.Reason = ResolutionIgnored, c.exclusionReason(result)
+		case info.Kind == rule.Warn:
+			resolution.Resolution = ResolutionWarned
+		case info.EffectiveOn != "" && !isResultEffective(Result{Metadata: map[string]any{metadataEffectiveOn: info.EffectiveOn}}, effectiveTime):
+			resolution.Resolution, resolution.Reason = ResolutionWarned, fmt.Sprintf("not yet effective, effective on %s", info.EffectiveOn)
+		default:
+			resolution.Resolution = ResolutionEnforced
+		}
+
+		resolutions = append(resolutions, resolution)
+	}
+
+	return resolutions, nil
+}
+
+// RuleMatchers returns the values of the include and exclude lists, without a term, that match
+// the rule.
+func RuleMatchers(info rule.Info) []string {
+	return makeMatchers(ruleResult(info))
+}
+
+// ruleResult returns a result, without a term, as produced by the rule.
+func ruleResult(info rule.Info) Result {
+	result := Result{
+		Metadata: map[string]any{
+			metadataCode: info.Code,
+		},
+	}
+	if len(info.Collections) > 0 {
+		result.Metadata[metadataCollections] = info.Collections
+	}
+
+	return result
+}
+
+// MatchesName returns true if the include or exclude list entry matches any of the matchers
+// returned by RuleMatchers. Globs and regular expressions are matched as patterns.
+func MatchesName(name string, matchers []string) bool {
+	return matchesName(name, matchers)
+}
+
+// IsRegex returns true if the include or exclude list entry is a regular expression.
+func IsRegex(name string) bool {
+	return isRegex(name)
+}
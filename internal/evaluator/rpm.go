@@ -0,0 +1,184 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package evaluator
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// evr is an RPM epoch, version and release, e.g. "1:2.3.4-5.el9".
+type evr struct {
+	epoch   int
+	version string
+	release string
+}
+
+// parseEVR parses the epoch, version and release. The epoch defaults to 0, and the release is
+// empty if not present.
+func parseEVR(s string) (evr, error) {
+	var e evr
+
+	if epoch, rest, found := strings.Cut(s, ":"); found {
+		n, err := strconv.Atoi(epoch)
+		if err != nil || n < 0 {
+			return evr{}, fmt.Errorf("invalid epoch %q", epoch)
+		}
+		e.epoch = n
+		s = rest
+	}
+
+	if i := strings.LastIndex(s, "-"); i >= 0 {
+		e.version, e.release = s[:i], s[i+1:]
+	} else {
+		e.version = s
+	}
+
+	if e.version == "" {
+		return evr{}, fmt.Errorf("missing version in %q", s)
+	}
+
+	return e, nil
+}
+
+// compareEVR compares the epochs, then the versions and then the releases. As with the
+// dependencies of RPM packages, the releases are only compared if both are present, so that
+// "1.2" matches any release of version 1.2.
+func compareEVR(a, b evr) int {
+	switch {
+	case a.epoch < b.epoch:
+		return -1
+	case a.epoch > b.epoch:
+		return 1
+	}
+
+	if c := rpmvercmp(a.version, b.version); c != 0 {
+		return c
+	}
+
+	if a.release == "" || b.release == "" {
+		return 0
+	}
+
+	return rpmvercmp(a.release, b.release)
+}
+
+// rpmvercmp compares two version, or release, strings the same way as the rpmvercmp function
+// of RPM. The strings are split into alphabetic and numeric segments, ignoring any other
+// separators, and compared segment by segment. Numeric segments are newer than alphabetic ones.
+// A "~" sorts before anything, even the end of the string, e.g. "1.0~rc1" is older than "1.0",
+// and a "^" sorts after the end of the string but before anything else.
+func rpmvercmp(a, b string) int {
+	if a == b {
+		return 0
+	}
+
+	isAlnum := func(c byte) bool {
+		return isDigit(c) || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+	}
+
+	for {
+		for len(a) > 0 && !isAlnum(a[0]) && a[0] != '~' && a[0] != '^' {
+			a = a[1:]
+		}
+		for len(b) > 0 && !isAlnum(b[0]) && b[0] != '~' && b[0] != '^' {
+			b = b[1:]
+		}
+
+		if strings.HasPrefix(a, "~") || strings.HasPrefix(b, "~") {
+			if !strings.HasPrefix(a, "~") {
+				return 1
+			}
+			if !strings.HasPrefix(b, "~") {
+				return -1
+			}
+			a, b = a[1:], b[1:]
+			continue
+		}
+
+		if strings.HasPrefix(a, "^") || strings.HasPrefix(b, "^") {
+			if a == "" {
+				return -1
+			}
+			if b == "" {
+				return 1
+			}
+			if !strings.HasPrefix(a, "^") {
+				return 1
+			}
+			if !strings.HasPrefix(b, "^") {
+				return -1
+			}
+			a, b = a[1:], b[1:]
+			continue
+		}
+
+		if a == "" || b == "" {
+			break
+		}
+
+		numeric := isDigit(a[0])
+		segment := func(s string) (string, string) {
+			i := 0
+			for i < len(s) && (numeric && isDigit(s[i]) || !numeric && isAlnum(s[i]) && !isDigit(s[i])) {
+				i++
+			}
+			return s[:i], s[i:]
+		}
+
+		var sa, sb string
+		sa, a = segment(a)
+		sb, b = segment(b)
+
+		// segments of different types, numeric ones are newer
+		if sb == "" {
+			if numeric {
+				return 1
+			}
+			return -1
+		}
+
+		if numeric {
+			sa = strings.TrimLeft(sa, "0")
+			sb = strings.TrimLeft(sb, "0")
+			if len(sa) != len(sb) {
+				if len(sa) > len(sb) {
+					return 1
+				}
+				return -1
+			}
+		}
+
+		if c := strings.Compare(sa, sb); c != 0 {
+			return c
+		}
+	}
+
+	switch {
+	case a == "" && b == "":
+		return 0
+	case a == "":
+		return -1
+	default:
+		return 1
+	}
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
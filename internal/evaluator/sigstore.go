@@ -0,0 +1,283 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// IMPORTANT: As with the other rego functions, the functions in this file never return an
+// error. A failed verification is reported in the returned result, any other problem results in
+// no value being returned.
+
+package evaluator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/open-policy-agent/opa/rego"
+	"github.com/open-policy-agent/opa/types"
+	"github.com/sigstore/cosign/v2/pkg/cosign"
+	ociremote "github.com/sigstore/cosign/v2/pkg/oci/remote"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/enterprise-contract/ec-cli/internal/attestation"
+	"github.com/enterprise-contract/ec-cli/internal/fetchers/oci"
+	"github.com/enterprise-contract/ec-cli/internal/policy"
+	"github.com/enterprise-contract/ec-cli/internal/signature"
+)
+
+const sigstoreVerifyImageName = "ec.sigstore.verify_image"
+const sigstoreVerifyAttestationName = "ec.sigstore.verify_attestation"
+
+// sigstoreOptions are the options accepted by the sigstore builtins. They mirror the
+// signature verification flags of the validate image command.
+type sigstoreOptions struct {
+	CertificateIdentity         string `json:"certificate_identity"`
+	CertificateIdentityRegExp   string `json:"certificate_identity_regexp"`
+	CertificateOIDCIssuer       string `json:"certificate_oidc_issuer"`
+	CertificateOIDCIssuerRegExp string `json:"certificate_oidc_issuer_regexp"`
+	IgnoreRekor                 bool   `json:"ignore_rekor"`
+	PublicKey                   string `json:"public_key"`
+	RekorURL                    string `json:"rekor_url"`
+}
+
+type imageVerification struct {
+	Success    bool                        `json:"success"`
+	Errors     []string                    `json:"errors"`
+	Signatures []signature.EntitySignature `json:"signatures"`
+}
+
+type attestationVerification struct {
+	Success      bool                  `json:"success"`
+	Errors       []string              `json:"errors"`
+	Attestations []verifiedAttestation `json:"attestations"`
+}
+
+type verifiedAttestation struct {
+	Statement  json.RawMessage             `json:"statement"`
+	Signatures []signature.EntitySignature `json:"signatures"`
+}
+
+func registerSigstoreVerifyImage() {
+	registerSigstoreVerification(sigstoreVerifyImageName, "the result of the image signature verification", types.NewObject(
+		[]*types.StaticProperty{
+			{Key: "success", Value: types.B},
+			{Key: "errors", Value: types.NewArray(nil, types.S)},
+			{Key: "signatures", Value: types.NewArray(nil, types.A)},
+		},
+		nil,
+	), sigstoreVerifyImage)
+}
+
+func registerSigstoreVerifyAttestation() {
+	registerSigstoreVerification(sigstoreVerifyAttestationName, "the result of the image attestation verification", types.NewObject(
+		[]*types.StaticProperty{
+			{Key: "success", Value: types.B},
+			{Key: "errors", Value: types.NewArray(nil, types.S)},
+			{Key: "attestations", Value: types.NewArray(nil, types.NewObject(
+				[]*types.StaticProperty{
+					{Key: "statement", Value: types.A},
+					{Key: "signatures", Value: types.NewArray(nil, types.A)},
+				},
+				nil,
+			))},
+		},
+		nil,
+	), sigstoreVerifyAttestation)
+}
+
+// registerSigstoreVerification registers a builtin verifying the given image reference with
+// the given signature verification options.
+func registerSigstoreVerification(name, description string, result types.Type, fn rego.Builtin2) {
+	decl := rego.Function{
+		Name: name,
+		Decl: types.NewFunction(
+			types.Args(
+				types.Named("ref", types.S).Description("OCI image reference, including the digest"),
+				types.Named("opts", types.NewObject(nil, types.NewDynamicProperty(types.S, types.A))).Description("signature verification options"),
+			),
+			types.Named("result", result).Description(description),
+		),
+		// As per the documentation, enable memoization to ensure function evaluation is
+		// deterministic. But also mark it as non-deterministic because it does rely on external
+		// entities, i.e. OCI registry and Rekor. https://www.openpolicyagent.org/docs/latest/extensions/
+		Memoize:          true,
+		Nondeterministic: true,
+	}
+
+	registerBuiltin2(&decl, fn)
+}
+
+func sigstoreVerifyImage(bctx rego.BuiltinContext, refTerm, optsTerm *ast.Term) (*ast.Term, error) {
+	ref, opts, err := sigstoreArgs(bctx, refTerm, optsTerm)
+	if err != nil {
+		return verificationTerm(sigstoreVerifyImageName, imageVerification{
+			Errors:     []string{err.Error()},
+			Signatures: []signature.EntitySignature{},
+		})
+	}
+	if ref == nil {
+		return nil, nil
+	}
+
+	opts.ClaimVerifier = cosign.SimpleClaimVerifier
+	found, _, err := oci.NewClient(bctx.Context).VerifyImageSignatures(bctx.Context, ref, opts)
+	if err != nil {
+		return verificationTerm(sigstoreVerifyImageName, imageVerification{
+			Errors:     []string{fmt.Sprintf("verify image signatures: %s", err)},
+			Signatures: []signature.EntitySignature{},
+		})
+	}
+
+	signatures := make([]signature.EntitySignature, 0, len(found))
+	for _, s := range found {
+		es, err := signature.NewEntitySignature(s)
+		if err != nil {
+			log.Errorf("%s entity signature: %s", sigstoreVerifyImageName, err)
+			return nil, nil
+		}
+		signatures = append(signatures, es)
+	}
+
+	return verificationTerm(sigstoreVerifyImageName, imageVerification{
+		Success:    true,
+		Errors:     []string{},
+		Signatures: signatures,
+	})
+}
+
+func sigstoreVerifyAttestation(bctx rego.BuiltinContext, refTerm, optsTerm *ast.Term) (*ast.Term, error) {
+	ref, opts, err := sigstoreArgs(bctx, refTerm, optsTerm)
+	if err != nil {
+		return verificationTerm(sigstoreVerifyAttestationName, attestationVerification{
+			Errors:       []string{err.Error()},
+			Attestations: []verifiedAttestation{},
+		})
+	}
+	if ref == nil {
+		return nil, nil
+	}
+
+	opts.ClaimVerifier = cosign.IntotoSubjectClaimVerifier
+	found, _, err := oci.NewClient(bctx.Context).VerifyImageAttestations(bctx.Context, ref, opts)
+	if err != nil {
+		return verificationTerm(sigstoreVerifyAttestationName, attestationVerification{
+			Errors:       []string{fmt.Sprintf("verify image attestations: %s", err)},
+			Attestations: []verifiedAttestation{},
+		})
+	}
+
+	attestations := make([]verifiedAttestation, 0, len(found))
+	for _, s := range found {
+		att, err := attestation.ProvenanceFromSignature(s)
+		if err != nil {
+			log.Errorf("%s parse attestation: %s", sigstoreVerifyAttestationName, err)
+			return nil, nil
+		}
+
+		signatures := att.Signatures()
+		if signatures == nil {
+			signatures = []signature.EntitySignature{}
+		}
+
+		attestations = append(attestations, verifiedAttestation{
+			Statement:  att.Statement(),
+			Signatures: signatures,
+		})
+	}
+
+	return verificationTerm(sigstoreVerifyAttestationName, attestationVerification{
+		Success:      true,
+		Errors:       []string{},
+		Attestations: attestations,
+	})
+}
+
+// sigstoreArgs parses the arguments of the sigstore builtins into the reference to verify and
+// the options to verify it with. A nil reference without an error is returned when the
+// arguments are not of the expected types. Problems with the values of the arguments are
+// returned as errors, to be reported in the result of the verification.
+func sigstoreArgs(bctx rego.BuiltinContext, refTerm, optsTerm *ast.Term) (name.Reference, *cosign.CheckOpts, error) {
+	uri, ok := refTerm.Value.(ast.String)
+	if !ok {
+		return nil, nil, nil
+	}
+
+	if _, ok := optsTerm.Value.(ast.Object); !ok {
+		return nil, nil, nil
+	}
+
+	ref, err := name.NewDigest(string(uri))
+	if err != nil {
+		return nil, nil, fmt.Errorf("new digest: %w", err)
+	}
+
+	value, err := ast.JSON(optsTerm.Value)
+	if err != nil {
+		return nil, nil, fmt.Errorf("options: %w", err)
+	}
+
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, nil, fmt.Errorf("options: %w", err)
+	}
+
+	var options sigstoreOptions
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&options); err != nil {
+		return nil, nil, fmt.Errorf("options: %w", err)
+	}
+
+	// The trusted root, timestamp authority, Rekor URL and whether to ignore Rekor of the policy
+	// being evaluated are used, the rules can only narrow down the verification.
+	active, _ := bctx.Context.Value(policyKey).(policy.Policy)
+
+	opts, err := policy.NewCheckOpts(bctx.Context, active, policy.SignatureOptions{
+		Identity: cosign.Identity{
+			Issuer:        options.CertificateOIDCIssuer,
+			IssuerRegExp:  options.CertificateOIDCIssuerRegExp,
+			Subject:       options.CertificateIdentity,
+			SubjectRegExp: options.CertificateIdentityRegExp,
+		},
+		IgnoreRekor: options.IgnoreRekor,
+		PublicKey:   options.PublicKey,
+		RekorURL:    options.RekorURL,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("options: %w", err)
+	}
+
+	opts.RegistryClientOpts = append(opts.RegistryClientOpts, ociremote.WithRemoteOptions(remoteOptions(bctx)...))
+
+	return ref, opts, nil
+}
+
+func verificationTerm(builtin string, result any) (*ast.Term, error) {
+	raw, err := json.Marshal(result)
+	if err != nil {
+		log.Errorf("%s marshal result: %s", builtin, err)
+		return nil, nil
+	}
+
+	value, err := ast.ValueFromReader(bytes.NewReader(raw))
+	if err != nil {
+		log.Errorf("%s parse result: %s", builtin, err)
+		return nil, nil
+	}
+
+	return ast.NewTerm(value), nil
+}
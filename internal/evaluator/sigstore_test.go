@@ -0,0 +1,261 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unit
+
+package evaluator
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	ecc "github.com/enterprise-contract/enterprise-contract-controller/api/v1alpha1"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/open-policy-agent/opa/rego"
+	"github.com/sigstore/cosign/v2/pkg/cosign"
+	cosignOCI "github.com/sigstore/cosign/v2/pkg/oci"
+	"github.com/sigstore/cosign/v2/pkg/oci/static"
+	"github.com/sigstore/cosign/v2/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/enterprise-contract/ec-cli/internal/fetchers/oci"
+	"github.com/enterprise-contract/ec-cli/internal/fetchers/oci/fake"
+	"github.com/enterprise-contract/ec-cli/internal/policy"
+	"github.com/enterprise-contract/ec-cli/internal/utils"
+)
+
+const sigstoreTestRef = "registry.local/spam@sha256:4bbf56a3a9231f752d3b9c174637975f0f83ed2b15e65799837c571e4ef3374b"
+
+func sigstoreOptionsTerm(t *testing.T, opts map[string]any) *ast.Term {
+	value, err := ast.InterfaceToValue(opts)
+	require.NoError(t, err)
+	return ast.NewTerm(value)
+}
+
+// withPolicyIgnoringRekor returns the context with a policy ignoring Rekor being evaluated, the
+// rules cannot ignore Rekor otherwise.
+func withPolicyIgnoringRekor(t *testing.T, ctx context.Context) context.Context {
+	p, err := policy.NewPolicy(ctx, policy.Options{
+		EffectiveTime: policy.Now,
+		IgnoreRekor:   true,
+		PublicKey:     utils.TestPublicKey,
+	})
+	require.NoError(t, err)
+
+	return context.WithValue(ctx, policyKey, p)
+}
+
+func TestSigstoreVerifyImage(t *testing.T) {
+	signature, err := static.NewSignature([]byte(`image`), "c2lnbmF0dXJl")
+	require.NoError(t, err)
+
+	cases := []struct {
+		name       string
+		ref        *ast.Term
+		opts       map[string]any
+		signatures []cosignOCI.Signature
+		verifyErr  error
+		expected   string
+		undefined  bool
+	}{
+		{
+			name:       "verified",
+			ref:        ast.StringTerm(sigstoreTestRef),
+			opts:       map[string]any{"public_key": utils.TestPublicKey, "ignore_rekor": true},
+			signatures: []cosignOCI.Signature{signature},
+			expected:   `{"success": true, "errors": [], "signatures": [{"keyid": "", "sig": "c2lnbmF0dXJl"}]}`,
+		},
+		{
+			name:      "verification failure",
+			ref:       ast.StringTerm(sigstoreTestRef),
+			opts:      map[string]any{"public_key": utils.TestPublicKey, "ignore_rekor": true},
+			verifyErr: errors.New("no matching signatures"),
+			expected:  `{"success": false, "errors": ["verify image signatures: no matching signatures"], "signatures": []}`,
+		},
+		{
+			name:     "missing digest",
+			ref:      ast.StringTerm("registry.local/spam:latest"),
+			opts:     map[string]any{"public_key": utils.TestPublicKey, "ignore_rekor": true},
+			expected: `{"success": false, "errors": ["new digest: a digest must contain exactly one '@' separator (e.g. registry/repository@digest) saw: registry.local/spam:latest"], "signatures": []}`,
+		},
+		{
+			name:     "unknown option",
+			ref:      ast.StringTerm(sigstoreTestRef),
+			opts:     map[string]any{"publickey": utils.TestPublicKey},
+			expected: `{"success": false, "errors": ["options: json: unknown field \"publickey\""], "signatures": []}`,
+		},
+		{
+			name:     "missing identity",
+			ref:      ast.StringTerm(sigstoreTestRef),
+			opts:     map[string]any{"certificate_identity": "spam"},
+			expected: `{"success": false, "errors": ["options: 1 error occurred:\n\t* certificate OIDC issuer must be provided for keyless workflow\n\n"], "signatures": []}`,
+		},
+		{
+			name:     "public key reference",
+			ref:      ast.StringTerm(sigstoreTestRef),
+			opts:     map[string]any{"public_key": "env://COSIGN_PUBLIC_KEY"},
+			expected: `{"success": false, "errors": ["options: public key must be provided in PEM format"], "signatures": []}`,
+		},
+		{
+			name:     "rekor url not of the policy",
+			ref:      ast.StringTerm(sigstoreTestRef),
+			opts:     map[string]any{"public_key": utils.TestPublicKey, "rekor_url": "https://rekor.evil.example"},
+			expected: `{"success": false, "errors": ["options: rekor URL \"https://rekor.evil.example\" does not match the Rekor URL of the policy"], "signatures": []}`,
+		},
+		{
+			name:      "unexpected ref type",
+			ref:       ast.IntNumberTerm(42),
+			opts:      map[string]any{},
+			undefined: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			client := fake.FakeClient{}
+			client.On("VerifyImageSignatures", mock.Anything, name.MustParseReference(sigstoreTestRef), mock.Anything).Return(c.signatures, true, c.verifyErr)
+			ctx := oci.WithClient(withPolicyIgnoringRekor(t, context.Background()), &client)
+			bctx := rego.BuiltinContext{Context: ctx}
+
+			result, err := sigstoreVerifyImage(bctx, c.ref, sigstoreOptionsTerm(t, c.opts))
+			require.NoError(t, err)
+			if c.undefined {
+				require.Nil(t, result)
+				return
+			}
+			require.NotNil(t, result)
+			assert.Equal(t, ast.MustParseTerm(c.expected).String(), result.String())
+
+			if len(client.Calls) > 0 {
+				opts := client.Calls[0].Arguments.Get(2).(*cosign.CheckOpts)
+				assert.NotNil(t, opts.ClaimVerifier)
+				assert.NotNil(t, opts.SigVerifier)
+				assert.True(t, opts.IgnoreTlog)
+			}
+		})
+	}
+}
+
+func TestSigstoreVerifyImageWithPolicyRekor(t *testing.T) {
+	utils.SetTestRekorPublicKey(t)
+
+	p, err := policy.NewOfflinePolicy(context.Background(), policy.Now)
+	require.NoError(t, err)
+	p = p.WithSpec(ecc.EnterpriseContractPolicySpec{RekorUrl: utils.TestRekorURL})
+
+	client := fake.FakeClient{}
+	client.On("VerifyImageSignatures", mock.Anything, name.MustParseReference(sigstoreTestRef), mock.Anything).Return([]cosignOCI.Signature{}, true, nil)
+	ctx := oci.WithClient(context.Background(), &client)
+	ctx = context.WithValue(ctx, policyKey, p)
+	bctx := rego.BuiltinContext{Context: ctx}
+
+	opts := sigstoreOptionsTerm(t, map[string]any{"public_key": utils.TestPublicKey, "rekor_url": utils.TestRekorURL})
+	result, err := sigstoreVerifyImage(bctx, ast.StringTerm(sigstoreTestRef), opts)
+	require.NoError(t, err)
+	assert.Equal(t, ast.MustParseTerm(`{"success": true, "errors": [], "signatures": []}`).String(), result.String())
+
+	checkOpts := client.Calls[0].Arguments.Get(2).(*cosign.CheckOpts)
+	assert.NotNil(t, checkOpts.RekorClient)
+	assert.NotNil(t, checkOpts.RekorPubKeys)
+}
+
+func TestSigstoreVerifyImageIgnoringPolicyRekor(t *testing.T) {
+	p, err := policy.NewOfflinePolicy(context.Background(), policy.Now)
+	require.NoError(t, err)
+	p = p.WithSpec(ecc.EnterpriseContractPolicySpec{RekorUrl: utils.TestRekorURL})
+
+	client := fake.FakeClient{}
+	ctx := oci.WithClient(context.Background(), &client)
+	ctx = context.WithValue(ctx, policyKey, p)
+	bctx := rego.BuiltinContext{Context: ctx}
+
+	opts := sigstoreOptionsTerm(t, map[string]any{"public_key": utils.TestPublicKey, "ignore_rekor": true})
+	result, err := sigstoreVerifyImage(bctx, ast.StringTerm(sigstoreTestRef), opts)
+	require.NoError(t, err)
+	assert.Equal(t, ast.MustParseTerm(`{"success": false, "errors": ["options: rekor cannot be ignored, the policy requires it"], "signatures": []}`).String(), result.String())
+	assert.Empty(t, client.Calls)
+}
+
+func TestSigstoreVerifyAttestation(t *testing.T) {
+	statement := `{"_type":"https://in-toto.io/Statement/v0.1","predicateType":"https://slsa.dev/provenance/v0.2","subject":[],"predicate":{"builder":{"id":"spam"}}}`
+	envelope, err := json.Marshal(cosign.AttestationPayload{
+		PayloadType: "application/vnd.in-toto+json",
+		PayLoad:     base64.StdEncoding.EncodeToString([]byte(statement)),
+		Signatures:  []cosign.Signatures{{KeyID: "key", Sig: "c2lnbmF0dXJl"}},
+	})
+	require.NoError(t, err)
+
+	att, err := static.NewAttestation(envelope, static.WithLayerMediaType(types.DssePayloadType))
+	require.NoError(t, err)
+
+	notAttestation, err := static.NewSignature([]byte(`image`), "c2lnbmF0dXJl")
+	require.NoError(t, err)
+
+	cases := []struct {
+		name         string
+		attestations []cosignOCI.Signature
+		verifyErr    error
+		expected     string
+		undefined    bool
+	}{
+		{
+			name:         "verified",
+			attestations: []cosignOCI.Signature{att},
+			expected: `{"success": true, "errors": [], "attestations": [{
+				"statement": {"_type": "https://in-toto.io/Statement/v0.1", "predicateType": "https://slsa.dev/provenance/v0.2", "subject": [], "predicate": {"builder": {"id": "spam"}}},
+				"signatures": [{"keyid": "key", "sig": "c2lnbmF0dXJl"}]
+			}]}`,
+		},
+		{
+			name:      "verification failure",
+			verifyErr: errors.New("no matching attestations"),
+			expected:  `{"success": false, "errors": ["verify image attestations: no matching attestations"], "attestations": []}`,
+		},
+		{
+			name:         "malformed attestation",
+			attestations: []cosignOCI.Signature{notAttestation},
+			undefined:    true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			client := fake.FakeClient{}
+			client.On("VerifyImageAttestations", mock.Anything, name.MustParseReference(sigstoreTestRef), mock.Anything).Return(c.attestations, true, c.verifyErr)
+			ctx := oci.WithClient(withPolicyIgnoringRekor(t, context.Background()), &client)
+			bctx := rego.BuiltinContext{Context: ctx}
+
+			opts := sigstoreOptionsTerm(t, map[string]any{"public_key": utils.TestPublicKey, "ignore_rekor": true})
+			result, err := sigstoreVerifyAttestation(bctx, ast.StringTerm(sigstoreTestRef), opts)
+			require.NoError(t, err)
+			if c.undefined {
+				require.Nil(t, result)
+				return
+			}
+			require.NotNil(t, result)
+			assert.Equal(t, ast.MustParseTerm(c.expected).String(), result.String())
+
+			checkOpts := client.Calls[0].Arguments.Get(2).(*cosign.CheckOpts)
+			assert.NotNil(t, checkOpts.ClaimVerifier)
+		})
+	}
+}
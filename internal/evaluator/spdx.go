@@ -0,0 +1,242 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package evaluator
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/open-policy-agent/opa/ast"
+)
+
+// SPDX license expression operators, in the order of precedence.
+const (
+	spdxWith = "WITH"
+	spdxAnd  = "AND"
+	spdxOr   = "OR"
+)
+
+// spdxIdentifier matches license and exception identifiers, including references to licenses
+// defined in a document, e.g. "DocumentRef-spdx-tool-1.2:LicenseRef-MIT-Style-2".
+var spdxIdentifier = regexp.MustCompile(`^[A-Za-z0-9.\-]+(:[A-Za-z0-9.\-]+)?$`)
+
+// licenseExpression is a node of a parsed SPDX license expression. It is either a license, with
+// an optional exception, or an operator applied to two or more expressions.
+type licenseExpression struct {
+	License string
+	// OrLater is set when the license is followed by "+", i.e. the version of the license or any
+	// later version.
+	OrLater   bool
+	Exception string
+
+	Operator    string
+	Expressions []*licenseExpression
+}
+
+// parseLicenseExpression parses an SPDX license expression, e.g.
+// "(MIT OR Apache-2.0) AND BSD-3-Clause". Operators are matched regardless of case. A chain of
+// the same operator is parsed into a single node, e.g. "A AND B AND C" results in an AND node
+// with three expressions.
+func parseLicenseExpression(expression string) (*licenseExpression, error) {
+	p := &spdxParser{tokens: tokenizeLicenseExpression(expression)}
+	if len(p.tokens) == 0 {
+		return nil, errors.New("empty license expression")
+	}
+
+	e, err := p.parseOperator(spdxOr)
+	if err != nil {
+		return nil, err
+	}
+
+	if t, ok := p.peek(); ok {
+		return nil, fmt.Errorf("unexpected %q", t)
+	}
+
+	return e, nil
+}
+
+func tokenizeLicenseExpression(expression string) []string {
+	expression = strings.NewReplacer("(", " ( ", ")", " ) ").Replace(expression)
+	return strings.Fields(expression)
+}
+
+type spdxParser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *spdxParser) peek() (string, bool) {
+	if p.pos >= len(p.tokens) {
+		return "", false
+	}
+
+	return p.tokens[p.pos], true
+}
+
+func (p *spdxParser) next() (string, bool) {
+	t, ok := p.peek()
+	if ok {
+		p.pos++
+	}
+
+	return t, ok
+}
+
+func (p *spdxParser) isOperator(operator string) bool {
+	t, ok := p.peek()
+	return ok && strings.EqualFold(t, operator)
+}
+
+// parseOperator parses a chain of expressions joined by the operator. The operands are parsed
+// with the operator of the next higher precedence.
+func (p *spdxParser) parseOperator(operator string) (*licenseExpression, error) {
+	operand := p.parseWith
+	if operator == spdxOr {
+		operand = func() (*licenseExpression, error) {
+			return p.parseOperator(spdxAnd)
+		}
+	}
+
+	first, err := operand()
+	if err != nil {
+		return nil, err
+	}
+
+	expressions := []*licenseExpression{first}
+	for p.isOperator(operator) {
+		p.pos++
+		e, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		expressions = append(expressions, e)
+	}
+
+	if len(expressions) == 1 {
+		return first, nil
+	}
+
+	return &licenseExpression{Operator: operator, Expressions: expressions}, nil
+}
+
+func (p *spdxParser) parseWith() (*licenseExpression, error) {
+	t, ok := p.next()
+	if !ok {
+		return nil, errors.New("unexpected end of the license expression")
+	}
+
+	if t == "(" {
+		e, err := p.parseOperator(spdxOr)
+		if err != nil {
+			return nil, err
+		}
+		if t, ok := p.next(); !ok || t != ")" {
+			return nil, errors.New(`missing ")"`)
+		}
+		return e, nil
+	}
+
+	if t == ")" || strings.EqualFold(t, spdxAnd) || strings.EqualFold(t, spdxOr) || strings.EqualFold(t, spdxWith) {
+		return nil, fmt.Errorf("unexpected %q", t)
+	}
+
+	e := &licenseExpression{License: t}
+	if strings.HasSuffix(t, "+") {
+		e.License, e.OrLater = strings.TrimSuffix(t, "+"), true
+	}
+	if !spdxIdentifier.MatchString(e.License) {
+		return nil, fmt.Errorf("invalid license identifier %q", t)
+	}
+
+	if p.isOperator(spdxWith) {
+		p.pos++
+		exception, ok := p.next()
+		if !ok || !spdxIdentifier.MatchString(exception) {
+			return nil, errors.New("missing license exception")
+		}
+		e.Exception = exception
+	}
+
+	return e, nil
+}
+
+// satisfiedBy returns true if the licenses can be complied with using only the allowed licenses.
+// For an OR expression, one of the choices needs to be satisfied, for an AND expression all of
+// them. A license with an exception is satisfied when either the license with the exception,
+// e.g. "GPL-2.0-only WITH Classpath-exception-2.0", or the license is allowed. Likewise, a
+// license followed by "+" is satisfied when either the license with or without the "+" is
+// allowed. Identifiers are matched regardless of case.
+func (e *licenseExpression) satisfiedBy(allowed map[string]bool) bool {
+	switch e.Operator {
+	case spdxAnd:
+		for _, x := range e.Expressions {
+			if !x.satisfiedBy(allowed) {
+				return false
+			}
+		}
+		return true
+	case spdxOr:
+		for _, x := range e.Expressions {
+			if x.satisfiedBy(allowed) {
+				return true
+			}
+		}
+		return false
+	}
+
+	license := strings.ToLower(e.License)
+	candidates := []string{license}
+	if e.OrLater {
+		candidates = append(candidates, license+"+")
+	}
+
+	for _, c := range candidates {
+		if allowed[c] {
+			return true
+		}
+		if e.Exception != "" && allowed[c+" with "+strings.ToLower(e.Exception)] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// term converts the expression into an object, a license is represented as
+// {"license": "GPL-2.0-only", "or_later": false, "exception": ""}, an operator as
+// {"operator": "AND", "expressions": [...]}.
+func (e *licenseExpression) term() *ast.Term {
+	if e.Operator == "" {
+		return ast.ObjectTerm(
+			ast.Item(ast.StringTerm("license"), ast.StringTerm(e.License)),
+			ast.Item(ast.StringTerm("or_later"), ast.BooleanTerm(e.OrLater)),
+			ast.Item(ast.StringTerm("exception"), ast.StringTerm(e.Exception)),
+		)
+	}
+
+	expressions := make([]*ast.Term, 0, len(e.Expressions))
+	for _, x := range e.Expressions {
+		expressions = append(expressions, x.term())
+	}
+
+	return ast.ObjectTerm(
+		ast.Item(ast.StringTerm("operator"), ast.StringTerm(e.Operator)),
+		ast.Item(ast.StringTerm("expressions"), ast.ArrayTerm(expressions...)),
+	)
+}
@@ -0,0 +1,167 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unit
+
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLicenseExpression(t *testing.T) {
+	mit := &licenseExpression{License: "MIT"}
+	apache := &licenseExpression{License: "Apache-2.0"}
+	bsd := &licenseExpression{License: "BSD-3-Clause"}
+
+	cases := []struct {
+		name       string
+		expression string
+		expected   *licenseExpression
+		err        string
+	}{
+		{
+			name:       "license",
+			expression: "MIT",
+			expected:   mit,
+		},
+		{
+			name:       "or later",
+			expression: "GPL-2.0+",
+			expected:   &licenseExpression{License: "GPL-2.0", OrLater: true},
+		},
+		{
+			name:       "exception",
+			expression: "GPL-2.0-only WITH Classpath-exception-2.0",
+			expected:   &licenseExpression{License: "GPL-2.0-only", Exception: "Classpath-exception-2.0"},
+		},
+		{
+			name:       "license reference",
+			expression: "DocumentRef-spdx-tool-1.2:LicenseRef-MIT-Style-2",
+			expected:   &licenseExpression{License: "DocumentRef-spdx-tool-1.2:LicenseRef-MIT-Style-2"},
+		},
+		{
+			name:       "AND binds tighter than OR",
+			expression: "MIT OR Apache-2.0 AND BSD-3-Clause",
+			expected: &licenseExpression{Operator: spdxOr, Expressions: []*licenseExpression{
+				mit,
+				{Operator: spdxAnd, Expressions: []*licenseExpression{apache, bsd}},
+			}},
+		},
+		{
+			name:       "parentheses",
+			expression: "(MIT OR Apache-2.0) AND BSD-3-Clause",
+			expected: &licenseExpression{Operator: spdxAnd, Expressions: []*licenseExpression{
+				{Operator: spdxOr, Expressions: []*licenseExpression{mit, apache}},
+				bsd,
+			}},
+		},
+		{
+			name:       "chain",
+			expression: "MIT and Apache-2.0 AND BSD-3-Clause",
+			expected:   &licenseExpression{Operator: spdxAnd, Expressions: []*licenseExpression{mit, apache, bsd}},
+		},
+		{
+			name:       "empty",
+			expression: " ",
+			err:        "empty license expression",
+		},
+		{
+			name:       "missing operand",
+			expression: "MIT OR",
+			err:        "unexpected end of the license expression",
+		},
+		{
+			name:       "missing parenthesis",
+			expression: "(MIT OR Apache-2.0",
+			err:        `missing ")"`,
+		},
+		{
+			name:       "extra parenthesis",
+			expression: "MIT)",
+			err:        `unexpected ")"`,
+		},
+		{
+			name:       "missing operator",
+			expression: "MIT Apache-2.0",
+			err:        `unexpected "Apache-2.0"`,
+		},
+		{
+			name:       "missing exception",
+			expression: "GPL-2.0-only WITH",
+			err:        "missing license exception",
+		},
+		{
+			name:       "exception on a compound expression",
+			expression: "(MIT OR Apache-2.0) WITH Classpath-exception-2.0",
+			err:        `unexpected "WITH"`,
+		},
+		{
+			name:       "invalid identifier",
+			expression: "MIT/X11",
+			err:        `invalid license identifier "MIT/X11"`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			e, err := parseLicenseExpression(c.expression)
+			if c.err != "" {
+				assert.EqualError(t, err, c.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, c.expected, e)
+		})
+	}
+}
+
+func TestLicenseSatisfiedBy(t *testing.T) {
+	cases := []struct {
+		name       string
+		expression string
+		allowed    []string
+		expected   bool
+	}{
+		{name: "allowed", expression: "MIT", allowed: []string{"mit"}, expected: true},
+		{name: "not allowed", expression: "GPL-3.0-only", allowed: []string{"mit"}},
+		{name: "one choice allowed", expression: "MIT OR GPL-3.0-only", allowed: []string{"mit"}, expected: true},
+		{name: "not all allowed", expression: "MIT AND GPL-3.0-only", allowed: []string{"mit"}},
+		{name: "nested", expression: "(MIT OR GPL-3.0-only) AND BSD-3-Clause", allowed: []string{"mit", "bsd-3-clause"}, expected: true},
+		{name: "license of exception allowed", expression: "GPL-2.0-only WITH Classpath-exception-2.0", allowed: []string{"gpl-2.0-only"}, expected: true},
+		{name: "exception allowed", expression: "GPL-2.0-only WITH Classpath-exception-2.0", allowed: []string{"gpl-2.0-only with classpath-exception-2.0"}, expected: true},
+		{name: "other exception allowed", expression: "GPL-2.0-only WITH Autoconf-exception-2.0", allowed: []string{"gpl-2.0-only with classpath-exception-2.0"}},
+		{name: "or later", expression: "GPL-2.0+", allowed: []string{"gpl-2.0"}, expected: true},
+		{name: "or later allowed", expression: "GPL-2.0+", allowed: []string{"gpl-2.0+"}, expected: true},
+		{name: "only or later allowed", expression: "GPL-2.0", allowed: []string{"gpl-2.0+"}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			e, err := parseLicenseExpression(c.expression)
+			require.NoError(t, err)
+
+			allowed := map[string]bool{}
+			for _, a := range c.allowed {
+				allowed[a] = true
+			}
+			assert.Equal(t, c.expected, e.satisfiedBy(allowed))
+		})
+	}
+}
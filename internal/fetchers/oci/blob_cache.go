@@ -0,0 +1,261 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package oci
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	log "github.com/sirupsen/logrus"
+)
+
+// blobMaxAge is how long a cached blob is kept without being used.
+const blobMaxAge = 30 * 24 * time.Hour
+
+// blobCache is a content-addressed cache of the blobs fetched by digest. A blob is only added
+// to the cache once it has been read in full and its content matches the digest, and it is
+// verified again when read from the cache. Blobs not used for blobMaxAge are removed.
+type blobCache struct {
+	dir string
+}
+
+// prune removes the blobs, and the temporary files of blobs being added, not used for
+// blobMaxAge.
+func (c *blobCache) prune() {
+	entries, err := os.ReadDir(filepath.Join(c.dir, "sha256"))
+	if err != nil {
+		return
+	}
+
+	cutoff := time.Now().Add(-blobMaxAge)
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil || e.IsDir() || info.ModTime().After(cutoff) {
+			continue
+		}
+
+		path := filepath.Join(c.dir, "sha256", e.Name())
+		if err := os.Remove(path); err != nil {
+			log.Debugf("unable to remove cached blob %q: %v", path, err)
+		} else if !strings.HasPrefix(e.Name(), ".blob-") {
+			log.Debugf("removed cached blob %q, not used since %s", path, info.ModTime().Format(time.RFC3339))
+		}
+	}
+}
+
+func (c *blobCache) path(digest v1.Hash) string {
+	return filepath.Join(c.dir, digest.Algorithm, digest.Hex)
+}
+
+// layer wraps the layer so that its uncompressed content is read from the cache when present.
+func (c *blobCache) layer(ref name.Digest, layer v1.Layer) v1.Layer {
+	digest, err := v1.NewHash(ref.DigestStr())
+	if err != nil || digest.Algorithm != "sha256" {
+		return layer
+	}
+
+	return &cachedLayer{Layer: layer, cache: c, digest: digest}
+}
+
+// cached returns the layer of the blob with the digest of the reference if it is cached. The
+// layer is served from the cache alone, so the registry is not contacted.
+func (c *blobCache) cached(ref name.Digest) (v1.Layer, bool) {
+	digest, err := v1.NewHash(ref.DigestStr())
+	if err != nil || digest.Algorithm != "sha256" {
+		return nil, false
+	}
+
+	rc, ok := c.get(digest)
+	if !ok {
+		return nil, false
+	}
+	defer rc.Close()
+
+	info, err := os.Stat(c.path(digest))
+	if err != nil {
+		return nil, false
+	}
+
+	log.Debugf("using cached blob %s", digest)
+	return &cachedBlob{cache: c, digest: digest, size: info.Size()}, true
+}
+
+// get returns the cached blob with the given digest. A cached blob not matching the digest is
+// removed from the cache.
+func (c *blobCache) get(digest v1.Hash) (io.ReadCloser, bool) {
+	path := c.path(digest)
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, false
+	}
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, f); err != nil {
+		f.Close()
+		return nil, false
+	}
+
+	if sum := fmt.Sprintf("%x", hasher.Sum(nil)); sum != digest.Hex {
+		log.Debugf("removing cached blob %q, its digest does not match: %q", path, sum)
+		f.Close()
+		_ = os.Remove(path)
+		return nil, false
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		f.Close()
+		return nil, false
+	}
+
+	// the modification time records when the blob was last used, see prune
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+
+	return f, true
+}
+
+// cachedLayer serves the uncompressed content of a layer, referenced by the digest of that
+// content, from the blob cache.
+type cachedLayer struct {
+	v1.Layer
+	cache  *blobCache
+	digest v1.Hash
+}
+
+func (l *cachedLayer) Uncompressed() (io.ReadCloser, error) {
+	if rc, ok := l.cache.get(l.digest); ok {
+		log.Debugf("using cached blob %s", l.digest)
+		return rc, nil
+	}
+
+	rc, err := l.Layer.Uncompressed()
+	if err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Dir(l.cache.path(l.digest))
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		log.Debugf("unable to create blob cache directory %q: %v", dir, err)
+		return rc, nil
+	}
+
+	tmp, err := os.CreateTemp(dir, ".blob-*")
+	if err != nil {
+		log.Debugf("unable to create blob cache file in %q: %v", dir, err)
+		return rc, nil
+	}
+
+	hasher := sha256.New()
+	return &cachingReader{
+		Reader: io.TeeReader(rc, io.MultiWriter(tmp, hasher)),
+		source: rc,
+		tmp:    tmp,
+		hasher: hasher,
+		path:   l.cache.path(l.digest),
+		digest: l.digest,
+	}, nil
+}
+
+// cachedBlob is the layer of a cached blob. The blob is the content fetched by its digest, so
+// its compressed and uncompressed content are the same.
+type cachedBlob struct {
+	cache  *blobCache
+	digest v1.Hash
+	size   int64
+}
+
+func (b *cachedBlob) Digest() (v1.Hash, error) {
+	return b.digest, nil
+}
+
+func (b *cachedBlob) DiffID() (v1.Hash, error) {
+	return b.digest, nil
+}
+
+func (b *cachedBlob) Compressed() (io.ReadCloser, error) {
+	return b.Uncompressed()
+}
+
+func (b *cachedBlob) Uncompressed() (io.ReadCloser, error) {
+	rc, ok := b.cache.get(b.digest)
+	if !ok {
+		return nil, fmt.Errorf("cached blob %s is no longer available", b.digest)
+	}
+
+	return rc, nil
+}
+
+func (b *cachedBlob) Size() (int64, error) {
+	return b.size, nil
+}
+
+func (b *cachedBlob) MediaType() (types.MediaType, error) {
+	return types.OCIUncompressedLayer, nil
+}
+
+// cachingReader writes the content read to a temporary file. Once the content is read in
+// full, or the reader is closed, the file is moved into the cache if the content read matches
+// the digest, e.g. it was not truncated by the reader, and removed otherwise.
+type cachingReader struct {
+	io.Reader
+	source io.Closer
+	tmp    *os.File
+	hasher hash.Hash
+	path   string
+	digest v1.Hash
+	done   bool
+}
+
+func (r *cachingReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	if err == io.EOF {
+		r.commit()
+	}
+
+	return n, err
+}
+
+func (r *cachingReader) Close() error {
+	r.commit()
+	return r.source.Close()
+}
+
+func (r *cachingReader) commit() {
+	if r.done {
+		return
+	}
+	r.done = true
+
+	if err := r.tmp.Close(); err != nil || fmt.Sprintf("%x", r.hasher.Sum(nil)) != r.digest.Hex {
+		_ = os.Remove(r.tmp.Name())
+		return
+	}
+
+	if err := os.Rename(r.tmp.Name(), r.path); err != nil {
+		log.Debugf("unable to add blob %s to the cache: %v", r.digest, err)
+		_ = os.Remove(r.tmp.Name())
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unit
+
+package oci
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlobCache(t *testing.T) {
+	content := []byte(`{"spam": "maps"}`)
+	layer := static.NewLayer(content, types.OCIUncompressedLayer)
+	digest, err := layer.Digest()
+	require.NoError(t, err)
+	ref, err := name.NewDigest("registry.local/spam@" + digest.String())
+	require.NoError(t, err)
+
+	read := func(c *blobCache, limit int64) []byte {
+		rc, err := c.layer(ref, layer).Uncompressed()
+		require.NoError(t, err)
+		data, err := io.ReadAll(io.LimitReader(rc, limit))
+		require.NoError(t, err)
+		require.NoError(t, rc.Close())
+		return data
+	}
+
+	cached := func(c *blobCache) bool {
+		_, err := os.Stat(filepath.Join(c.dir, "sha256", digest.Hex))
+		return err == nil
+	}
+
+	t.Run("cached once read", func(t *testing.T) {
+		c := &blobCache{dir: t.TempDir()}
+		assert.Equal(t, content, read(c, 1024))
+		assert.True(t, cached(c))
+		assert.Equal(t, content, read(c, 1024))
+	})
+
+	t.Run("truncated not cached", func(t *testing.T) {
+		c := &blobCache{dir: t.TempDir()}
+		assert.Equal(t, content[:5], read(c, 5))
+		assert.False(t, cached(c))
+
+		entries, err := os.ReadDir(filepath.Join(c.dir, "sha256"))
+		require.NoError(t, err)
+		assert.Empty(t, entries)
+	})
+
+	t.Run("corrupted removed", func(t *testing.T) {
+		c := &blobCache{dir: t.TempDir()}
+		require.NoError(t, os.MkdirAll(filepath.Join(c.dir, "sha256"), 0700))
+		require.NoError(t, os.WriteFile(filepath.Join(c.dir, "sha256", digest.Hex), []byte("bogus"), 0600))
+
+		_, ok := c.get(digest)
+		assert.False(t, ok)
+		assert.False(t, cached(c))
+
+		assert.Equal(t, content, read(c, 1024))
+		assert.True(t, cached(c))
+	})
+
+	t.Run("served from cache", func(t *testing.T) {
+		c := &blobCache{dir: t.TempDir()}
+		_, ok := c.cached(ref)
+		assert.False(t, ok)
+
+		read(c, 1024)
+
+		l, ok := c.cached(ref)
+		require.True(t, ok)
+		d, err := l.Digest()
+		require.NoError(t, err)
+		assert.Equal(t, digest, d)
+		size, err := l.Size()
+		require.NoError(t, err)
+		assert.Equal(t, int64(len(content)), size)
+
+		rc, err := l.Uncompressed()
+		require.NoError(t, err)
+		data, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		require.NoError(t, rc.Close())
+		assert.Equal(t, content, data)
+	})
+
+	t.Run("pruned when unused", func(t *testing.T) {
+		c := &blobCache{dir: t.TempDir()}
+		read(c, 1024)
+
+		c.prune()
+		assert.True(t, cached(c))
+
+		old := time.Now().Add(-blobMaxAge - time.Hour)
+		require.NoError(t, os.Chtimes(filepath.Join(c.dir, "sha256", digest.Hex), old, old))
+		c.prune()
+		assert.False(t, cached(c))
+	})
+}
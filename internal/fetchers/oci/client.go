@@ -27,6 +27,8 @@ import (
 	v1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/google/go-containerregistry/pkg/v1/cache"
 	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/sigstore/cosign/v2/pkg/cosign"
+	"github.com/sigstore/cosign/v2/pkg/oci"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -36,6 +38,8 @@ const clientKey key = "ec.fetcher.config.client"
 
 var imgCache cache.Cache
 
+var blobs *blobCache
+
 func init() {
 	initCache()
 }
@@ -55,12 +59,17 @@ func initCache() {
 		}
 		log.Debugf("using %q directory to store image cache", imgCacheDir)
 		imgCache = cache.NewFilesystemCache(imgCacheDir)
+		blobs = &blobCache{dir: path.Join(imgCacheDir, "blobs")}
+		blobs.prune()
 	}
 }
 
 type client interface {
 	Image(name.Reference, ...remote.Option) (v1.Image, error)
 	Layer(name.Digest, ...remote.Option) (v1.Layer, error)
+	Index(name.Reference, ...remote.Option) (v1.ImageIndex, error)
+	VerifyImageSignatures(context.Context, name.Reference, *cosign.CheckOpts) ([]oci.Signature, bool, error)
+	VerifyImageAttestations(context.Context, name.Reference, *cosign.CheckOpts) ([]oci.Signature, bool, error)
 }
 
 var defaultClient = remoteClient{}
@@ -95,11 +104,41 @@ func (*remoteClient) Image(ref name.Reference, opts ...remote.Option) (v1.Image,
 }
 
 func (*remoteClient) Layer(ref name.Digest, options ...remote.Option) (v1.Layer, error) {
-	// TODO: Caching a layer directly is difficult and may not be possible, see:
-	//   https://github.com/google/go-containerregistry/issues/1821
+	// remote.Layer contacts the registry, cached blobs are served without it
+	if blobs != nil {
+		if layer, ok := blobs.cached(ref); ok {
+			return layer, nil
+		}
+	}
+
 	layer, err := remote.Layer(ref, options...)
 	if err != nil {
 		return nil, fmt.Errorf("fetching layer: %w", err)
 	}
+
+	// Caching a layer directly is difficult and may not be possible, see:
+	//   https://github.com/google/go-containerregistry/issues/1821
+	// Instead, the uncompressed content of layers referenced by the digest of that content,
+	// i.e. blobs, is cached.
+	if blobs != nil {
+		layer = blobs.layer(ref, layer)
+	}
+
 	return layer, nil
 }
+
+func (*remoteClient) Index(ref name.Reference, opts ...remote.Option) (v1.ImageIndex, error) {
+	index, err := remote.Index(ref, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("fetching index: %w", err)
+	}
+	return index, nil
+}
+
+func (*remoteClient) VerifyImageSignatures(ctx context.Context, ref name.Reference, opts *cosign.CheckOpts) ([]oci.Signature, bool, error) {
+	return cosign.VerifyImageSignatures(ctx, ref, opts)
+}
+
+func (*remoteClient) VerifyImageAttestations(ctx context.Context, ref name.Reference, opts *cosign.CheckOpts) ([]oci.Signature, bool, error) {
+	return cosign.VerifyImageAttestations(ctx, ref, opts)
+}
@@ -32,6 +32,7 @@ import (
 	"github.com/google/go-containerregistry/pkg/registry"
 	"github.com/google/go-containerregistry/pkg/v1/random"
 	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/static"
 	"github.com/google/go-containerregistry/pkg/v1/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -41,15 +42,20 @@ func TestCacheInit(t *testing.T) {
 	// by default the cache should be on
 	assert.NotNil(t, imgCache)
 
+	assert.NotNil(t, blobs)
+
 	t.Setenv("EC_CACHE", "false")
 	imgCache = nil
+	blobs = nil
 	initCache()
 	assert.Nil(t, imgCache)
+	assert.Nil(t, blobs)
 
 	t.Cleanup(func() {
 		t.Setenv("EC_CACHE", "true")
 		initCache()
 		assert.NotNil(t, imgCache)
+		assert.NotNil(t, blobs)
 	})
 }
 
@@ -91,8 +97,12 @@ func TestImage(t *testing.T) {
 }
 
 func TestLayer(t *testing.T) {
-	layer, err := random.Layer(1024, types.OCIUncompressedLayer)
-	require.NoError(t, err)
+	previous := blobs
+	blobs = &blobCache{dir: t.TempDir()}
+	t.Cleanup(func() { blobs = previous })
+
+	// The uncompressed content of the layer needs to match its digest for it to be cached
+	layer := static.NewLayer([]byte(`{"spam": "maps"}`), types.OCIUncompressedLayer)
 
 	l := &bytes.Buffer{}
 	registry := httptest.NewServer(registry.New(registry.Logger(log.New(l, "", 0))))
@@ -125,5 +135,5 @@ func TestLayer(t *testing.T) {
 
 	msg := fmt.Sprintf("GET /v2/repository/image/blobs/%s", digest)
 	blobDownloadCount := strings.Count(l.String(), msg)
-	assert.Equal(t, fetchCount, blobDownloadCount)
+	assert.Equal(t, 1, blobDownloadCount) // fetched only once, then read from the blob cache
 }
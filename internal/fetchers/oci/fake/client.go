@@ -28,6 +28,8 @@ import (
 	"github.com/google/go-containerregistry/pkg/v1/empty"
 	"github.com/google/go-containerregistry/pkg/v1/mutate"
 	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/sigstore/cosign/v2/pkg/cosign"
+	cosignOCI "github.com/sigstore/cosign/v2/pkg/oci"
 	"github.com/stretchr/testify/mock"
 
 	"github.com/enterprise-contract/ec-cli/internal/fetchers/oci"
@@ -97,3 +99,30 @@ func (m *FakeClient) Layer(ref name.Digest, opts ...remote.Option) (v1.Layer, er
 	}
 	return layer, args.Error(1)
 }
+
+func (m *FakeClient) Index(ref name.Reference, opts ...remote.Option) (v1.ImageIndex, error) {
+	args := m.Called(ref, opts)
+	var index v1.ImageIndex
+	if maybeIndex, ok := args.Get(0).(v1.ImageIndex); ok {
+		index = maybeIndex
+	}
+	return index, args.Error(1)
+}
+
+func (m *FakeClient) VerifyImageSignatures(ctx context.Context, ref name.Reference, opts *cosign.CheckOpts) ([]cosignOCI.Signature, bool, error) {
+	args := m.Called(ctx, ref, opts)
+	var signatures []cosignOCI.Signature
+	if maybeSignatures, ok := args.Get(0).([]cosignOCI.Signature); ok {
+		signatures = maybeSignatures
+	}
+	return signatures, args.Bool(1), args.Error(2)
+}
+
+func (m *FakeClient) VerifyImageAttestations(ctx context.Context, ref name.Reference, opts *cosign.CheckOpts) ([]cosignOCI.Signature, bool, error) {
+	args := m.Called(ctx, ref, opts)
+	var attestations []cosignOCI.Signature
+	if maybeAttestations, ok := args.Get(0).([]cosignOCI.Signature); ok {
+		attestations = maybeAttestations
+	}
+	return attestations, args.Bool(1), args.Error(2)
+}
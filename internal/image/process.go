@@ -26,6 +26,8 @@ import (
 	v1 "github.com/google/go-containerregistry/pkg/v1"
 	"github.com/google/go-containerregistry/pkg/v1/remote"
 	"github.com/hashicorp/go-multierror"
+
+	"github.com/enterprise-contract/ec-cli/internal/fetchers/oci"
 )
 
 type key string
@@ -71,6 +73,26 @@ func ParseAndResolveAll(ctx context.Context, urls []string, opts ...name.Option)
 	return refs, nil
 }
 
+// ImageLabels returns the labels from the config of the image at the given url.
+func ImageLabels(ctx context.Context, url string) (map[string]string, error) {
+	ref, err := name.ParseReference(url)
+	if err != nil {
+		return nil, err
+	}
+
+	img, err := oci.NewClient(ctx).Image(ref, remote.WithContext(ctx), remote.WithAuthFromKeychain(authn.DefaultKeychain))
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := img.ConfigFile()
+	if err != nil {
+		return nil, err
+	}
+
+	return config.Config.Labels, nil
+}
+
 type ImageReference struct {
 	Repository string
 	Digest     string
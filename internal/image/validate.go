@@ -120,6 +120,9 @@ func ValidateImage(ctx context.Context, comp app.SnapshotComponent, p policy.Pol
 		out.Data = append(out.Data, data)
 	}
 
+	// rules can depend on rules of other source groups
+	evaluator.TrimDependents(allResults)
+
 	out.PolicyInput = inputJSON
 
 	log.Debug("Conftest policy check complete")
@@ -34,7 +34,10 @@ import (
 
 type contextKey string
 
-const clientContextKey contextKey = "ec.kubernetes.client"
+const (
+	clientContextKey        contextKey = "ec.kubernetes.client"
+	dynamicClientContextKey contextKey = "ec.kubernetes.dynamic-client"
+)
 
 type Client interface {
 	FetchEnterpriseContractPolicy(ctx context.Context, ref string) (*ecc.EnterpriseContractPolicy, error)
@@ -73,6 +76,28 @@ func NewClient(ctx context.Context) (Client, error) {
 	}, nil
 }
 
+func WithDynamicClient(ctx context.Context, client dynamic.Interface) context.Context {
+	return context.WithValue(ctx, dynamicClientContextKey, client)
+}
+
+// NewDynamicClient constructs a dynamic client for the cluster of the Kubernetes config,
+// given by the --kubeconfig flag or found in the default locations.
+func NewDynamicClient(ctx context.Context) (dynamic.Interface, error) {
+	client, ok := ctx.Value(dynamicClientContextKey).(dynamic.Interface)
+	if ok && client != nil {
+		return client, nil
+	}
+
+	return createK8SClient()
+}
+
+// NewClientFor constructs a new kubernetes client using the given dynamic client
+func NewClientFor(client dynamic.Interface) Client {
+	return &kubernetesClient{
+		client: client,
+	}
+}
+
 func createK8SClient() (client dynamic.Interface, err error) {
 	rules := clientcmd.NewDefaultClientConfigLoadingRules()
 	if kubeconfig != "" {
@@ -213,3 +213,19 @@ func Test_FetchSnapshot(t *testing.T) {
 		})
 	}
 }
+
+func Test_NewDynamicClient(t *testing.T) {
+	got, err := NewDynamicClient(WithDynamicClient(context.Background(), fakeClient))
+	assert.NoError(t, err)
+	assert.Same(t, fakeClient, got)
+
+	t.Setenv("KUBECONFIG", "/nonexistant")
+	_, err = NewDynamicClient(context.Background())
+	assert.EqualError(t, err, "invalid configuration: no configuration has been provided, try setting KUBERNETES_MASTER environment variable")
+}
+
+func Test_NewClientFor(t *testing.T) {
+	got, err := NewClientFor(fakeClient).FetchSnapshot(context.Background(), "test/snapshot")
+	assert.NoError(t, err)
+	assert.Equal(t, testSnapshot, *got)
+}
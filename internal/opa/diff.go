@@ -0,0 +1,322 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package opa
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/open-policy-agent/opa/ast"
+	"golang.org/x/exp/slices"
+
+	"github.com/enterprise-contract/ec-cli/internal/opa/rule"
+)
+
+// FieldChange is the change of a single annotation of a rule.
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   any    `json:"old"`
+	New   any    `json:"new"`
+}
+
+// RuleChange describes a rule that was added, removed, renamed or changed between two
+// versions of the policy sources.
+type RuleChange struct {
+	Code string        `json:"code"`
+	Kind rule.RuleKind `json:"kind"`
+	// OldCode is the code of the rule in the old sources, set only for renamed rules.
+	OldCode string        `json:"oldCode,omitempty"`
+	Title   string        `json:"title,omitempty"`
+	Changes []FieldChange `json:"changes,omitempty"`
+	// Breaking is set if the change could make the validation of images start failing, the
+	// reasons explain why.
+	Breaking bool     `json:"breaking"`
+	Reasons  []string `json:"reasons,omitempty"`
+}
+
+// RulesDiff holds the differences between the deny and warn rules of two versions of the
+// policy sources, each sorted by code.
+type RulesDiff struct {
+	Added   []RuleChange `json:"added"`
+	Removed []RuleChange `json:"removed"`
+	Renamed []RuleChange `json:"renamed"`
+	Changed []RuleChange `json:"changed"`
+}
+
+// Breaking returns the number of changes that could make the validation of images start
+// failing.
+func (d RulesDiff) Breaking() int {
+	breaking := 0
+	for _, changes := range [][]RuleChange{d.Added, d.Removed, d.Renamed, d.Changed} {
+		for _, c := range changes {
+			if c.Breaking {
+				breaking++
+			}
+		}
+	}
+
+	return breaking
+}
+
+// rulesByCode collects the deny and warn rules from all sources by their code. If a code is
+// found in more than one source the rule found first, in sorted order of the sources, is used.
+func rulesByCode(allData map[string][]*ast.AnnotationsRef) map[string]rule.Info {
+	sources := make([]string, 0, len(allData))
+	for src := range allData {
+		sources = append(sources, src)
+	}
+	sort.Strings(sources)
+
+	rules := map[string]rule.Info{}
+	for _, src := range sources {
+		for _, a := range allData[src] {
+			if a.Annotations == nil || a.Annotations.Scope != "rule" {
+				continue
+			}
+
+			info := rule.RuleInfo(a)
+			if info.Kind != rule.Deny && info.Kind != rule.Warn {
+				continue
+			}
+
+			if _, ok := rules[info.Code]; !ok {
+				rules[info.Code] = info
+			}
+		}
+	}
+
+	return rules
+}
+
+// DiffRules compares the deny and warn rules of the old and the new policy sources by their
+// code. A removed and an added rule in the same package with the same title are reported as
+// a renamed rule.
+func DiffRules(oldData, newData map[string][]*ast.AnnotationsRef) RulesDiff {
+	oldRules := rulesByCode(oldData)
+	newRules := rulesByCode(newData)
+
+	var removed, added []rule.Info
+	diff := RulesDiff{
+		Added:   []RuleChange{},
+		Removed: []RuleChange{},
+		Renamed: []RuleChange{},
+		Changed: []RuleChange{},
+	}
+	for _, code := range sortedCodes(oldRules) {
+		o := oldRules[code]
+		if n, ok := newRules[code]; ok {
+			if c := changed(o, n); len(c.Changes) > 0 {
+				diff.Changed = append(diff.Changed, c)
+			}
+		} else {
+			removed = append(removed, o)
+		}
+	}
+
+	for _, code := range sortedCodes(newRules) {
+		if _, ok := oldRules[code]; !ok {
+			added = append(added, newRules[code])
+		}
+	}
+
+	for _, o := range removed {
+		i := slices.IndexFunc(added, func(n rule.Info) bool {
+			return n.Package == o.Package && n.Title != "" && n.Title == o.Title
+		})
+		if i == -1 {
+			diff.Removed = append(diff.Removed, RuleChange{Code: o.Code, Kind: o.Kind, Title: o.Title})
+			continue
+		}
+
+		c := changed(o, added[i])
+		c.OldCode = o.Code
+		c.Breaking = true
+		c.Reasons = append([]string{fmt.Sprintf("the rule was renamed, excludes and includes of %s no longer apply to it", o.Code)}, c.Reasons...)
+		diff.Renamed = append(diff.Renamed, c)
+		added = slices.Delete(added, i, i+1)
+	}
+
+	for _, n := range added {
+		c := RuleChange{Code: n.Code, Kind: n.Kind, Title: n.Title}
+		if n.Kind == rule.Deny {
+			c.Breaking = true
+			c.Reasons = []string{"new deny rule"}
+		}
+		diff.Added = append(diff.Added, c)
+	}
+
+	sort.Slice(diff.Renamed, func(i, j int) bool {
+		return diff.Renamed[i].Code < diff.Renamed[j].Code
+	})
+
+	return diff
+}
+
+func sortedCodes(rules map[string]rule.Info) []string {
+	codes := make([]string, 0, len(rules))
+	for code := range rules {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	return codes
+}
+
+// changed compares the annotations of the same rule in the old and the new sources.
+func changed(o, n rule.Info) RuleChange {
+	c := RuleChange{Code: n.Code, Kind: n.Kind, Title: n.Title}
+
+	if o.Kind != n.Kind {
+		c.Changes = append(c.Changes, FieldChange{Field: "kind", Old: o.Kind, New: n.Kind})
+		if n.Kind == rule.Deny {
+			c.Breaking = true
+			c.Reasons = append(c.Reasons, "the rule was changed from a warning to a violation")
+		}
+	}
+
+	if o.EffectiveOn != n.EffectiveOn {
+		c.Changes = append(c.Changes, FieldChange{Field: "effective_on", Old: o.EffectiveOn, New: n.EffectiveOn})
+		if n.Kind == rule.Deny && effectiveEarlier(o.EffectiveOn, n.EffectiveOn) {
+			c.Breaking = true
+			c.Reasons = append(c.Reasons, "the rule is effective earlier")
+		}
+	}
+
+	if !slices.Equal(o.Collections, n.Collections) {
+		c.Changes = append(c.Changes, FieldChange{Field: "collections", Old: o.Collections, New: n.Collections})
+		var joined []string
+		for _, col := range n.Collections {
+			if !slices.Contains(o.Collections, col) {
+				joined = append(joined, col)
+			}
+		}
+		if n.Kind == rule.Deny && len(joined) > 0 {
+			c.Breaking = true
+			c.Reasons = append(c.Reasons, fmt.Sprintf("the rule was added to the %s collections", strings.Join(joined, ", ")))
+		}
+	}
+
+	if !slices.Equal(o.DependsOn, n.DependsOn) {
+		c.Changes = append(c.Changes, FieldChange{Field: "depends_on", Old: o.DependsOn, New: n.DependsOn})
+	}
+
+	if o.FailureMsg != n.FailureMsg {
+		c.Changes = append(c.Changes, FieldChange{Field: "failure_msg", Old: o.FailureMsg, New: n.FailureMsg})
+	}
+
+	if o.Title != n.Title {
+		c.Changes = append(c.Changes, FieldChange{Field: "title", Old: o.Title, New: n.Title})
+	}
+
+	if o.Description != n.Description {
+		c.Changes = append(c.Changes, FieldChange{Field: "description", Old: o.Description, New: n.Description})
+	}
+
+	if o.Solution != n.Solution {
+		c.Changes = append(c.Changes, FieldChange{Field: "solution", Old: o.Solution, New: n.Solution})
+	}
+
+	return c
+}
+
+// effectiveEarlier returns true if the rule is effective sooner with the new effective_on
+// value than with the old one. A missing value means the rule is always effective.
+func effectiveEarlier(oldValue, newValue string) bool {
+	if newValue == "" {
+		return true
+	}
+
+	if oldValue == "" {
+		return false
+	}
+
+	o, err := time.Parse(time.RFC3339, oldValue)
+	if err != nil {
+		return true
+	}
+
+	n, err := time.Parse(time.RFC3339, newValue)
+	if err != nil {
+		return true
+	}
+
+	return n.Before(o)
+}
+
+// OutputDiff writes the differences between the rules in text, breaking changes are marked
+// with an exclamation mark.
+func OutputDiff(out io.Writer, diff RulesDiff) error {
+	var b strings.Builder
+	section := func(title string, changes []RuleChange, line func(RuleChange) string) {
+		if len(changes) == 0 {
+			return
+		}
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "%s:\n", title)
+		for _, c := range changes {
+			mark := " "
+			if c.Breaking {
+				mark = "!"
+			}
+			fmt.Fprintf(&b, "%s %s\n", mark, line(c))
+			for _, f := range c.Changes {
+				fmt.Fprintf(&b, "    %s: %s -> %s\n", f.Field, diffValue(f.Old), diffValue(f.New))
+			}
+			for _, r := range c.Reasons {
+				fmt.Fprintf(&b, "    ! %s\n", r)
+			}
+		}
+	}
+
+	describe := func(c RuleChange) string {
+		if c.Title == "" {
+			return fmt.Sprintf("%s (%s)", c.Code, c.Kind)
+		}
+		return fmt.Sprintf("%s (%s): %s", c.Code, c.Kind, c.Title)
+	}
+
+	section("Added", diff.Added, describe)
+	section("Removed", diff.Removed, describe)
+	section("Renamed", diff.Renamed, func(c RuleChange) string {
+		return fmt.Sprintf("%s -> %s", c.OldCode, describe(c))
+	})
+	section("Changed", diff.Changed, describe)
+
+	if b.Len() == 0 {
+		b.WriteString("No changes\n")
+	} else {
+		fmt.Fprintf(&b, "\n%d added, %d removed, %d renamed, %d changed, %d breaking\n",
+			len(diff.Added), len(diff.Removed), len(diff.Renamed), len(diff.Changed), diff.Breaking())
+	}
+
+	_, err := io.WriteString(out, b.String())
+	return err
+}
+
+func diffValue(v any) string {
+	switch v := v.(type) {
+	case []string:
+		return fmt.Sprintf("[%s]", strings.Join(v, ", "))
+	default:
+		return fmt.Sprintf("%q", fmt.Sprint(v))
+	}
+}
@@ -0,0 +1,247 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unit
+
+package opa
+
+import (
+	"bytes"
+	"testing"
+
+	hd "github.com/MakeNowJust/heredoc"
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/enterprise-contract/ec-cli/internal/opa/rule"
+)
+
+func diffAnnotations(t *testing.T, module string) map[string][]*ast.AnnotationsRef {
+	t.Helper()
+
+	refs, err := inspectMultiple([]string{"release/labels.rego"}, []string{module})
+	require.NoError(t, err)
+
+	return map[string][]*ast.AnnotationsRef{"source": refs}
+}
+
+var diffOld = hd.Doc(`
+	package policy.release.labels
+
+	import future.keywords.contains
+	import future.keywords.if
+
+	# METADATA
+	# title: Required labels
+	# custom:
+	#   short_name: required
+	#   failure_msg: The %q label is missing
+	#   collections:
+	#   - minimal
+	deny contains result if {
+		false
+	}
+
+	# METADATA
+	# title: Deprecated labels
+	# custom:
+	#   short_name: deprecated
+	warn contains result if {
+		false
+	}
+
+	# METADATA
+	# title: Optional labels
+	# custom:
+	#   short_name: optional
+	warn contains result if {
+		false
+	}
+
+	# METADATA
+	# title: Label format
+	# custom:
+	#   short_name: format
+	#   effective_on: 2024-06-01T00:00:00Z
+	deny contains result if {
+		false
+	}
+`)
+
+var diffNew = hd.Doc(`
+	package policy.release.labels
+
+	import future.keywords.contains
+	import future.keywords.if
+
+	# METADATA
+	# title: Required labels
+	# custom:
+	#   short_name: required
+	#   failure_msg: The label %q is required
+	#   collections:
+	#   - minimal
+	#   - redhat
+	#   depends_on:
+	#   - labels.format
+	deny contains result if {
+		false
+	}
+
+	# METADATA
+	# title: Deprecated labels
+	# custom:
+	#   short_name: deprecated
+	deny contains result if {
+		false
+	}
+
+	# METADATA
+	# title: Label format
+	# custom:
+	#   short_name: label_format
+	#   effective_on: 2024-01-01T00:00:00Z
+	deny contains result if {
+		false
+	}
+
+	# METADATA
+	# title: Label values
+	# custom:
+	#   short_name: values
+	deny contains result if {
+		false
+	}
+`)
+
+func TestDiffRules(t *testing.T) {
+	diff := DiffRules(diffAnnotations(t, diffOld), diffAnnotations(t, diffNew))
+
+	assert.Equal(t, RulesDiff{
+		Added: []RuleChange{
+			{Code: "labels.values", Kind: "deny", Title: "Label values", Breaking: true, Reasons: []string{"new deny rule"}},
+		},
+		Removed: []RuleChange{
+			{Code: "labels.optional", Kind: "warn", Title: "Optional labels"},
+		},
+		Renamed: []RuleChange{
+			{
+				Code:    "labels.label_format",
+				Kind:    "deny",
+				OldCode: "labels.format",
+				Title:   "Label format",
+				Changes: []FieldChange{
+					{Field: "effective_on", Old: "2024-06-01T00:00:00Z", New: "2024-01-01T00:00:00Z"},
+				},
+				Breaking: true,
+				Reasons: []string{
+					"the rule was renamed, excludes and includes of labels.format no longer apply to it",
+					"the rule is effective earlier",
+				},
+			},
+		},
+		Changed: []RuleChange{
+			{
+				Code:  "labels.deprecated",
+				Kind:  "deny",
+				Title: "Deprecated labels",
+				Changes: []FieldChange{
+					{Field: "kind", Old: rule.Warn, New: rule.Deny},
+				},
+				Breaking: true,
+				Reasons:  []string{"the rule was changed from a warning to a violation"},
+			},
+			{
+				Code:  "labels.required",
+				Kind:  "deny",
+				Title: "Required labels",
+				Changes: []FieldChange{
+					{Field: "collections", Old: []string{"minimal"}, New: []string{"minimal", "redhat"}},
+					{Field: "depends_on", Old: []string{}, New: []string{"labels.format"}},
+					{Field: "failure_msg", Old: "The %q label is missing", New: "The label %q is required"},
+				},
+				Breaking: true,
+				Reasons:  []string{"the rule was added to the redhat collections"},
+			},
+		},
+	}, diff)
+	assert.Equal(t, 4, diff.Breaking())
+}
+
+func TestDiffRulesNoChanges(t *testing.T) {
+	diff := DiffRules(diffAnnotations(t, diffOld), diffAnnotations(t, diffOld))
+
+	assert.Equal(t, RulesDiff{
+		Added:   []RuleChange{},
+		Removed: []RuleChange{},
+		Renamed: []RuleChange{},
+		Changed: []RuleChange{},
+	}, diff)
+
+	var out bytes.Buffer
+	require.NoError(t, OutputDiff(&out, diff))
+	assert.Equal(t, "No changes\n", out.String())
+}
+
+func TestOutputDiff(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, OutputDiff(&out, DiffRules(diffAnnotations(t, diffOld), diffAnnotations(t, diffNew))))
+
+	assert.Equal(t, hd.Doc(`
+		Added:
+		! labels.values (deny): Label values
+		    ! new deny rule
+
+		Removed:
+		  labels.optional (warn): Optional labels
+
+		Renamed:
+		! labels.format -> labels.label_format (deny): Label format
+		    effective_on: "2024-06-01T00:00:00Z" -> "2024-01-01T00:00:00Z"
+		    ! the rule was renamed, excludes and includes of labels.format no longer apply to it
+		    ! the rule is effective earlier
+
+		Changed:
+		! labels.deprecated (deny): Deprecated labels
+		    kind: "warn" -> "deny"
+		    ! the rule was changed from a warning to a violation
+		! labels.required (deny): Required labels
+		    collections: [minimal] -> [minimal, redhat]
+		    depends_on: [] -> [labels.format]
+		    failure_msg: "The %q label is missing" -> "The label %q is required"
+		    ! the rule was added to the redhat collections
+
+		1 added, 1 removed, 1 renamed, 2 changed, 4 breaking
+	`), out.String())
+}
+
+func TestEffectiveEarlier(t *testing.T) {
+	cases := []struct {
+		old, new string
+		expected bool
+	}{
+		{"2024-01-01T00:00:00Z", "", true},
+		{"", "2024-01-01T00:00:00Z", false},
+		{"2024-06-01T00:00:00Z", "2024-01-01T00:00:00Z", true},
+		{"2024-01-01T00:00:00Z", "2024-06-01T00:00:00Z", false},
+		{"2024-01-01T00:00:00Z", "invalid", true},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.expected, effectiveEarlier(c.old, c.new), "%q -> %q", c.old, c.new)
+	}
+}
@@ -0,0 +1,365 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package opa
+
+import (
+	"fmt"
+	htmlTemplate "html/template"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+
+	hd "github.com/MakeNowJust/heredoc"
+	"github.com/open-policy-agent/opa/ast"
+
+	"github.com/enterprise-contract/ec-cli/internal/opa/rule"
+)
+
+// DocsFormats are the formats of the rule reference documentation.
+var DocsFormats = []string{"markdown", "asciidoc", "html"}
+
+var docsTemplates = map[string]string{
+	"markdown": hd.Doc(`
+		# Rule reference
+		{{- range .Sources }}
+
+		## Source: ` + "`{{ .URL }}`" + `
+		{{- range .Packages }}
+
+		### Package: ` + "`{{ .Name }}`" + `{{ with .Title }} - {{ . }}{{ end }}
+		{{- with .Description }}
+
+		{{ . }}
+		{{- end }}
+		{{- range .Rules }}
+
+		<a id="{{ .Anchor }}"></a>
+		#### {{ or .Title .Code }}
+		{{- with .Description }}
+
+		{{ . }}
+		{{- end }}
+
+		* Code: ` + "`{{ .Code }}`" + `
+		* Kind: {{ .Kind }}
+		{{- with .FailureMsg }}
+		* Failure message: ` + "`{{ . }}`" + `
+		{{- end }}
+		{{- with .Solution }}
+		* Solution: {{ . }}
+		{{- end }}
+		{{- with .EffectiveOn }}
+		* Effective on: {{ . }}
+		{{- end }}
+		{{- with .DependsOn }}
+		* Depends on: {{ range $i, $d := . }}{{ if $i }}, {{ end }}{{ if $d.Anchor }}[` + "`{{ $d.Code }}`" + `](#{{ $d.Anchor }}){{ else }}` + "`{{ $d.Code }}`" + `{{ end }}{{ end }}
+		{{- end }}
+		{{- with .Collections }}
+		* Collections: {{ range $i, $c := . }}{{ if $i }}, {{ end }}[{{ $c.Name }}](#{{ $c.Anchor }}){{ end }}
+		{{- end }}
+		* Location: ` + "`{{ .Location }}`" + `
+		{{- end }}
+		{{- end }}
+		{{- end }}
+		{{- with .Collections }}
+
+		## Collections
+		{{- range . }}
+
+		<a id="{{ .Anchor }}"></a>
+		### {{ .Name }}
+		{{ range .Rules }}
+		* [` + "`{{ .Code }}`" + `](#{{ .Anchor }}){{ with .Title }}: {{ . }}{{ end }}
+		{{- end }}
+		{{- end }}
+		{{- end }}
+	`),
+
+	"asciidoc": hd.Doc(`
+		= Rule reference
+		{{- range .Sources }}
+
+		== Source: ` + "`{{ .URL }}`" + `
+		{{- range .Packages }}
+
+		=== Package: ` + "`{{ .Name }}`" + `{{ with .Title }} - {{ . }}{{ end }}
+		{{- with .Description }}
+
+		{{ . }}
+		{{- end }}
+		{{- range .Rules }}
+
+		[#{{ .Anchor }}]
+		==== {{ or .Title .Code }}
+		{{- with .Description }}
+
+		{{ . }}
+		{{- end }}
+
+		Code:: ` + "`{{ .Code }}`" + `
+		Kind:: {{ .Kind }}
+		{{- with .FailureMsg }}
+		Failure message:: ` + "`{{ . }}`" + `
+		{{- end }}
+		{{- with .Solution }}
+		Solution:: {{ . }}
+		{{- end }}
+		{{- with .EffectiveOn }}
+		Effective on:: {{ . }}
+		{{- end }}
+		{{- with .DependsOn }}
+		Depends on:: {{ range $i, $d := . }}{{ if $i }}, {{ end }}{{ if $d.Anchor }}<<{{ $d.Anchor }},` + "`{{ $d.Code }}`" + `>>{{ else }}` + "`{{ $d.Code }}`" + `{{ end }}{{ end }}
+		{{- end }}
+		{{- with .Collections }}
+		Collections:: {{ range $i, $c := . }}{{ if $i }}, {{ end }}<<{{ $c.Anchor }},{{ $c.Name }}>>{{ end }}
+		{{- end }}
+		Location:: ` + "`{{ .Location }}`" + `
+		{{- end }}
+		{{- end }}
+		{{- end }}
+		{{- with .Collections }}
+
+		== Collections
+		{{- range . }}
+
+		[#{{ .Anchor }}]
+		=== {{ .Name }}
+		{{ range .Rules }}
+		* <<{{ .Anchor }},` + "`{{ .Code }}`" + `>>{{ with .Title }}: {{ . }}{{ end }}
+		{{- end }}
+		{{- end }}
+		{{- end }}
+	`),
+
+	"html": hd.Doc(`
+		<!DOCTYPE html>
+		<html>
+		<head>
+		<meta charset="utf-8">
+		<title>Rule reference</title>
+		</head>
+		<body>
+		<h1>Rule reference</h1>
+		{{- range .Sources }}
+		<h2>Source: <code>{{ .URL }}</code></h2>
+		{{- range .Packages }}
+		<h3>Package: <code>{{ .Name }}</code>{{ with .Title }} - {{ . }}{{ end }}</h3>
+		{{- with .Description }}
+		<p>{{ . }}</p>
+		{{- end }}
+		{{- range .Rules }}
+		<h4 id="{{ .Anchor }}">{{ or .Title .Code }}</h4>
+		{{- with .Description }}
+		<p>{{ . }}</p>
+		{{- end }}
+		<dl>
+		<dt>Code</dt><dd><code>{{ .Code }}</code></dd>
+		<dt>Kind</dt><dd>{{ .Kind }}</dd>
+		{{- with .FailureMsg }}
+		<dt>Failure message</dt><dd><code>{{ . }}</code></dd>
+		{{- end }}
+		{{- with .Solution }}
+		<dt>Solution</dt><dd>{{ . }}</dd>
+		{{- end }}
+		{{- with .EffectiveOn }}
+		<dt>Effective on</dt><dd>{{ . }}</dd>
+		{{- end }}
+		{{- with .DependsOn }}
+		<dt>Depends on</dt><dd>{{ range $i, $d := . }}{{ if $i }}, {{ end }}{{ if $d.Anchor }}<a href="#{{ $d.Anchor }}"><code>{{ $d.Code }}</code></a>{{ else }}<code>{{ $d.Code }}</code>{{ end }}{{ end }}</dd>
+		{{- end }}
+		{{- with .Collections }}
+		<dt>Collections</dt><dd>{{ range $i, $c := . }}{{ if $i }}, {{ end }}<a href="#{{ $c.Anchor }}">{{ $c.Name }}</a>{{ end }}</dd>
+		{{- end }}
+		<dt>Location</dt><dd><code>{{ .Location }}</code></dd>
+		</dl>
+		{{- end }}
+		{{- end }}
+		{{- end }}
+		{{- with .Collections }}
+		<h2>Collections</h2>
+		{{- range . }}
+		<h3 id="{{ .Anchor }}">{{ .Name }}</h3>
+		<ul>
+		{{- range .Rules }}
+		<li><a href="#{{ .Anchor }}"><code>{{ .Code }}</code></a>{{ with .Title }}: {{ . }}{{ end }}</li>
+		{{- end }}
+		</ul>
+		{{- end }}
+		{{- end }}
+		</body>
+		</html>
+	`),
+}
+
+type docsLink struct {
+	Name   string
+	Code   string
+	Anchor string
+}
+
+type docsRule struct {
+	rule.Info
+	Anchor string
+	// Location is the file, relative to the policy source, and the line of the rule.
+	Location    string
+	DependsOn   []docsLink
+	Collections []docsLink
+}
+
+type docsPackage struct {
+	Name        string
+	Title       string
+	Description string
+	Rules       []*docsRule
+}
+
+type docsSource struct {
+	URL      string
+	Packages []*docsPackage
+}
+
+type docsCollection struct {
+	Name   string
+	Anchor string
+	Rules  []*docsRule
+}
+
+type docs struct {
+	Sources     []*docsSource
+	Collections []*docsCollection
+}
+
+var anchorInvalidCharacters = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+func anchor(prefix, name string) string {
+	return prefix + "-" + anchorInvalidCharacters.ReplaceAllString(name, "-")
+}
+
+// OutputDocs writes the reference documentation of the deny and warn rules in the given
+// format, one of DocsFormats. The rules of each source are grouped by package, followed by
+// the rules in each collection. Rules are linked by their code, so the anchor of a rule
+// defined in more than one source refers to its first occurrence.
+func OutputDocs(out io.Writer, allData map[string][]*ast.AnnotationsRef, format string) error {
+	text, ok := docsTemplates[format]
+	if !ok {
+		return fmt.Errorf("unknown documentation format %q", format)
+	}
+
+	d := buildDocs(allData)
+
+	if format == "html" {
+		return htmlTemplate.Must(htmlTemplate.New(format).Parse(text)).Execute(out, d)
+	}
+
+	return template.Must(template.New(format).Parse(text)).Execute(out, d)
+}
+
+func buildDocs(allData map[string][]*ast.AnnotationsRef) docs {
+	urls := make([]string, 0, len(allData))
+	for url := range allData {
+		urls = append(urls, url)
+	}
+	sort.Strings(urls)
+
+	var d docs
+	codes := map[string]bool{}
+	collections := map[string]*docsCollection{}
+	for _, url := range urls {
+		src := &docsSource{URL: url}
+		packages := map[string]*docsPackage{}
+		pkg := func(name string) *docsPackage {
+			p, ok := packages[name]
+			if !ok {
+				p = &docsPackage{Name: name}
+				packages[name] = p
+				src.Packages = append(src.Packages, p)
+			}
+			return p
+		}
+
+		for _, a := range allData[url] {
+			if a.Annotations == nil {
+				continue
+			}
+
+			info := rule.RuleInfo(a)
+			switch {
+			case a.Annotations.Scope == "package":
+				// the path of package annotations is the package itself
+				p := pkg(strings.TrimPrefix(a.Path.String(), "data."))
+				p.Title, p.Description = info.Title, info.Description
+			case a.Annotations.Scope == "rule" && (info.Kind == rule.Deny || info.Kind == rule.Warn):
+				r := &docsRule{Info: info, Anchor: anchor("rule", info.Code)}
+				if a.Location != nil {
+					r.Location = fmt.Sprintf("%s:%d", a.Location.File, a.Location.Row)
+				}
+				for _, c := range info.Collections {
+					r.Collections = append(r.Collections, docsLink{Name: c, Anchor: anchor("collection", c)})
+					if _, ok := collections[c]; !ok {
+						collections[c] = &docsCollection{Name: c, Anchor: anchor("collection", c)}
+					}
+					collections[c].Rules = append(collections[c].Rules, r)
+				}
+				codes[info.Code] = true
+				p := pkg(info.Package)
+				p.Rules = append(p.Rules, r)
+			}
+		}
+
+		sort.Slice(src.Packages, func(i, j int) bool {
+			return src.Packages[i].Name < src.Packages[j].Name
+		})
+		for _, p := range src.Packages {
+			sortRules(p.Rules)
+		}
+
+		d.Sources = append(d.Sources, src)
+	}
+
+	// dependencies are linked only if the rule is documented
+	for _, s := range d.Sources {
+		for _, p := range s.Packages {
+			for _, r := range p.Rules {
+				for _, code := range r.Info.DependsOn {
+					link := docsLink{Code: code}
+					if codes[code] {
+						link.Anchor = anchor("rule", code)
+					}
+					r.DependsOn = append(r.DependsOn, link)
+				}
+			}
+		}
+	}
+
+	for _, c := range collections {
+		sortRules(c.Rules)
+		d.Collections = append(d.Collections, c)
+	}
+	sort.Slice(d.Collections, func(i, j int) bool {
+		return d.Collections[i].Name < d.Collections[j].Name
+	})
+
+	return d
+}
+
+func sortRules(rules []*docsRule) {
+	sort.SliceStable(rules, func(i, j int) bool {
+		return rules[i].Code < rules[j].Code
+	})
+}
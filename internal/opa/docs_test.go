@@ -0,0 +1,151 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unit
+
+package opa
+
+import (
+	"bytes"
+	"testing"
+
+	hd "github.com/MakeNowJust/heredoc"
+	"github.com/gkampitakis/go-snaps/snaps"
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func docsAnnotations(t *testing.T) map[string][]*ast.AnnotationsRef {
+	t.Helper()
+
+	release, err := inspectMultiple([]string{"release/labels.rego", "release/image.rego"}, []string{
+		hd.Doc(`
+			# METADATA
+			# title: Labels
+			# description: Checks of the image labels.
+			package policy.release.labels
+
+			import future.keywords.contains
+			import future.keywords.if
+
+			# METADATA
+			# title: Required labels
+			# description: The image has the <required> labels.
+			# custom:
+			#   short_name: required
+			#   failure_msg: The %q label is missing
+			#   solution: Add the label to the image.
+			#   collections:
+			#   - minimal
+			#   - redhat
+			#   depends_on:
+			#   - image.present
+			#   - other.missing
+			deny contains result if {
+				false
+			}
+
+			# METADATA
+			# title: Deprecated labels
+			# description: The image does not have deprecated labels.
+			# custom:
+			#   short_name: deprecated
+			#   effective_on: 2024-01-01T00:00:00Z
+			#   collections:
+			#   - redhat
+			warn contains result if {
+				false
+			}
+
+			# METADATA
+			# title: Not a rule
+			helper := true
+		`),
+		hd.Doc(`
+			package policy.release.image
+
+			import future.keywords.contains
+			import future.keywords.if
+
+			# METADATA
+			# title: Image present
+			# description: The image is present.
+			# custom:
+			#   short_name: present
+			#   collections:
+			#   - minimal
+			deny contains result if {
+				false
+			}
+		`),
+	})
+	require.NoError(t, err)
+
+	return map[string][]*ast.AnnotationsRef{
+		"git::https://example.com/policy.git//policy": release,
+	}
+}
+
+func TestOutputDocs(t *testing.T) {
+	for _, format := range DocsFormats {
+		t.Run(format, func(t *testing.T) {
+			var out bytes.Buffer
+			require.NoError(t, OutputDocs(&out, docsAnnotations(t), format))
+
+			snaps.MatchSnapshot(t, out.String())
+		})
+	}
+}
+
+func TestOutputDocsUnknownFormat(t *testing.T) {
+	var out bytes.Buffer
+	err := OutputDocs(&out, docsAnnotations(t), "pdf")
+	assert.EqualError(t, err, `unknown documentation format "pdf"`)
+	assert.Empty(t, out.String())
+}
+
+func TestBuildDocs(t *testing.T) {
+	d := buildDocs(docsAnnotations(t))
+
+	require.Len(t, d.Sources, 1)
+	pkgs := d.Sources[0].Packages
+	require.Len(t, pkgs, 2)
+
+	assert.Equal(t, "policy.release.image", pkgs[0].Name)
+	assert.Equal(t, "policy.release.labels", pkgs[1].Name)
+	assert.Equal(t, "Labels", pkgs[1].Title)
+	assert.Equal(t, "Checks of the image labels.", pkgs[1].Description)
+
+	rules := pkgs[1].Rules
+	require.Len(t, rules, 2)
+	assert.Equal(t, "labels.deprecated", rules[0].Code)
+	assert.Equal(t, "rule-labels-deprecated", rules[0].Anchor)
+	assert.Equal(t, "release/labels.rego:34", rules[0].Location)
+	assert.Equal(t, "labels.required", rules[1].Code)
+	assert.Equal(t, []docsLink{
+		{Code: "image.present", Anchor: "rule-image-present"},
+		{Code: "other.missing"},
+	}, rules[1].DependsOn)
+
+	require.Len(t, d.Collections, 2)
+	assert.Equal(t, "minimal", d.Collections[0].Name)
+	assert.Equal(t, "collection-minimal", d.Collections[0].Anchor)
+	assert.Len(t, d.Collections[0].Rules, 2)
+	assert.Equal(t, "image.present", d.Collections[0].Rules[0].Code)
+	assert.Equal(t, "redhat", d.Collections[1].Name)
+	assert.Len(t, d.Collections[1].Rules, 2)
+}
@@ -23,11 +23,14 @@ import (
 	"io/fs"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/open-policy-agent/opa/ast"
 	"github.com/open-policy-agent/opa/ast/json"
 	"github.com/spf13/afero"
+
+	"github.com/enterprise-contract/ec-cli/internal/opa/rule"
 )
 
 func inspectSingle(path, module string) ([]*ast.AnnotationsRef, error) {
@@ -55,6 +58,12 @@ func inspectSingle(path, module string) ([]*ast.AnnotationsRef, error) {
 		results = append(results, as.Chain(rule)...)
 	}
 
+	// package annotations, e.g. the conventions of subpackages, can be declared in a module
+	// without rules
+	if len(mod.Rules) == 0 {
+		results = append(results, as.Flatten()...)
+	}
+
 	return results, nil
 }
 
@@ -78,6 +87,12 @@ func inspectMultiple(paths, modules []string) ([]*ast.AnnotationsRef, error) {
 	return results, nil
 }
 
+// IsCheckRule reports whether the rule with the given name is a deny or warn rule, i.e. one
+// whose results are reported when evaluating.
+func IsCheckRule(ruleName string) bool {
+	return isWarning(ruleName) || isFailure(ruleName)
+}
+
 // Borrowed from conftest
 func isWarning(ruleName string) bool {
 	return regexp.MustCompile("^warn(_[a-zA-Z0-9]+)*$").MatchString(ruleName)
@@ -204,6 +219,9 @@ func InspectDir(afs afero.Fs, dir string) ([]*ast.AnnotationsRef, error) {
 		return nil, err
 	}
 
+	// Rules inherit the documentation URL and code prefixes of their packages
+	rule.InheritConventions(allAnnotations)
+
 	// Return only interesting rules
 	result, err := interestingRulesOnly(allAnnotations)
 	if err != nil {
@@ -231,3 +249,85 @@ type wrapperFs struct {
 func (w wrapperFs) Open(name string) (fs.File, error) {
 	return w.afs.Open(name)
 }
+
+// StringLiterals returns the strings used in the rego files found in the given directory,
+// excluding tests. This includes the string components of references, e.g. "key" in
+// data.rule_data.key.
+func StringLiterals(afs afero.Fs, dir string) (map[string]bool, error) {
+	literals := map[string]bool{}
+	// See InspectDir on why fs.WalkDir is used instead of afero.Walk
+	err := fs.WalkDir(wrapperFs{afs: afs}, dir, func(path string, d fs.DirEntry, readErr error) error {
+		if readErr != nil {
+			return readErr
+		}
+
+		pathLower := strings.ToLower(path)
+		if d.IsDir() || filepath.Ext(pathLower) != ".rego" || strings.HasSuffix(filepath.Base(pathLower), "_test.rego") {
+			return nil
+		}
+
+		contents, err := afero.ReadFile(afs, path)
+		if err != nil {
+			return nil
+		}
+
+		mod, err := ast.ParseModule(shortPath(path, dir), string(contents))
+		if err != nil {
+			return err
+		}
+
+		ast.WalkTerms(mod, func(t *ast.Term) bool {
+			if s, ok := t.Value.(ast.String); ok {
+				literals[string(s)] = true
+			}
+			return false
+		})
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return literals, nil
+}
+
+// ParseDirs parses the rego files in the directories, the tests excluded, sorted by path. The
+// annotations of the modules are processed.
+func ParseDirs(afs afero.Fs, dirs []string) ([]*ast.Module, error) {
+	var paths []string
+	for _, dir := range dirs {
+		err := fs.WalkDir(wrapperFs{afs: afs}, dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+
+			name := strings.ToLower(filepath.Base(path))
+			if filepath.Ext(name) == ".rego" && !strings.HasSuffix(name, "_test.rego") {
+				paths = append(paths, path)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(paths)
+
+	modules := make([]*ast.Module, 0, len(paths))
+	for _, path := range paths {
+		contents, err := afero.ReadFile(afs, path)
+		if err != nil {
+			return nil, err
+		}
+
+		m, err := ast.ParseModuleWithOpts(path, string(contents), ast.ParserOptions{ProcessAnnotation: true})
+		if err != nil {
+			return nil, err
+		}
+		modules = append(modules, m)
+	}
+
+	return modules, nil
+}
@@ -30,6 +30,8 @@ import (
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/enterprise-contract/ec-cli/internal/opa/rule"
 )
 
 func Test_InspectMultiple(t *testing.T) {
@@ -160,6 +162,51 @@ func TestInspectDir(t *testing.T) {
 	}
 }
 
+func TestInspectDirConventions(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	files := map[string]string{
+		"policy/org.rego": hd.Doc(`
+			# METADATA
+			# scope: subpackages
+			# custom:
+			#   code_prefixes: [org.policy]
+			#   documentation_url: https://docs.example.com/{{ .CodePackage }}.html#{{ .ShortName }}
+			package org.policy
+		`),
+		"policy/a/a.rego": hd.Doc(`
+			package org.policy.a
+
+			import future.keywords.contains
+			import future.keywords.if
+
+			# METADATA
+			# title: Rule
+			# custom:
+			#   short_name: rule
+			deny contains result if {
+				false
+			}
+		`),
+	}
+	for path, content := range files {
+		require.NoError(t, afero.WriteFile(fs, path, []byte(content), 0644))
+	}
+
+	annotations, err := InspectDir(fs, "policy")
+	require.NoError(t, err)
+
+	var infos []rule.Info
+	for _, a := range annotations {
+		if a.Annotations.Scope == "rule" {
+			infos = append(infos, rule.RuleInfo(a))
+		}
+	}
+
+	require.Len(t, infos, 1)
+	assert.Equal(t, "a.rule", infos[0].Code)
+	assert.Equal(t, "https://docs.example.com/a.html#rule", infos[0].DocumentationUrl)
+}
+
 func TestCheckRules(t *testing.T) {
 	cases := []struct {
 		name string
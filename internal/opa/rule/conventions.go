@@ -0,0 +1,120 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package rule
+
+import (
+	"strings"
+
+	"github.com/open-policy-agent/opa/ast"
+)
+
+const (
+	// DocumentationUrlAnnotation is the custom annotation holding the template of the
+	// documentation URL of a rule, see RenderDocumentationUrl.
+	DocumentationUrlAnnotation = "documentation_url"
+	// CodePrefixesAnnotation is the custom annotation holding the package prefixes removed
+	// from the package of a rule to form its code, see DefaultCodePrefixes.
+	CodePrefixesAnnotation = "code_prefixes"
+)
+
+var conventionAnnotations = []string{DocumentationUrlAnnotation, CodePrefixesAnnotation}
+
+// InheritConventions sets the documentation_url and code_prefixes annotations of the rules
+// not declaring them to the ones declared by the package of the rule, or with the
+// subpackages scope by the package of the rule or its closest ancestor. The codes of the
+// rules need to match the codes the rules report in their results.
+func InheritConventions(refs []*ast.AnnotationsRef) {
+	packageScope := map[string]*ast.Annotations{}
+	subpackagesScope := map[string]*ast.Annotations{}
+	for _, a := range refs {
+		if a == nil || a.Annotations == nil {
+			continue
+		}
+
+		switch a.Annotations.Scope {
+		case "package":
+			packageScope[packageName(a)] = a.Annotations
+		case "subpackages":
+			subpackagesScope[packageName(a)] = a.Annotations
+		}
+	}
+
+	for _, a := range refs {
+		if !isRule(a) {
+			continue
+		}
+
+		packages := packages(a)
+		declaring := []*ast.Annotations{packageScope[strings.Join(packages, ".")]}
+		for i := len(packages); i >= 0; i-- {
+			declaring = append(declaring, subpackagesScope[strings.Join(packages[:i], ".")])
+		}
+
+		for _, key := range conventionAnnotations {
+			for _, d := range declaring {
+				if d == nil {
+					continue
+				}
+				if v, ok := d.Custom[key]; ok {
+					setDefault(a.Annotations, key, v)
+					break
+				}
+			}
+		}
+	}
+}
+
+// DefaultConventions sets the documentation URL template and the code prefixes of the rules
+// not declaring, or inheriting, the documentation_url and code_prefixes annotations. Empty
+// values are not set.
+func DefaultConventions(refs []*ast.AnnotationsRef, documentationUrl string, codePrefixes []string) {
+	if documentationUrl == "" && len(codePrefixes) == 0 {
+		return
+	}
+
+	for _, a := range refs {
+		if !isRule(a) {
+			continue
+		}
+
+		if documentationUrl != "" {
+			setDefault(a.Annotations, DocumentationUrlAnnotation, documentationUrl)
+		}
+
+		if len(codePrefixes) > 0 {
+			prefixes := make([]any, 0, len(codePrefixes))
+			for _, p := range codePrefixes {
+				prefixes = append(prefixes, p)
+			}
+			setDefault(a.Annotations, CodePrefixesAnnotation, prefixes)
+		}
+	}
+}
+
+func isRule(a *ast.AnnotationsRef) bool {
+	return a != nil && a.Annotations != nil && (a.Annotations.Scope == "rule" || a.Annotations.Scope == "document")
+}
+
+func setDefault(a *ast.Annotations, key string, value any) {
+	if a.Custom == nil {
+		a.Custom = map[string]any{}
+	}
+
+	if _, ok := a.Custom[key]; !ok {
+		a.Custom[key] = value
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unit
+
+package rule
+
+import (
+	"testing"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func annotationRefs(t *testing.T, modules ...string) []*ast.AnnotationsRef {
+	parsed := make([]*ast.Module, 0, len(modules))
+	for _, m := range modules {
+		parsed = append(parsed, ast.MustParseModuleWithOpts(m, ast.ParserOptions{ProcessAnnotation: true}))
+	}
+
+	as, errs := ast.BuildAnnotationSet(parsed)
+	require.Empty(t, errs)
+
+	return as.Flatten()
+}
+
+func codesAndUrls(refs []*ast.AnnotationsRef) map[string]string {
+	infos := map[string]string{}
+	for _, a := range refs {
+		if isRule(a) {
+			info := RuleInfo(a)
+			infos[info.Code] = info.DocumentationUrl
+		}
+	}
+
+	return infos
+}
+
+var conventionModules = []string{
+	heredoc.Doc(`
+		# METADATA
+		# scope: subpackages
+		# custom:
+		#   code_prefixes: [org.policy]
+		#   documentation_url: https://docs.example.com/{{ .CodePackage }}#{{ .ShortName }}
+		package org.policy
+	`),
+	heredoc.Doc(`
+		package org.policy.a
+
+		# METADATA
+		# custom:
+		#   short_name: x
+		deny() { true }
+
+		# METADATA
+		# custom:
+		#   short_name: w
+		#   documentation_url: https://w.example.com
+		deny() { true }
+	`),
+	heredoc.Doc(`
+		# METADATA
+		# custom:
+		#   documentation_url: https://b.example.com/{{ .Code }}
+		package org.policy.b
+
+		# METADATA
+		# custom:
+		#   short_name: x
+		deny() { true }
+	`),
+	heredoc.Doc(`
+		package other
+
+		# METADATA
+		# custom:
+		#   short_name: x
+		deny() { true }
+	`),
+}
+
+func TestInheritConventions(t *testing.T) {
+	refs := annotationRefs(t, conventionModules...)
+
+	InheritConventions(refs)
+
+	assert.Equal(t, map[string]string{
+		"a.x":     "https://docs.example.com/a#x",
+		"a.w":     "https://w.example.com",
+		"b.x":     "https://b.example.com/b.x",
+		"other.x": "",
+	}, codesAndUrls(refs))
+}
+
+func TestDefaultConventions(t *testing.T) {
+	refs := annotationRefs(t, conventionModules...)
+
+	InheritConventions(refs)
+	DefaultConventions(refs, "https://default.example.com/{{ .Code }}", []string{"other"})
+
+	assert.Equal(t, map[string]string{
+		"a.x": "https://docs.example.com/a#x",
+		"a.w": "https://w.example.com",
+		"b.x": "https://b.example.com/b.x",
+		"x":   "https://default.example.com/x",
+	}, codesAndUrls(refs))
+}
+
+func TestDefaultConventionsNone(t *testing.T) {
+	refs := annotationRefs(t, conventionModules[3])
+
+	DefaultConventions(refs, "", nil)
+
+	assert.Equal(t, map[string]string{"other.x": ""}, codesAndUrls(refs))
+}
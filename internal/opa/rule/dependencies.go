@@ -0,0 +1,128 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package rule
+
+import (
+	"slices"
+	"sort"
+)
+
+// Cycle holds rules that depend on each other, directly or transitively.
+type Cycle struct {
+	// Codes holds the codes of the rules in the cycle, sorted.
+	Codes []string
+	// Path holds the shortest path of dependencies from the lowest code back to it, e.g.
+	// a.one, a.two, a.one.
+	Path []string
+}
+
+// DependencyCycles finds the cycles in the dependencies of the rules, given as the codes each
+// rule depends on by code. Rules in more than one cycle with each other are in the same Cycle,
+// a rule depending on itself is a cycle of its own. The cycles are returned sorted by their
+// lowest code. Dependencies on unknown rules are ignored.
+func DependencyCycles(dependsOn map[string][]string) []Cycle {
+	codes := make([]string, 0, len(dependsOn))
+	for code := range dependsOn {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	// Tarjan's strongly connected components algorithm
+	index := map[string]int{}
+	lowlink := map[string]int{}
+	onStack := map[string]bool{}
+	var stack []string
+	var cycles []Cycle
+
+	var connect func(code string)
+	connect = func(code string) {
+		index[code] = len(index)
+		lowlink[code] = index[code]
+		stack = append(stack, code)
+		onStack[code] = true
+
+		for _, dep := range dependsOn[code] {
+			if _, visited := index[dep]; !visited {
+				connect(dep)
+				lowlink[code] = min(lowlink[code], lowlink[dep])
+			} else if onStack[dep] {
+				lowlink[code] = min(lowlink[code], index[dep])
+			}
+		}
+
+		if lowlink[code] != index[code] {
+			return
+		}
+
+		var component []string
+		for {
+			c := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[c] = false
+			component = append(component, c)
+			if c == code {
+				break
+			}
+		}
+
+		if len(component) > 1 || slices.Contains(dependsOn[code], code) {
+			sort.Strings(component)
+			cycles = append(cycles, Cycle{Codes: component, Path: cycleThrough(dependsOn, component)})
+		}
+	}
+
+	for _, code := range codes {
+		if _, visited := index[code]; !visited {
+			connect(code)
+		}
+	}
+
+	sort.Slice(cycles, func(i, j int) bool {
+		return cycles[i].Codes[0] < cycles[j].Codes[0]
+	})
+
+	return cycles
+}
+
+// cycleThrough returns the shortest cycle through the lowest code of the component, given with
+// that code repeated at the end.
+func cycleThrough(dependsOn map[string][]string, component []string) []string {
+	start := component[0]
+	previous := map[string]string{}
+	queue := []string{start}
+	for len(queue) > 0 {
+		code := queue[0]
+		queue = queue[1:]
+		for _, dep := range dependsOn[code] {
+			if dep == start {
+				cycle := []string{start}
+				for c := code; c != start; c = previous[c] {
+					cycle = append([]string{c}, cycle...)
+				}
+				return append([]string{start}, cycle...)
+			}
+			if _, seen := previous[dep]; seen || !slices.Contains(component, dep) {
+				continue
+			}
+			previous[dep] = code
+			queue = append(queue, dep)
+		}
+	}
+
+	// not reached, every rule of a component is in a cycle with the others
+	return component
+}
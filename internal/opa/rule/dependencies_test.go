@@ -0,0 +1,48 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unit
+
+package rule
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDependencyCycles(t *testing.T) {
+	cycles := DependencyCycles(map[string][]string{
+		"a.one":   {"a.two"},
+		"a.two":   {"a.three", "a.other", "a.one"},
+		"a.three": {"a.one"},
+		"a.four":  {"a.three"},
+		"a.self":  {"a.self"},
+		"a.other": {"unknown"},
+	})
+
+	assert.Equal(t, []Cycle{
+		{Codes: []string{"a.one", "a.three", "a.two"}, Path: []string{"a.one", "a.two", "a.one"}},
+		{Codes: []string{"a.self"}, Path: []string{"a.self", "a.self"}},
+	}, cycles)
+}
+
+func TestDependencyCyclesNone(t *testing.T) {
+	assert.Empty(t, DependencyCycles(map[string][]string{
+		"a.one": {"a.two"},
+		"a.two": {},
+	}))
+}
@@ -19,7 +19,9 @@ package rule
 import (
 	"fmt"
 	"regexp"
+	"slices"
 	"strings"
+	"text/template"
 
 	"github.com/open-policy-agent/opa/ast"
 )
@@ -62,6 +64,10 @@ func solution(a *ast.AnnotationsRef) string {
 	return xrefRegExp.ReplaceAllString(customAnnotationString(a, "solution"), "$1")
 }
 
+func failureMsg(a *ast.AnnotationsRef) string {
+	return customAnnotationString(a, "failure_msg")
+}
+
 func lastTerm(a *ast.AnnotationsRef) string {
 	if a == nil || len(a.Path) == 0 {
 		return ""
@@ -158,11 +164,35 @@ func packageName(a *ast.AnnotationsRef) string {
 	return strings.Join(packages(a), ".")
 }
 
-var knownRuleCategories = map[string]bool{
-	"release":  true,
-	"pipeline": true,
+// DefaultCodePrefixes are the package prefixes removed from the package of a rule to form
+// the prefix of its code when the rule does not declare the code_prefixes annotation, e.g.
+// the code of the rule "rule" in the package "policy.release.pkg" is "pkg.rule".
+var DefaultCodePrefixes = []string{"policy.release", "policy.pipeline", "policy", "release", "pipeline"}
+
+// codePrefixes returns the package prefixes declared with the code_prefixes annotation, or
+// DefaultCodePrefixes.
+func codePrefixes(a *ast.AnnotationsRef) []string {
+	if a == nil || a.Annotations == nil || a.Annotations.Custom == nil {
+		return DefaultCodePrefixes
+	}
+
+	values, ok := a.Annotations.Custom[CodePrefixesAnnotation].([]any)
+	if !ok {
+		return DefaultCodePrefixes
+	}
+
+	prefixes := make([]string, 0, len(values))
+	for _, v := range values {
+		if prefix, ok := v.(string); ok {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+
+	return prefixes
 }
 
+// codePackage returns the package of the rule without the longest of the code prefixes
+// matching it, by whole package segments.
 func codePackage(a *ast.AnnotationsRef) string {
 	if a == nil {
 		return ""
@@ -170,19 +200,15 @@ func codePackage(a *ast.AnnotationsRef) string {
 
 	packages := packages(a)
 
-	l := len(packages)
-
-	if len(packages) > 0 && packages[0] == "policy" {
-		// remove the policy package
-		packages = packages[1:]
-	}
-
-	if l > 0 && knownRuleCategories[packages[0]] {
-		// remove any known rule categories
-		packages = packages[1:]
+	stripped := 0
+	for _, prefix := range codePrefixes(a) {
+		p := strings.Split(prefix, ".")
+		if len(p) > stripped && len(p) <= len(packages) && slices.Equal(p, packages[:len(p)]) {
+			stripped = len(p)
+		}
 	}
 
-	return strings.Join(packages, ".")
+	return strings.Join(packages[stripped:], ".")
 }
 
 func code(a *ast.AnnotationsRef) string {
@@ -199,20 +225,28 @@ func code(a *ast.AnnotationsRef) string {
 	return fmt.Sprintf("%s.%s", codePackage, shortName(a))
 }
 
-func documentationUrl(a *ast.AnnotationsRef) string {
+// documentationUrl renders the template declared with the documentation_url annotation using
+// the information of the rule. Without the annotation, the URL of the rule in the
+// documentation of the Enterprise Contract policies is returned for rules in packages like
+// policy.release.some_package_name. An empty string is returned if the template cannot be
+// rendered.
+func documentationUrl(a *ast.AnnotationsRef, info Info) string {
 	if a == nil {
 		return ""
 	}
 
-	// Notes:
-	// - This makes the assumption that we're looking at our own EC rules with
-	//   docs in the enterprise-contract github pages. That's not likely to be true
-	//   always. A future improvement for this might include a way to extract a
-	//   docs url from a package annotation instead using the hard-coded url here.
-	// - The length test is because we're expecting pathStrings to be like this:
-	//     data.policy.release.some_package_name.deny
-	//   Avoid errors indexing pathStrings and also try to avoid showing a url
-	//   if it's unlikely to be a real link to existing docs.
+	if tmpl := customAnnotationString(a, DocumentationUrlAnnotation); tmpl != "" {
+		url, err := RenderDocumentationUrl(tmpl, info)
+		if err != nil {
+			return ""
+		}
+		return url
+	}
+
+	// The length test is because we're expecting pathStrings to be like this:
+	//   data.policy.release.some_package_name.deny
+	// Avoid errors indexing pathStrings and also try to avoid showing a url
+	// if it's unlikely to be a real link to existing docs.
 	ruleDocUrlFormat := "https://enterprisecontract.dev/docs/ec-policies/%s_policy.html#%s__%s"
 	pathStrings := strings.Split(a.Path.String(), ".")
 	shortName := shortName(a)
@@ -223,6 +257,22 @@ func documentationUrl(a *ast.AnnotationsRef) string {
 	return ""
 }
 
+// RenderDocumentationUrl renders the documentation URL template, a text/template, with the
+// information of the rule, e.g. https://docs.example.com/{{ .CodePackage }}.html#{{ .ShortName }}.
+func RenderDocumentationUrl(tmpl string, info Info) (string, error) {
+	t, err := template.New("documentation_url").Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	if err := t.Execute(&b, info); err != nil {
+		return "", err
+	}
+
+	return b.String(), nil
+}
+
 func dependsOn(a *ast.AnnotationsRef) []string {
 	if a == nil {
 		return []string{}
@@ -263,26 +313,33 @@ type Info struct {
 	Description      string
 	DocumentationUrl string
 	EffectiveOn      string
+	FailureMsg       string
 	Kind             RuleKind
 	Package          string
 	ShortName        string
 	Solution         string
-	Title            string
+	// Source is the URL of the policy source the rule was found in, it is not known when
+	// inspecting a single policy source, and is set when collecting the rules of a source group.
+	Source string
+	Title  string
 }
 
 func RuleInfo(a *ast.AnnotationsRef) Info {
-	return Info{
-		Code:             code(a),
-		CodePackage:      codePackage(a),
-		Collections:      collections(a),
-		Description:      description(a),
-		DependsOn:        dependsOn(a),
-		DocumentationUrl: documentationUrl(a),
-		EffectiveOn:      effectiveOn(a),
-		Solution:         solution(a),
-		Kind:             kind(a),
-		Package:          packageName(a),
-		ShortName:        shortName(a),
-		Title:            title(a),
+	info := Info{
+		Code:        code(a),
+		CodePackage: codePackage(a),
+		Collections: collections(a),
+		Description: description(a),
+		DependsOn:   dependsOn(a),
+		EffectiveOn: effectiveOn(a),
+		FailureMsg:  failureMsg(a),
+		Solution:    solution(a),
+		Kind:        kind(a),
+		Package:     packageName(a),
+		ShortName:   shortName(a),
+		Title:       title(a),
 	}
+	info.DocumentationUrl = documentationUrl(a, info)
+
+	return info
 }
@@ -341,6 +341,40 @@ func TestSolution(t *testing.T) {
 	}
 }
 
+func TestFailureMsg(t *testing.T) {
+	cases := []struct {
+		name       string
+		annotation *ast.AnnotationsRef
+		expected   string
+	}{
+		{
+			name: "without failure_msg",
+			annotation: annotationRef(heredoc.Doc(`
+				package a
+				# METADATA
+				# title: title
+				deny() { true }`)),
+			expected: "",
+		},
+		{
+			name: "with failure_msg",
+			annotation: annotationRef(heredoc.Doc(`
+				package a
+				# METADATA
+				# custom:
+				#   failure_msg: Too much %s
+				deny() { true }`)),
+			expected: "Too much %s",
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("[%d] - %s", i, c.name), func(t *testing.T) {
+			assert.Equal(t, c.expected, failureMsg(c.annotation))
+		})
+	}
+}
+
 func TestCollections(t *testing.T) {
 	cases := []struct {
 		name       string
@@ -536,6 +570,53 @@ func TestCode(t *testing.T) {
 				deny() { true }`)),
 			expected: "x",
 		},
+		{
+			name: "with just policy package",
+			annotation: annotationRef(heredoc.Doc(`
+				package policy
+				# METADATA
+				# custom:
+				#   short_name: x
+				deny() { true }`)),
+			expected: "x",
+		},
+		{
+			name: "code prefixes",
+			annotation: annotationRef(heredoc.Doc(`
+				package org.policy.a.b
+				# METADATA
+				# custom:
+				#   short_name: x
+				#   code_prefixes:
+				#   - org
+				#   - org.policy
+				#   - org.pol
+				deny() { true }`)),
+			expected: "a.b.x",
+		},
+		{
+			name: "code prefixes replace the default ones",
+			annotation: annotationRef(heredoc.Doc(`
+				package policy.release.a
+				# METADATA
+				# custom:
+				#   short_name: x
+				#   code_prefixes:
+				#   - policy
+				deny() { true }`)),
+			expected: "release.a.x",
+		},
+		{
+			name: "no code prefixes",
+			annotation: annotationRef(heredoc.Doc(`
+				package policy.release.a
+				# METADATA
+				# custom:
+				#   short_name: x
+				#   code_prefixes: []
+				deny() { true }`)),
+			expected: "policy.release.a.x",
+		},
 	}
 
 	for i, c := range cases {
@@ -545,6 +626,69 @@ func TestCode(t *testing.T) {
 	}
 }
 
+func TestDocumentationUrl(t *testing.T) {
+	cases := []struct {
+		name       string
+		annotation *ast.AnnotationsRef
+		expected   string
+	}{
+		{
+			name:       "nothing",
+			annotation: nil,
+			expected:   "",
+		},
+		{
+			name: "enterprise contract policies",
+			annotation: annotationRef(heredoc.Doc(`
+				package policy.release.a
+				# METADATA
+				# custom:
+				#   short_name: x
+				deny() { true }`)),
+			expected: "https://enterprisecontract.dev/docs/ec-policies/release_policy.html#a__x",
+		},
+		{
+			name: "other policies",
+			annotation: annotationRef(heredoc.Doc(`
+				package org.a
+				# METADATA
+				# custom:
+				#   short_name: x
+				deny() { true }`)),
+			expected: "",
+		},
+		{
+			name: "template",
+			annotation: annotationRef(heredoc.Doc(`
+				package org.policy.a
+				# METADATA
+				# custom:
+				#   short_name: x
+				#   code_prefixes: [org.policy]
+				#   documentation_url: https://docs.example.com/{{ .CodePackage }}.html#{{ .Code }}
+				deny() { true }`)),
+			expected: "https://docs.example.com/a.html#a.x",
+		},
+		{
+			name: "invalid template",
+			annotation: annotationRef(heredoc.Doc(`
+				package policy.release.a
+				# METADATA
+				# custom:
+				#   short_name: x
+				#   documentation_url: https://docs.example.com/{{ .Unknown }}
+				deny() { true }`)),
+			expected: "",
+		},
+	}
+
+	for i, c := range cases {
+		t.Run(fmt.Sprintf("[%d] - %s", i, c.name), func(t *testing.T) {
+			assert.Equal(t, c.expected, RuleInfo(c.annotation).DocumentationUrl)
+		})
+	}
+}
+
 func TestDependsOn(t *testing.T) {
 	cases := []struct {
 		name       string
@@ -219,7 +219,7 @@ func keepSomeMetadata(results []evaluator.Result) {
 
 func keepSomeMetadataSingle(result evaluator.Result) {
 	for key := range result.Metadata {
-		if key == "code" || key == "effective_on" {
+		if key == "code" || key == "effective_on" || key == "exception" || key == "explanation" {
 			continue
 		}
 		delete(result.Metadata, key)
@@ -259,6 +259,38 @@ func (o Output) Warnings() []evaluator.Result {
 	return warnings
 }
 
+// Exceptions aggregates and returns all results waived by exceptions.
+func (o Output) Exceptions() []evaluator.Result {
+	exceptions := make([]evaluator.Result, 0, 10)
+	for _, result := range o.PolicyCheck {
+		exceptions = append(exceptions, result.Exceptions...)
+	}
+
+	exceptions = sortResults(exceptions)
+	return exceptions
+}
+
+// Skipped aggregates and returns all skipped results.
+func (o Output) Skipped() []evaluator.Result {
+	skipped := make([]evaluator.Result, 0, 10)
+	for _, result := range o.PolicyCheck {
+		skipped = append(skipped, result.Skipped...)
+	}
+
+	skipped = sortResults(skipped)
+	return skipped
+}
+
+// Coverage aggregates and returns the disposition of every rule evaluated.
+func (o Output) Coverage() []evaluator.RuleCoverage {
+	var coverage []evaluator.RuleCoverage
+	for _, result := range o.PolicyCheck {
+		coverage = append(coverage, result.Coverage...)
+	}
+
+	return coverage
+}
+
 // Successes aggregates and returns all successes.
 func (o Output) Successes() []evaluator.Result {
 	successes := make([]evaluator.Result, 0, 10)
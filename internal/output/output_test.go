@@ -785,6 +785,54 @@ func Test_Warnings(t *testing.T) {
 	}
 }
 
+func Test_Exceptions(t *testing.T) {
+	cases := []struct {
+		name     string
+		output   Output
+		expected []evaluator.Result
+	}{
+		{
+			name:     "no-exceptions",
+			output:   Output{},
+			expected: []evaluator.Result{},
+		},
+		{
+			name: "mixed results",
+			output: Output{
+				PolicyCheck: []evaluator.Outcome{
+					{
+						Failures: []evaluator.Result{
+							{Message: "failure for policy check 1"},
+						},
+						Exceptions: []evaluator.Result{
+							{Message: "exception for policy check 2", Metadata: map[string]any{"code": "b.b"}},
+						},
+					},
+					{},
+					{
+						Warnings: []evaluator.Result{
+							{Message: "warning for policy check 3"},
+						},
+						Exceptions: []evaluator.Result{
+							{Message: "exception for policy check 4", Metadata: map[string]any{"code": "a.a"}},
+						},
+					},
+				},
+			},
+			expected: []evaluator.Result{
+				{Message: "exception for policy check 4", Metadata: map[string]any{"code": "a.a"}},
+				{Message: "exception for policy check 2", Metadata: map[string]any{"code": "b.b"}},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.expected, c.output.Exceptions())
+		})
+	}
+}
+
 func TestSetImageAccessibleCheckFromError(t *testing.T) {
 	cases := []struct {
 		name           string
@@ -1039,3 +1087,19 @@ func TestSetAttestationSyntaxCheckFromError(t *testing.T) {
 		})
 	}
 }
+
+func Test_Coverage(t *testing.T) {
+	assert.Nil(t, Output{}.Coverage())
+
+	out := Output{
+		PolicyCheck: []evaluator.Outcome{
+			{Coverage: []evaluator.RuleCoverage{{Code: "a.a", Disposition: evaluator.DispositionFailed}}},
+			{},
+			{Coverage: []evaluator.RuleCoverage{{Code: "b.b", Disposition: evaluator.DispositionPassed}}},
+		},
+	}
+	assert.Equal(t, []evaluator.RuleCoverage{
+		{Code: "a.a", Disposition: evaluator.DispositionFailed},
+		{Code: "b.b", Disposition: evaluator.DispositionPassed},
+	}, out.Coverage())
+}
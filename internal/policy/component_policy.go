@@ -0,0 +1,236 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package policy
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	ecc "github.com/enterprise-contract/enterprise-contract-controller/api/v1alpha1"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/hashicorp/go-multierror"
+	app "github.com/redhat-appstudio/application-api/api/v1alpha1"
+	"github.com/sigstore/cosign/v2/pkg/cosign"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+	"sigs.k8s.io/yaml"
+
+	"github.com/enterprise-contract/ec-cli/internal/utils"
+)
+
+// DefaultComponentPolicyName is the name reported for components not matching any component
+// policy. It cannot be used as the name of a component policy.
+const DefaultComponentPolicyName = "default"
+
+// ComponentPolicy selects the policy source groups, and the signer, used when validating the
+// components of a snapshot matching it.
+type ComponentPolicy struct {
+	// Name identifies the component policy in the report.
+	Name string `json:"name"`
+	// Match determines the components the component policy applies to.
+	Match ComponentMatch `json:"match"`
+	// Sources are the names of the source groups, from the policy spec, used for the matching
+	// components. All source groups are used when empty.
+	Sources []string `json:"sources,omitempty"`
+	// PublicKey replaces the public key of the policy for the matching components.
+	PublicKey string `json:"publicKey,omitempty"`
+	// Identity replaces the keyless identity of the policy for the matching components.
+	Identity *ecc.Identity `json:"identity,omitempty"`
+}
+
+// ComponentMatch holds the criteria a component must meet. Every criteria set must be met.
+// Name and Repository are glob patterns, as understood by path.Match. The values of Labels
+// are glob patterns matched against the labels of the component's image.
+type ComponentMatch struct {
+	// Name is matched against the name of the component.
+	Name string `json:"name,omitempty"`
+	// Repository is matched against the repository of the component's image, e.g.
+	// registry.io/org/repository, without a tag or digest. As with path.Match, a * does not
+	// match a /, so registry.io/org/* does not match registry.io/org/team/repository.
+	Repository string `json:"repository,omitempty"`
+	// Labels are matched against the labels of the component's image.
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// NeedsLabels returns true if matching requires the labels of the component's image.
+func (m ComponentMatch) NeedsLabels() bool {
+	return len(m.Labels) > 0
+}
+
+// Matches returns true if the component meets all the criteria. The labels function is only
+// called when label criteria are set.
+func (m ComponentMatch) Matches(comp app.SnapshotComponent, labels func() (map[string]string, error)) (bool, error) {
+	if m.Name != "" {
+		if ok, err := path.Match(m.Name, comp.Name); err != nil || !ok {
+			return false, err
+		}
+	}
+
+	if m.Repository != "" {
+		ref, err := name.ParseReference(comp.ContainerImage)
+		if err != nil {
+			return false, fmt.Errorf("parsing image reference %q: %w", comp.ContainerImage, err)
+		}
+		if ok, err := path.Match(m.Repository, ref.Context().Name()); err != nil || !ok {
+			return false, err
+		}
+	}
+
+	if m.NeedsLabels() {
+		imageLabels, err := labels()
+		if err != nil {
+			return false, err
+		}
+		for k, pattern := range m.Labels {
+			value, ok := imageLabels[k]
+			if !ok {
+				return false, nil
+			}
+			if ok, err := path.Match(pattern, value); err != nil || !ok {
+				return false, err
+			}
+		}
+	}
+
+	return true, nil
+}
+
+// SelectComponentPolicy returns the first of the given component policies matching the
+// component, or nil if none match.
+func SelectComponentPolicy(policies []ComponentPolicy, comp app.SnapshotComponent, labels func() (map[string]string, error)) (*ComponentPolicy, error) {
+	for i := range policies {
+		ok, err := policies[i].Match.Matches(comp, labels)
+		if err != nil {
+			return nil, fmt.Errorf("matching component policy %q: %w", policies[i].Name, err)
+		}
+		if ok {
+			log.Debugf("Component %q matches component policy %q", comp.Name, policies[i].Name)
+			return &policies[i], nil
+		}
+	}
+
+	return nil, nil
+}
+
+// LoadComponentPolicies reads the component policies from the given YAML, or JSON, file. The
+// file holds either a list of component policies, or an object with the list in the
+// componentPolicies attribute, i.e. the same format used in the policy configuration.
+func LoadComponentPolicies(ctx context.Context, file string) ([]ComponentPolicy, error) {
+	data, err := afero.ReadFile(utils.FS(ctx), file)
+	if err != nil {
+		return nil, fmt.Errorf("reading component policies: %w", err)
+	}
+
+	var policies []ComponentPolicy
+	if err := yaml.Unmarshal(data, &policies); err != nil {
+		var extensions Extensions
+		if err := yaml.Unmarshal(data, &extensions); err != nil {
+			return nil, fmt.Errorf("parsing component policies from %s: %w", file, err)
+		}
+		policies = extensions.ComponentPolicies
+	}
+
+	if err := validateComponentPolicies(policies); err != nil {
+		return nil, err
+	}
+
+	return policies, nil
+}
+
+func validateComponentPolicies(policies []ComponentPolicy) error {
+	var errs error
+	names := map[string]bool{}
+	for i, cp := range policies {
+		if cp.Name == "" {
+			errs = multierror.Append(errs, fmt.Errorf("component policy at index %d has no name", i))
+			continue
+		}
+		if cp.Name == DefaultComponentPolicyName {
+			errs = multierror.Append(errs, fmt.Errorf("component policy at index %d uses the reserved name %q", i, cp.Name))
+		}
+		if names[cp.Name] {
+			errs = multierror.Append(errs, fmt.Errorf("duplicate component policy name %q", cp.Name))
+		}
+		names[cp.Name] = true
+
+		if cp.Match.Name == "" && cp.Match.Repository == "" && !cp.Match.NeedsLabels() {
+			errs = multierror.Append(errs, fmt.Errorf("component policy %q does not specify any match criteria", cp.Name))
+		}
+		for _, pattern := range []string{cp.Match.Name, cp.Match.Repository} {
+			if _, err := path.Match(pattern, ""); err != nil {
+				errs = multierror.Append(errs, fmt.Errorf("component policy %q has an invalid pattern %q: %w", cp.Name, pattern, err))
+			}
+		}
+		if cp.PublicKey != "" && cp.Identity != nil {
+			errs = multierror.Append(errs, fmt.Errorf("component policy %q specifies both a public key and an identity", cp.Name))
+		}
+	}
+
+	return errs
+}
+
+// WithComponentPolicy returns a copy of the policy restricted to the source groups, and using
+// the signer, of the given component policy.
+func (p *policy) WithComponentPolicy(ctx context.Context, cp ComponentPolicy) (Policy, error) {
+	c := *p
+
+	if len(cp.Sources) > 0 {
+		sources := make([]ecc.Source, 0, len(cp.Sources))
+		for _, sourceName := range cp.Sources {
+			found := false
+			for _, s := range p.Sources {
+				if s.Name == sourceName {
+					sources = append(sources, s)
+					found = true
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("component policy %q refers to unknown source group %q", cp.Name, sourceName)
+			}
+		}
+		c.Sources = sources
+	}
+
+	if cp.PublicKey == "" && cp.Identity == nil {
+		return &c, nil
+	}
+
+	if cp.PublicKey != "" {
+		c.PublicKey = cp.PublicKey
+		c.identity = cosign.Identity{}
+	} else {
+		c.PublicKey = ""
+		c.identity = cosign.Identity{
+			Issuer:        cp.Identity.Issuer,
+			Subject:       cp.Identity.Subject,
+			IssuerRegExp:  cp.Identity.IssuerRegExp,
+			SubjectRegExp: cp.Identity.SubjectRegExp,
+		}
+		if err := validateIdentity(c.identity); err != nil {
+			return nil, fmt.Errorf("invalid identity in component policy %q: %w", cp.Name, err)
+		}
+	}
+
+	opts, err := checkOpts(ctx, &c)
+	if err != nil {
+		return nil, err
+	}
+	c.checkOpts = opts
+
+	return &c, nil
+}
@@ -0,0 +1,225 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unit
+
+package policy
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	ecc "github.com/enterprise-contract/enterprise-contract-controller/api/v1alpha1"
+	app "github.com/redhat-appstudio/application-api/api/v1alpha1"
+	"github.com/sigstore/cosign/v2/pkg/cosign"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/enterprise-contract/ec-cli/internal/utils"
+)
+
+func TestComponentMatch(t *testing.T) {
+	comp := app.SnapshotComponent{Name: "my-bundle", ContainerImage: "registry.io/org/bundle:v1@sha256:" + "a1b2c3d4e5f6a1b2c3d4e5f6a1b2c3d4e5f6a1b2c3d4e5f6a1b2c3d4e5f6a1b2"}
+	labels := func() (map[string]string, error) {
+		return map[string]string{"operators.operatorframework.io.bundle.package.v1": "my-operator"}, nil
+	}
+
+	cases := []struct {
+		name     string
+		match    ComponentMatch
+		expected bool
+		err      string
+	}{
+		{name: "name", match: ComponentMatch{Name: "*-bundle"}, expected: true},
+		{name: "name mismatch", match: ComponentMatch{Name: "app-*"}},
+		{name: "repository", match: ComponentMatch{Repository: "registry.io/org/*"}, expected: true},
+		{name: "repository mismatch", match: ComponentMatch{Repository: "registry.io/base/*"}},
+		{name: "repository star does not match slash", match: ComponentMatch{Repository: "registry.io/*"}},
+		{name: "label", match: ComponentMatch{Labels: map[string]string{"operators.operatorframework.io.bundle.package.v1": "*"}}, expected: true},
+		{name: "missing label", match: ComponentMatch{Labels: map[string]string{"com.redhat.component": "*"}}},
+		{name: "all criteria", match: ComponentMatch{
+			Name:       "my-*",
+			Repository: "registry.io/org/bundle",
+			Labels:     map[string]string{"operators.operatorframework.io.bundle.package.v1": "my-operator"},
+		}, expected: true},
+		{name: "one criteria mismatch", match: ComponentMatch{Name: "my-*", Repository: "registry.io/base/*"}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ok, err := c.match.Matches(comp, labels)
+			require.NoError(t, err)
+			assert.Equal(t, c.expected, ok)
+		})
+	}
+}
+
+func TestComponentMatchLabelsOnlyFetchedWhenNeeded(t *testing.T) {
+	labels := func() (map[string]string, error) {
+		return nil, errors.New("should not be called")
+	}
+
+	ok, err := ComponentMatch{Name: "*"}.Matches(app.SnapshotComponent{Name: "app"}, labels)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	_, err = ComponentMatch{Labels: map[string]string{"a": "b"}}.Matches(app.SnapshotComponent{Name: "app"}, labels)
+	assert.EqualError(t, err, "should not be called")
+}
+
+func TestSelectComponentPolicy(t *testing.T) {
+	policies := []ComponentPolicy{
+		{Name: "first", Match: ComponentMatch{Name: "app-*"}},
+		{Name: "second", Match: ComponentMatch{Name: "app-*"}},
+		{Name: "third", Match: ComponentMatch{Name: "base"}},
+	}
+
+	selected, err := SelectComponentPolicy(policies, app.SnapshotComponent{Name: "app-1"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "first", selected.Name)
+
+	selected, err = SelectComponentPolicy(policies, app.SnapshotComponent{Name: "other"}, nil)
+	require.NoError(t, err)
+	assert.Nil(t, selected)
+}
+
+func TestLoadComponentPolicies(t *testing.T) {
+	expected := []ComponentPolicy{
+		{Name: "bundles", Match: ComponentMatch{Name: "*-bundle"}, Sources: []string{"bundle"}},
+	}
+
+	cases := []struct {
+		name    string
+		content string
+		err     string
+	}{
+		{
+			name: "list",
+			content: `- name: bundles
+  match:
+    name: "*-bundle"
+  sources: [bundle]`,
+		},
+		{
+			name: "object",
+			content: `componentPolicies:
+- name: bundles
+  match:
+    name: "*-bundle"
+  sources: [bundle]`,
+		},
+		{
+			name:    "no name",
+			content: `[{"match": {"name": "x"}}]`,
+			err:     "component policy at index 0 has no name",
+		},
+		{
+			name:    "reserved name",
+			content: `[{"name": "default", "match": {"name": "x"}}]`,
+			err:     `component policy at index 0 uses the reserved name "default"`,
+		},
+		{
+			name:    "duplicate name",
+			content: `[{"name": "a", "match": {"name": "x"}}, {"name": "a", "match": {"name": "y"}}]`,
+			err:     `duplicate component policy name "a"`,
+		},
+		{
+			name:    "no criteria",
+			content: `[{"name": "a"}]`,
+			err:     `component policy "a" does not specify any match criteria`,
+		},
+		{
+			name:    "invalid pattern",
+			content: `[{"name": "a", "match": {"name": "[x"}}]`,
+			err:     `component policy "a" has an invalid pattern "[x"`,
+		},
+		{
+			name:    "key and identity",
+			content: `[{"name": "a", "match": {"name": "x"}, "publicKey": "k", "identity": {"subject": "s"}}]`,
+			err:     `component policy "a" specifies both a public key and an identity`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			require.NoError(t, afero.WriteFile(fs, "/policies.yaml", []byte(c.content), 0400))
+			ctx := utils.WithFS(context.Background(), fs)
+
+			policies, err := LoadComponentPolicies(ctx, "/policies.yaml")
+			if c.err != "" {
+				assert.ErrorContains(t, err, c.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, expected, policies)
+		})
+	}
+}
+
+func TestWithComponentPolicy(t *testing.T) {
+	ctx := utils.WithFS(context.Background(), afero.NewMemMapFs())
+	utils.SetTestRekorPublicKey(t)
+	utils.SetTestFulcioRoots(t)
+	utils.SetTestCTLogPublicKey(t)
+
+	p, err := NewPolicy(ctx, Options{
+		PolicyRef: toJson(ecc.EnterpriseContractPolicySpec{
+			PublicKey: utils.TestPublicKey,
+			Sources: []ecc.Source{
+				{Name: "app", Policy: []string{"app"}},
+				{Name: "bundle", Policy: []string{"bundle"}},
+			},
+		}),
+		EffectiveTime: Now,
+	})
+	require.NoError(t, err)
+
+	t.Run("sources", func(t *testing.T) {
+		c, err := p.WithComponentPolicy(ctx, ComponentPolicy{Name: "bundles", Sources: []string{"bundle"}})
+		require.NoError(t, err)
+		assert.Equal(t, []ecc.Source{{Name: "bundle", Policy: []string{"bundle"}}}, c.Spec().Sources)
+		assert.Equal(t, utils.TestPublicKey, c.Spec().PublicKey)
+		// the original policy is unchanged
+		assert.Len(t, p.Spec().Sources, 2)
+	})
+
+	t.Run("unknown source", func(t *testing.T) {
+		_, err := p.WithComponentPolicy(ctx, ComponentPolicy{Name: "bundles", Sources: []string{"nope"}})
+		assert.EqualError(t, err, `component policy "bundles" refers to unknown source group "nope"`)
+	})
+
+	t.Run("identity", func(t *testing.T) {
+		c, err := p.WithComponentPolicy(ctx, ComponentPolicy{Name: "keyless", Identity: &ecc.Identity{
+			Subject: "subject",
+			Issuer:  "issuer",
+		}})
+		require.NoError(t, err)
+		assert.True(t, c.Keyless())
+		assert.Equal(t, cosign.Identity{Subject: "subject", Issuer: "issuer"}, c.Identity())
+		opts, err := c.CheckOpts()
+		require.NoError(t, err)
+		assert.Equal(t, []cosign.Identity{{Subject: "subject", Issuer: "issuer"}}, opts.Identities)
+		assert.False(t, p.Keyless())
+	})
+
+	t.Run("invalid identity", func(t *testing.T) {
+		_, err := p.WithComponentPolicy(ctx, ComponentPolicy{Name: "keyless", Identity: &ecc.Identity{Subject: "subject"}})
+		assert.ErrorContains(t, err, `invalid identity in component policy "keyless"`)
+	})
+}
@@ -0,0 +1,151 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package policy
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/hashicorp/go-multierror"
+	app "github.com/redhat-appstudio/application-api/api/v1alpha1"
+	"github.com/spf13/afero"
+	"sigs.k8s.io/yaml"
+
+	"github.com/enterprise-contract/ec-cli/internal/utils"
+)
+
+// Exception waives the results of a policy rule until it expires. Unlike an exclude entry, an
+// exception records why, and by whom, the rule was waived. Waived results are reported as
+// exceptions instead of being discarded.
+type Exception struct {
+	// Value matches the results to waive, using the same syntax as the include and exclude
+	// entries of the policy configuration, e.g. "pkg.rule", "pkg.rule:term" or "@collection".
+	Value string `json:"value"`
+	// Component is a glob pattern, as understood by path.Match, matched against the name of
+	// the component. The exception applies to all components when empty.
+	Component string `json:"component,omitempty"`
+	// ImageRef is a glob pattern, as understood by path.Match, matched against the repository
+	// of the image of the component, i.e. the image reference without the tag or digest, e.g.
+	// "registry.io/org/*". The exception applies to all images when empty.
+	ImageRef string `json:"imageRef,omitempty"`
+	// ExpiresOn is the date, in the YYYY-MM-DD or RFC3339 format, after which the exception
+	// no longer applies.
+	ExpiresOn string `json:"expiresOn"`
+	// Reason is the justification for the exception.
+	Reason string `json:"reason"`
+	// Ticket is the URL of the ticket tracking the exception.
+	Ticket string `json:"ticket,omitempty"`
+	// ApprovedBy is who approved the exception.
+	ApprovedBy string `json:"approvedBy,omitempty"`
+}
+
+// Expiry returns the time at which the exception expires.
+func (e Exception) Expiry() (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, e.ExpiresOn); err == nil {
+		return t.UTC(), nil
+	}
+
+	t, err := time.Parse(DateFormat, e.ExpiresOn)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid expiry date %q, expecting %s or RFC3339 format", e.ExpiresOn, DateFormat)
+	}
+
+	// A date means the exception is valid throughout that day
+	return t.UTC().Add(24*time.Hour - time.Nanosecond), nil
+}
+
+// Expired returns true if the exception is expired at the given time.
+func (e Exception) Expired(at time.Time) bool {
+	expiry, err := e.Expiry()
+	if err != nil {
+		// Exceptions are validated when loaded, an invalid date never waives anything
+		return true
+	}
+
+	return at.After(expiry)
+}
+
+// AppliesTo returns true if the exception applies to the given component. The image reference
+// pattern is matched against the repository of the component image, like the repository of
+// a ComponentMatch. An image reference that cannot be parsed matches no pattern.
+func (e Exception) AppliesTo(comp app.SnapshotComponent) bool {
+	if e.Component != "" {
+		if ok, _ := path.Match(e.Component, comp.Name); !ok {
+			return false
+		}
+	}
+
+	if e.ImageRef != "" {
+		ref, err := name.ParseReference(comp.ContainerImage)
+		if err != nil {
+			return false
+		}
+		if ok, _ := path.Match(e.ImageRef, ref.Context().Name()); !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// LoadExceptions reads the exceptions from the given YAML, or JSON, file. The file holds either
+// a list of exceptions, or an object with the list in the exceptions attribute, i.e. the same
+// format used in the policy configuration.
+func LoadExceptions(ctx context.Context, file string) ([]Exception, error) {
+	data, err := afero.ReadFile(utils.FS(ctx), file)
+	if err != nil {
+		return nil, fmt.Errorf("reading exceptions: %w", err)
+	}
+
+	var exceptions []Exception
+	if err := yaml.Unmarshal(data, &exceptions); err != nil {
+		var extensions Extensions
+		if err := yaml.Unmarshal(data, &extensions); err != nil {
+			return nil, fmt.Errorf("parsing exceptions from %s: %w", file, err)
+		}
+		exceptions = extensions.Exceptions
+	}
+
+	return exceptions, nil
+}
+
+func validateExceptions(exceptions []Exception) error {
+	var errs error
+	for i, e := range exceptions {
+		if e.Value == "" {
+			errs = multierror.Append(errs, fmt.Errorf("exception at index %d does not specify a value", i))
+		}
+		if e.Reason == "" {
+			errs = multierror.Append(errs, fmt.Errorf("exception at index %d does not specify a reason", i))
+		}
+		if e.ExpiresOn == "" {
+			errs = multierror.Append(errs, fmt.Errorf("exception at index %d does not specify an expiry date", i))
+		} else if _, err := e.Expiry(); err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("exception at index %d has an %w", i, err))
+		}
+		for _, pattern := range []string{e.Component, e.ImageRef} {
+			if _, err := path.Match(pattern, ""); err != nil {
+				errs = multierror.Append(errs, fmt.Errorf("exception at index %d has an invalid pattern %q: %w", i, pattern, err))
+			}
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,114 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unit
+
+package policy
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	app "github.com/redhat-appstudio/application-api/api/v1alpha1"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/enterprise-contract/ec-cli/internal/utils"
+)
+
+func TestExceptionExpired(t *testing.T) {
+	cases := []struct {
+		name      string
+		expiresOn string
+		at        time.Time
+		expired   bool
+	}{
+		{name: "date before", expiresOn: "2024-01-02", at: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "date same day", expiresOn: "2024-01-02", at: time.Date(2024, 1, 2, 23, 59, 0, 0, time.UTC)},
+		{name: "date after", expiresOn: "2024-01-02", at: time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC), expired: true},
+		{name: "time before", expiresOn: "2024-01-02T10:00:00Z", at: time.Date(2024, 1, 2, 9, 0, 0, 0, time.UTC)},
+		{name: "time after", expiresOn: "2024-01-02T10:00:00Z", at: time.Date(2024, 1, 2, 11, 0, 0, 0, time.UTC), expired: true},
+		{name: "invalid", expiresOn: "tomorrow", at: time.Date(2024, 1, 2, 11, 0, 0, 0, time.UTC), expired: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.expired, Exception{ExpiresOn: c.expiresOn}.Expired(c.at))
+		})
+	}
+}
+
+func TestExceptionAppliesTo(t *testing.T) {
+	comp := app.SnapshotComponent{Name: "my-bundle", ContainerImage: "registry.io/org/bundle:v1"}
+
+	assert.True(t, Exception{}.AppliesTo(comp))
+	assert.True(t, Exception{Component: "my-*"}.AppliesTo(comp))
+	assert.False(t, Exception{Component: "app-*"}.AppliesTo(comp))
+	assert.True(t, Exception{ImageRef: "registry.io/org/*"}.AppliesTo(comp))
+	assert.True(t, Exception{ImageRef: "registry.io/org/bundle"}.AppliesTo(comp))
+	assert.False(t, Exception{ImageRef: "registry.io/org/bundle:v1"}.AppliesTo(comp))
+	assert.False(t, Exception{ImageRef: "registry.io/base/*"}.AppliesTo(comp))
+	assert.False(t, Exception{ImageRef: "*"}.AppliesTo(app.SnapshotComponent{ContainerImage: "not a reference"}))
+	assert.False(t, Exception{Component: "my-*", ImageRef: "registry.io/base/*"}.AppliesTo(comp))
+}
+
+func TestExceptionsInPolicy(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/exceptions.yaml", []byte(`exceptions:
+- value: pkg.rule
+  expiresOn: 2030-01-01
+  reason: from file`), 0400))
+	require.NoError(t, afero.WriteFile(fs, "/invalid.yaml", []byte(`- value: pkg.rule`), 0400))
+	ctx := utils.WithFS(context.Background(), fs)
+	utils.SetTestRekorPublicKey(t)
+
+	policyRef := toJson(map[string]any{
+		"publicKey": utils.TestPublicKey,
+		"exceptions": []Exception{
+			{Value: "pkg.other", ExpiresOn: "2030-01-01", Reason: "from policy"},
+		},
+	})
+
+	p, err := NewPolicy(ctx, Options{
+		PolicyRef:     policyRef,
+		EffectiveTime: Now,
+		Exceptions:    "/exceptions.yaml",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []Exception{
+		{Value: "pkg.other", ExpiresOn: "2030-01-01", Reason: "from policy"},
+		{Value: "pkg.rule", ExpiresOn: "2030-01-01", Reason: "from file"},
+	}, p.Extensions().Exceptions)
+
+	_, err = NewPolicy(ctx, Options{
+		PolicyRef:     policyRef,
+		EffectiveTime: Now,
+		Exceptions:    "/invalid.yaml",
+	})
+	assert.ErrorContains(t, err, "exception at index 1 does not specify a reason")
+	assert.ErrorContains(t, err, "exception at index 1 does not specify an expiry date")
+
+	_, err = NewPolicy(ctx, Options{
+		PolicyRef: toJson(map[string]any{
+			"publicKey":  utils.TestPublicKey,
+			"exceptions": []Exception{{Value: "pkg.rule", ExpiresOn: "soon", Reason: "r"}},
+		}),
+		EffectiveTime: Now,
+	})
+	assert.ErrorContains(t, err, `exception at index 0 has an invalid expiry date "soon"`)
+}
@@ -0,0 +1,67 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package policy
+
+import (
+	"fmt"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Extensions holds policy configuration understood by ec that is not part of the
+// EnterpriseContractPolicySpec API. The attributes are read from the same document as the
+// spec. This means they are only available when the policy configuration is provided inline,
+// as a file, or from a git repository. An EnterpriseContractPolicy fetched from a cluster by
+// reference never carries them, the controller reads the EnterpriseContractPolicy as a
+// document instead.
+type Extensions struct {
+	// TrustedRoot is the path to, or the contents of, a Sigstore trusted_root.json file used
+	// instead of the verification material distributed via TUF.
+	TrustedRoot string `json:"trustedRoot,omitempty"`
+	// TSACertChain is the path to, or the contents of, a PEM file with the certificate chain
+	// of the RFC 3161 timestamp authority.
+	TSACertChain string `json:"tsaCertChain,omitempty"`
+	// ComponentPolicies select the policy source groups, and the signer, used for specific
+	// components of a snapshot. The first matching component policy is used. Components
+	// not matching any are validated with the policy as is.
+	ComponentPolicies []ComponentPolicy `json:"componentPolicies,omitempty"`
+	// Exceptions waive the results of policy rules until they expire.
+	Exceptions []Exception `json:"exceptions,omitempty"`
+	// Sources holds the options of the source groups, read from the source groups of the
+	// spec.
+	Sources []SourceOptions `json:"sources,omitempty"`
+}
+
+// parseExtensions reads the Extensions from the given policy document. The document is either
+// an EnterpriseContractPolicy, in which case the extensions are read from its spec, or the spec
+// itself.
+func parseExtensions(policyRef string) (Extensions, error) {
+	ecp := struct {
+		APIVersion string     `json:"apiVersion"`
+		Spec       Extensions `json:"spec"`
+	}{}
+	if err := yaml.Unmarshal([]byte(policyRef), &ecp); err == nil && ecp.APIVersion != "" {
+		return ecp.Spec, nil
+	}
+
+	var extensions Extensions
+	if err := yaml.Unmarshal([]byte(policyRef), &extensions); err != nil {
+		return Extensions{}, fmt.Errorf("unable to parse policy extensions: %w", err)
+	}
+
+	return extensions, nil
+}
@@ -0,0 +1,302 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package lint statically checks a policy configuration against its policy sources.
+package lint
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	ecc "github.com/enterprise-contract/enterprise-contract-controller/api/v1alpha1"
+	"github.com/open-policy-agent/opa/ast"
+	log "github.com/sirupsen/logrus"
+	extv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+
+	"github.com/enterprise-contract/ec-cli/internal/evaluator"
+	"github.com/enterprise-contract/ec-cli/internal/opa"
+	"github.com/enterprise-contract/ec-cli/internal/opa/rule"
+	"github.com/enterprise-contract/ec-cli/internal/policy"
+	"github.com/enterprise-contract/ec-cli/internal/policy/source"
+	"github.com/enterprise-contract/ec-cli/internal/utils"
+)
+
+// Severities of the issues found.
+const (
+	// SeverityError marks issues that make the policy configuration behave differently than
+	// intended, e.g. an exclude entry with a typo, or that make ec report the results of a
+	// rule incorrectly, or not at all.
+	SeverityError = "error"
+	// SeverityWarning marks issues that are likely unintended but harmless.
+	SeverityWarning = "warning"
+)
+
+// Issue is a problem found in the policy configuration or in the annotations of a rule.
+type Issue struct {
+	Severity string `json:"severity"`
+	// Source is the name of the source group the issue was found in, empty for issues with
+	// the policy configuration as a whole and with rules.
+	Source string `json:"source,omitempty"`
+	// File and Row locate the rule the issue was found in, Check names the check of the
+	// rule annotations reporting it.
+	File    string `json:"file,omitempty"`
+	Row     int    `json:"row,omitempty"`
+	Check   string `json:"check,omitempty"`
+	Message string `json:"message"`
+}
+
+func (i Issue) String() string {
+	var b strings.Builder
+	if i.File != "" {
+		fmt.Fprintf(&b, "%s:%d: ", i.File, i.Row)
+	}
+	b.WriteString(i.Severity)
+	if i.Source != "" {
+		fmt.Fprintf(&b, ": %s", i.Source)
+	}
+	fmt.Fprintf(&b, ": %s", i.Message)
+	if i.Check != "" {
+		fmt.Fprintf(&b, " (%s)", i.Check)
+	}
+
+	return b.String()
+}
+
+// SourceName returns the name of the source group, or a name based on its position when the
+// source group is not named.
+func SourceName(i int, src ecc.Source) string {
+	if src.Name != "" {
+		return src.Name
+	}
+
+	return fmt.Sprintf("sources[%d]", i)
+}
+
+// Lint checks the policy configuration against the rules found in its policy sources. The
+// issues found are returned ordered by source group. An error is returned only if the checks
+// could not be performed.
+func Lint(ctx context.Context, p policy.Policy) ([]Issue, error) {
+	fs := utils.FS(ctx)
+	workDir, err := utils.CreateWorkDir(fs)
+	if err != nil {
+		log.Debug("Failed to create work dir!")
+		return nil, err
+	}
+	defer utils.CleanupWorkDir(fs, workDir)
+
+	var issues []Issue
+	spec := p.Spec()
+	for i, src := range spec.Sources {
+		l := linter{ctx: ctx, source: SourceName(i, src)}
+		l.checkVolatileConfig(src.VolatileConfig)
+		rules, literals, fetched := l.fetch(src, p.Extensions().SourceOptions(src), workDir)
+		if fetched {
+			l.checkEntries(src, spec.Configuration, rules)
+			l.checkRuleData(src.RuleData, literals)
+		}
+		issues = append(issues, l.issues...)
+	}
+
+	return issues, nil
+}
+
+type linter struct {
+	ctx    context.Context
+	source string
+	issues []Issue
+}
+
+func (l *linter) report(severity, format string, args ...any) {
+	l.issues = append(l.issues, Issue{Severity: severity, Source: l.source, Message: fmt.Sprintf(format, args...)})
+}
+
+// checkVolatileConfig reports volatile criteria with effective dates that cannot be parsed,
+// or where the end of the time window is before its start.
+func (l *linter) checkVolatileConfig(vc *ecc.VolatileSourceConfig) {
+	if vc == nil {
+		return
+	}
+
+	check := func(list string, criteria []ecc.VolatileCriteria) {
+		for _, c := range criteria {
+			from, fromErr := parseTime(c.EffectiveOn)
+			if fromErr != nil {
+				l.report(SeverityError, "volatileConfig.%s entry %q has an invalid effectiveOn %q, expecting RFC3339 format", list, c.Value, c.EffectiveOn)
+			}
+			until, untilErr := parseTime(c.EffectiveUntil)
+			if untilErr != nil {
+				l.report(SeverityError, "volatileConfig.%s entry %q has an invalid effectiveUntil %q, expecting RFC3339 format", list, c.Value, c.EffectiveUntil)
+			}
+			if fromErr == nil && untilErr == nil && !from.IsZero() && !until.IsZero() && until.Before(from) {
+				l.report(SeverityError, "volatileConfig.%s entry %q has an effectiveUntil %s before its effectiveOn %s, it never applies", list, c.Value, c.EffectiveUntil, c.EffectiveOn)
+			}
+		}
+	}
+
+	check("include", vc.Include)
+	check("exclude", vc.Exclude)
+}
+
+func parseTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	return time.Parse(time.RFC3339, value)
+}
+
+// fetch downloads each policy source of the source group and returns the information of the
+// rules found, and the string literals used in them. Sources that fail to download, or have
+// no rules, are reported. The rules are collected as when evaluating, rules defined by more
+// than one source are reported unless the rule precedence allows it. Returns false if any of
+// the sources could not be fetched, or the rules could not be collected.
+func (l *linter) fetch(src ecc.Source, options policy.SourceOptions, workDir string) ([]rule.Info, map[string]bool, bool) {
+	fs := utils.FS(l.ctx)
+	annotations := map[string][]*ast.AnnotationsRef{}
+	literals := map[string]bool{}
+	fetched := true
+	for _, url := range src.Policy {
+		s := &source.PolicyUrl{Url: url, Kind: source.PolicyKind}
+
+		dir, err := s.GetPolicy(l.ctx, workDir, false)
+		if err != nil {
+			l.report(SeverityError, "policy source %q could not be fetched: %v", url, err)
+			fetched = false
+			continue
+		}
+
+		inspected, err := opa.InspectDir(fs, dir)
+		if err != nil {
+			l.report(SeverityError, "policy source %q could not be inspected: %v", url, err)
+			fetched = false
+			continue
+		}
+		annotations[url] = inspected
+
+		if !slices.ContainsFunc(inspected, func(a *ast.AnnotationsRef) bool {
+			return a.Annotations != nil && rule.RuleInfo(a).ShortName != ""
+		}) {
+			l.report(SeverityWarning, "policy source %q contains no annotated rules", url)
+		}
+
+		found, err := opa.StringLiterals(fs, dir)
+		if err != nil {
+			l.report(SeverityError, "policy source %q could not be parsed: %v", url, err)
+			fetched = false
+			continue
+		}
+		for s := range found {
+			literals[s] = true
+		}
+	}
+
+	collected, err := evaluator.CollectRules(options, src.Policy, annotations)
+	if err != nil {
+		l.report(SeverityError, "%v", err)
+		return nil, literals, false
+	}
+
+	rules := make([]rule.Info, 0, len(collected))
+	for _, info := range collected {
+		rules = append(rules, info)
+	}
+
+	return rules, literals, fetched
+}
+
+// checkEntries reports the include and exclude entries that do not match any rule, package or
+// collection. Such entries are ignored when evaluating, which usually means they contain a
+// typo. The entries of the policy configuration are checked only if the source group does
+// not specify its own, as only then they apply to the source group. Regular expressions are
+// only checked for validity, as they may match only results with particular terms.
+func (l *linter) checkEntries(src ecc.Source, configuration *ecc.EnterpriseContractPolicyConfiguration, rules []rule.Info) {
+	matchers := make([][]string, 0, len(rules))
+	for _, info := range rules {
+		matchers = append(matchers, evaluator.RuleMatchers(info))
+	}
+
+	check := func(list string, entries []string) {
+		for _, entry := range entries {
+			if evaluator.IsRegex(entry) {
+				if err := evaluator.ValidatePattern(entry); err != nil {
+					l.report(SeverityError, "%s entry %q is not a valid regular expression: %v", list, entry, err)
+				}
+				continue
+			}
+
+			name, _, _ := strings.Cut(entry, ":")
+			if name == "*" || slices.ContainsFunc(matchers, func(m []string) bool { return evaluator.MatchesName(name, m) }) {
+				continue
+			}
+			l.report(SeverityError, "%s entry %q does not match any rule, package or collection", list, entry)
+		}
+	}
+
+	if src.Config != nil {
+		check("config.include", src.Config.Include)
+		check("config.exclude", src.Config.Exclude)
+	}
+
+	if vc := src.VolatileConfig; vc != nil {
+		for _, c := range vc.Include {
+			check("volatileConfig.include", []string{c.Value})
+		}
+		for _, c := range vc.Exclude {
+			check("volatileConfig.exclude", []string{c.Value})
+		}
+	}
+
+	if (src.Config == nil || len(src.Config.Include)+len(src.Config.Exclude) == 0) && configuration != nil {
+		check("configuration.include", configuration.Include)
+		check("configuration.exclude", configuration.Exclude)
+		for _, c := range configuration.Collections {
+			check("configuration.collections", []string{"@" + c})
+		}
+	}
+}
+
+// checkRuleData reports the keys of the rule data that do not appear in any of the rules of
+// the source group. Rules access the rule data using the key either in a reference, e.g.
+// data.rule_data.key, or as an argument of a function, so the key is expected to appear as a
+// string literal.
+func (l *linter) checkRuleData(ruleData *extv1.JSON, literals map[string]bool) {
+	if ruleData == nil {
+		return
+	}
+
+	var data map[string]any
+	if err := json.Unmarshal(ruleData.Raw, &data); err != nil {
+		l.report(SeverityError, "ruleData is not a JSON object: %v", err)
+		return
+	}
+
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if !literals[k] {
+			l.report(SeverityWarning, "ruleData key %q is not read by any rule", k)
+		}
+	}
+}
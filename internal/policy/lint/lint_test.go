@@ -0,0 +1,218 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unit
+
+package lint
+
+import (
+	"context"
+	"errors"
+	"path"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/enterprise-contract/ec-cli/internal/policy"
+	"github.com/enterprise-contract/ec-cli/internal/policy/source"
+	"github.com/enterprise-contract/ec-cli/internal/utils"
+)
+
+type mockDownloader struct {
+	mock.Mock
+}
+
+func (m *mockDownloader) Download(_ context.Context, dest string, sourceUrl string, showMsg bool) error {
+	args := m.Called(dest, sourceUrl, showMsg)
+
+	return args.Error(0)
+}
+
+const rules = `package pkg
+
+import future.keywords.contains
+import future.keywords.if
+
+# METADATA
+# custom:
+#   short_name: one
+#   collections:
+#   - minimal
+deny contains result if {
+	input.allowed != data.rule_data.allowed
+	result := "one"
+}
+`
+
+const otherRules = `package other
+
+import future.keywords.contains
+import future.keywords.if
+
+# METADATA
+# custom:
+#   short_name: two
+deny contains result if {
+	false
+	result := "two"
+}
+`
+
+const unannotated = `package pkg
+
+allow := true
+`
+
+func setUp(t *testing.T) context.Context {
+	fs := afero.NewMemMapFs()
+	ctx := utils.WithFS(context.Background(), fs)
+
+	downloader := mockDownloader{}
+	ctx = context.WithValue(ctx, source.DownloaderFuncKey, &downloader)
+
+	for url, content := range map[string]string{
+		"rules":       rules,
+		"again":       rules,
+		"other":       otherRules,
+		"unannotated": unannotated,
+	} {
+		content := content
+		downloader.On("Download", mock.Anything, url, false).Return(nil).Run(func(args mock.Arguments) {
+			dir := args.String(0)
+			require.NoError(t, fs.MkdirAll(dir, 0755))
+			require.NoError(t, afero.WriteFile(fs, path.Join(dir, "rules.rego"), []byte(content), 0644))
+		})
+	}
+	downloader.On("Download", mock.Anything, "missing", false).Return(errors.New("not found"))
+
+	return ctx
+}
+
+func TestLint(t *testing.T) {
+	cases := []struct {
+		name     string
+		policy   string
+		expected []Issue
+	}{
+		{
+			name:   "no issues",
+			policy: `{"sources":[{"name":"release","policy":["rules","other"],"config":{"include":["@minimal","other"],"exclude":["pkg.one:term","*"]},"ruleData":{"allowed":true}}]}`,
+		},
+		{
+			name:   "unmatched entries",
+			policy: `{"sources":[{"policy":["rules"],"config":{"include":["pkg.on"],"exclude":["@mnimal"]},"volatileConfig":{"exclude":[{"value":"pkg.two"}]}}]}`,
+			expected: []Issue{
+				{Severity: SeverityError, Source: "sources[0]", Message: `config.include entry "pkg.on" does not match any rule, package or collection`},
+				{Severity: SeverityError, Source: "sources[0]", Message: `config.exclude entry "@mnimal" does not match any rule, package or collection`},
+				{Severity: SeverityError, Source: "sources[0]", Message: `volatileConfig.exclude entry "pkg.two" does not match any rule, package or collection`},
+			},
+		},
+		{
+			name:   "patterns",
+			policy: `{"sources":[{"policy":["rules","other"],"config":{"include":["p*.o*","/^other\\./"],"exclude":["x*","/(/"]}}]}`,
+			expected: []Issue{
+				{Severity: SeverityError, Source: "sources[0]", Message: `config.exclude entry "x*" does not match any rule, package or collection`},
+				{Severity: SeverityError, Source: "sources[0]", Message: "config.exclude entry \"/(/\" is not a valid regular expression: error parsing regexp: missing closing ): `(`"},
+			},
+		},
+		{
+			name:   "global configuration",
+			policy: `{"configuration":{"exclude":["pkg.two"],"collections":["minimal"]},"sources":[{"policy":["rules"]},{"policy":["rules"],"config":{"include":["*"]}}]}`,
+			expected: []Issue{
+				{Severity: SeverityError, Source: "sources[0]", Message: `configuration.exclude entry "pkg.two" does not match any rule, package or collection`},
+			},
+		},
+		{
+			name:   "volatile dates",
+			policy: `{"sources":[{"policy":["rules"],"volatileConfig":{"exclude":[{"value":"pkg.one","effectiveOn":"2024-02-01T00:00:00Z","effectiveUntil":"2024-01-01T00:00:00Z"},{"value":"pkg","effectiveOn":"yesterday"}]}}]}`,
+			expected: []Issue{
+				{Severity: SeverityError, Source: "sources[0]", Message: `volatileConfig.exclude entry "pkg.one" has an effectiveUntil 2024-01-01T00:00:00Z before its effectiveOn 2024-02-01T00:00:00Z, it never applies`},
+				{Severity: SeverityError, Source: "sources[0]", Message: `volatileConfig.exclude entry "pkg" has an invalid effectiveOn "yesterday", expecting RFC3339 format`},
+			},
+		},
+		{
+			name:   "fetch failure",
+			policy: `{"sources":[{"name":"release","policy":["missing"],"config":{"include":["nope"]}}]}`,
+			expected: []Issue{
+				{Severity: SeverityError, Source: "release", Message: `policy source "missing" could not be fetched: not found`},
+			},
+		},
+		{
+			name:   "no annotated rules",
+			policy: `{"sources":[{"policy":["unannotated"]}]}`,
+			expected: []Issue{
+				{Severity: SeverityWarning, Source: "sources[0]", Message: `policy source "unannotated" contains no annotated rules`},
+			},
+		},
+		{
+			name:   "duplicate rules",
+			policy: `{"sources":[{"policy":["rules","again"]}]}`,
+			expected: []Issue{
+				{Severity: SeverityError, Source: "sources[0]", Message: "found a second rule with the same code: `pkg.one`, defined in \"rules\" and \"again\""},
+			},
+		},
+		{
+			name:   "duplicate rules with precedence",
+			policy: `{"sources":[{"policy":["rules","again"],"rulePrecedence":"last"}]}`,
+		},
+		{
+			name:   "duplicate rules with namespace",
+			policy: `{"sources":[{"policy":["rules","again"],"ruleNamespaces":{"again":"org"}}]}`,
+			expected: []Issue{
+				{Severity: SeverityError, Source: "sources[0]", Message: "found a second rule with the same code: `org/pkg.one`, defined in \"rules\" and \"again\""},
+			},
+		},
+		{
+			name:   "namespaced rule with precedence",
+			policy: `{"sources":[{"policy":["rules","again"],"ruleNamespaces":{"again":"org"},"rulePrecedence":"last","config":{"exclude":["org/pkg.one"]}}]}`,
+		},
+		{
+			name:   "rule replaced twice",
+			policy: `{"sources":[{"policy":["rules","again","rules"],"ruleNamespaces":{"again":"org"},"rulePrecedence":"last","config":{"exclude":["org/pkg.one"]}}]}`,
+			expected: []Issue{
+				{Severity: SeverityError, Source: "sources[0]", Message: `config.exclude entry "org/pkg.one" does not match any rule, package or collection`},
+			},
+		},
+		{
+			name:   "unused rule data",
+			policy: `{"sources":[{"policy":["rules"],"ruleData":{"allowed":true,"alowed":false}}]}`,
+			expected: []Issue{
+				{Severity: SeverityWarning, Source: "sources[0]", Message: `ruleData key "alowed" is not read by any rule`},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctx := setUp(t)
+
+			p, err := policy.NewInertPolicy(ctx, c.policy)
+			require.NoError(t, err)
+
+			issues, err := Lint(ctx, p)
+			require.NoError(t, err)
+			assert.Equal(t, c.expected, issues)
+		})
+	}
+}
+
+func TestIssueString(t *testing.T) {
+	assert.Equal(t, "error: release: oops", Issue{Severity: SeverityError, Source: "release", Message: "oops"}.String())
+	assert.Equal(t, "warning: oops", Issue{Severity: SeverityWarning, Message: "oops"}.String())
+}
@@ -0,0 +1,191 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package lint
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/spf13/afero"
+
+	"github.com/enterprise-contract/ec-cli/internal/evaluator"
+	"github.com/enterprise-contract/ec-cli/internal/opa"
+	"github.com/enterprise-contract/ec-cli/internal/opa/rule"
+)
+
+// Names of the lint checks.
+const (
+	CheckMissingShortName   = "missing-short-name"
+	CheckMissingDocs        = "missing-documentation"
+	CheckDuplicateCode      = "duplicate-code"
+	CheckInvalidEffectiveOn = "invalid-effective-on"
+	CheckUnknownDependency  = "unknown-dependency"
+	CheckDependencyCycle    = "dependency-cycle"
+	CheckUnusedCollection   = "unused-collection"
+	CheckBrokenXref         = "broken-xref"
+	CheckInvalidDocsUrl     = "invalid-documentation-url"
+)
+
+// RulesOptions configure the checks of the rule annotations.
+type RulesOptions struct {
+	// Collections included by the policy configuration. Collections of the rules that are not
+	// included are reported only if set.
+	Collections []string
+}
+
+type lintRule struct {
+	info     rule.Info
+	custom   map[string]any
+	location *ast.Location
+}
+
+// LintRules checks the annotations of the deny and warn rules in the rego files found in the
+// directories, the tests excluded. The rules of all directories are checked together, so a
+// rule can depend on a rule in a different directory. The issues are returned sorted by file
+// and row. An error is returned only if the rego files could not be parsed.
+func LintRules(afs afero.Fs, dirs []string, opts RulesOptions) ([]Issue, error) {
+	modules, err := opa.ParseDirs(afs, dirs)
+	if err != nil {
+		return nil, err
+	}
+
+	annotations, errs := ast.BuildAnnotationSet(modules)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	var issues []Issue
+	report := func(loc *ast.Location, severity, check, format string, args ...any) {
+		issues = append(issues, Issue{
+			File:     loc.File,
+			Row:      loc.Row,
+			Severity: severity,
+			Check:    check,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	var rules []lintRule
+	for _, m := range modules {
+		for _, r := range m.Rules {
+			name := r.Head.Ref()[0].String()
+			if !opa.IsCheckRule(name) {
+				continue
+			}
+
+			// the rule inherits the conventions of its package
+			rule.InheritConventions(annotations.Chain(r))
+
+			var a *ast.Annotations
+			if rs := annotations.GetRuleScope(r); len(rs) > 0 {
+				a = rs[0]
+			} else {
+				a = annotations.GetDocumentScope(r.Path())
+			}
+
+			var info rule.Info
+			if a != nil {
+				info = rule.RuleInfo(ast.NewAnnotationsRef(a))
+			}
+			if info.ShortName == "" {
+				report(r.Location, SeverityError, CheckMissingShortName,
+					"rule %s.%s has no short_name annotation, its successes are not reported and it cannot be included or excluded by its code", m.Package.Path, name)
+				continue
+			}
+
+			rules = append(rules, lintRule{info: info, custom: a.Custom, location: r.Location})
+		}
+	}
+
+	// the first rule with a code, in file order, is the one the others are reported against
+	byCode := map[string]lintRule{}
+	for _, r := range rules {
+		if first, ok := byCode[r.info.Code]; ok {
+			report(r.location, SeverityError, CheckDuplicateCode, "rule code %q is already used at %s:%d", r.info.Code, first.location.File, first.location.Row)
+			continue
+		}
+		byCode[r.info.Code] = r
+	}
+
+	for _, r := range rules {
+		for _, f := range []struct{ name, value string }{{"title", r.info.Title}, {"description", r.info.Description}} {
+			if f.value == "" {
+				report(r.location, SeverityWarning, CheckMissingDocs, "rule %q has no %s annotation, its results are reported without one", r.info.Code, f.name)
+			}
+		}
+
+		if v, ok := r.custom["effective_on"]; ok {
+			s, isString := v.(string)
+			if _, err := time.Parse(evaluator.EffectiveOnFormat, s); !isString || err != nil {
+				report(r.location, SeverityError, CheckInvalidEffectiveOn,
+					"rule %q has an invalid effective_on %v, expecting a UTC time, e.g. 2024-01-01T00:00:00Z, the rule is always effective", r.info.Code, v)
+			}
+		}
+
+		for _, d := range r.info.DependsOn {
+			if _, ok := byCode[d]; !ok {
+				report(r.location, SeverityError, CheckUnknownDependency, "rule %q depends on the unknown rule %q", r.info.Code, d)
+			}
+		}
+
+		if opts.Collections != nil {
+			for _, c := range r.info.Collections {
+				if !slices.Contains(opts.Collections, c) {
+					report(r.location, SeverityWarning, CheckUnusedCollection, "rule %q is in the collection %q, which is not included by the policy configuration", r.info.Code, c)
+				}
+			}
+		}
+
+		if v, ok := r.custom[rule.DocumentationUrlAnnotation]; ok {
+			s, isString := v.(string)
+			if !isString {
+				report(r.location, SeverityError, CheckInvalidDocsUrl, "rule %q has a documentation_url that is not a string", r.info.Code)
+			} else if _, err := rule.RenderDocumentationUrl(s, r.info); err != nil {
+				report(r.location, SeverityError, CheckInvalidDocsUrl, "rule %q has an invalid documentation_url template: %v", r.info.Code, err)
+			}
+		}
+
+		// well formed xref links are replaced by their text in rule.Info, any left won't render
+		for _, f := range []struct{ name, value string }{{"description", r.info.Description}, {"solution", r.info.Solution}} {
+			if strings.Contains(f.value, "xref:") {
+				report(r.location, SeverityWarning, CheckBrokenXref, "the %s of rule %q has an xref: link that is not rendered, expecting xref:page.adoc#anchor[text]", f.name, r.info.Code)
+			}
+		}
+	}
+
+	dependsOn := make(map[string][]string, len(byCode))
+	for code, r := range byCode {
+		dependsOn[code] = r.info.DependsOn
+	}
+	for _, cycle := range rule.DependencyCycles(dependsOn) {
+		r := byCode[cycle.Path[0]]
+		report(r.location, SeverityError, CheckDependencyCycle, "rules depend on each other in a cycle: %s", strings.Join(cycle.Path, " -> "))
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].File != issues[j].File {
+			return issues[i].File < issues[j].File
+		}
+		return issues[i].Row < issues[j].Row
+	})
+
+	return issues, nil
+}
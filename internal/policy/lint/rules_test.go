@@ -0,0 +1,213 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unit
+
+package lint
+
+import (
+	"testing"
+
+	hd "github.com/MakeNowJust/heredoc"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func lintFs(t *testing.T, files map[string]string) afero.Fs {
+	t.Helper()
+
+	fs := afero.NewMemMapFs()
+	for name, content := range files {
+		require.NoError(t, afero.WriteFile(fs, name, []byte(content), 0644))
+	}
+
+	return fs
+}
+
+func TestLintRules(t *testing.T) {
+	fs := lintFs(t, map[string]string{
+		"policy/release/a.rego": hd.Doc(`
+			package policy.release.a
+
+			import future.keywords.contains
+			import future.keywords.if
+
+			# METADATA
+			# title: First
+			# description: See xref:docs/page-name.adoc[the docs].
+			# custom:
+			#   short_name: first
+			#   effective_on: 2024-01-01
+			#   depends_on:
+			#   - a.second
+			#   - a.missing
+			#   collections:
+			#   - minimal
+			#   - unused
+			deny contains result if {
+				false
+			}
+
+			# METADATA
+			# title: Second
+			# description: See xref:page.adoc#anchor[the docs].
+			# custom:
+			#   short_name: second
+			#   effective_on: 2024-01-01T00:00:00Z
+			#   depends_on:
+			#   - a.first
+			#   collections:
+			#   - minimal
+			warn contains result if {
+				false
+			}
+
+			warn contains result if {
+				false
+			}
+		`),
+		"policy/release/b.rego": hd.Doc(`
+			package policy.release.a
+
+			import future.keywords.contains
+			import future.keywords.if
+
+			# METADATA
+			# title: Duplicate
+			# custom:
+			#   short_name: first
+			deny contains result if {
+				false
+			}
+
+			# METADATA
+			# title: Not a rule
+			helper := true
+		`),
+		"policy/release/b_test.rego": hd.Doc(`
+			package policy.release.a_test
+
+			deny := true
+		`),
+	})
+
+	issues, err := LintRules(fs, []string{"policy"}, RulesOptions{Collections: []string{"minimal"}})
+	require.NoError(t, err)
+
+	lines := make([]string, 0, len(issues))
+	for _, i := range issues {
+		lines = append(lines, i.String())
+	}
+
+	assert.Equal(t, []string{
+		`policy/release/a.rego:18: error: rule "a.first" has an invalid effective_on 2024-01-01, expecting a UTC time, e.g. 2024-01-01T00:00:00Z, the rule is always effective (invalid-effective-on)`,
+		`policy/release/a.rego:18: error: rule "a.first" depends on the unknown rule "a.missing" (unknown-dependency)`,
+		`policy/release/a.rego:18: warning: rule "a.first" is in the collection "unused", which is not included by the policy configuration (unused-collection)`,
+		`policy/release/a.rego:18: warning: the description of rule "a.first" has an xref: link that is not rendered, expecting xref:page.adoc#anchor[text] (broken-xref)`,
+		`policy/release/a.rego:18: error: rules depend on each other in a cycle: a.first -> a.second -> a.first (dependency-cycle)`,
+		`policy/release/a.rego:36: error: rule data.policy.release.a.warn has no short_name annotation, its successes are not reported and it cannot be included or excluded by its code (missing-short-name)`,
+		`policy/release/b.rego:10: error: rule code "a.first" is already used at policy/release/a.rego:18 (duplicate-code)`,
+		`policy/release/b.rego:10: warning: rule "a.first" has no description annotation, its results are reported without one (missing-documentation)`,
+	}, lines)
+}
+
+func TestLintRulesWithoutPolicyConfiguration(t *testing.T) {
+	fs := lintFs(t, map[string]string{
+		"policy/a.rego": hd.Doc(`
+			package policy.release.a
+
+			import future.keywords.contains
+			import future.keywords.if
+
+			# METADATA
+			# title: First
+			# description: The first rule.
+			# custom:
+			#   short_name: first
+			#   collections:
+			#   - unused
+			deny contains result if {
+				false
+			}
+		`),
+	})
+
+	issues, err := LintRules(fs, []string{"policy"}, RulesOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, issues)
+}
+
+func TestLintRulesConventions(t *testing.T) {
+	fs := lintFs(t, map[string]string{
+		"policy/org.rego": hd.Doc(`
+			# METADATA
+			# scope: subpackages
+			# custom:
+			#   code_prefixes: [org.policy]
+			#   documentation_url: https://docs.example.com/{{ .Unknown }}
+			package org.policy
+		`),
+		"policy/a.rego": hd.Doc(`
+			package org.policy.a
+
+			import future.keywords.contains
+			import future.keywords.if
+
+			# METADATA
+			# title: First
+			# description: The first rule.
+			# custom:
+			#   short_name: first
+			#   depends_on:
+			#   - a.second
+			deny contains result if {
+				false
+			}
+
+			# METADATA
+			# custom:
+			#   short_name: second
+			#   documentation_url: https://docs.example.com/{{ .Code }}
+			deny contains result if {
+				false
+			}
+		`),
+	})
+
+	issues, err := LintRules(fs, []string{"policy"}, RulesOptions{})
+	require.NoError(t, err)
+
+	lines := make([]string, 0, len(issues))
+	for _, i := range issues {
+		lines = append(lines, i.String())
+	}
+
+	assert.Equal(t, []string{
+		`policy/a.rego:13: error: rule "a.first" has an invalid documentation_url template: template: documentation_url:1:28: executing "documentation_url" at <.Unknown>: can't evaluate field Unknown in type rule.Info (invalid-documentation-url)`,
+		`policy/a.rego:21: warning: rule "a.second" has no title annotation, its results are reported without one (missing-documentation)`,
+		`policy/a.rego:21: warning: rule "a.second" has no description annotation, its results are reported without one (missing-documentation)`,
+	}, lines)
+}
+
+func TestLintRulesInvalidRego(t *testing.T) {
+	fs := lintFs(t, map[string]string{
+		"policy/a.rego": "package",
+	})
+
+	_, err := LintRules(fs, []string{"policy"}, RulesOptions{})
+	assert.Error(t, err)
+}
@@ -19,6 +19,7 @@ package policy
 import (
 	"context"
 	"crypto"
+	"crypto/x509"
 	"errors"
 	"fmt"
 	"os"
@@ -57,6 +58,8 @@ type Policy interface {
 	AttestationTime(time.Time)
 	Identity() cosign.Identity
 	Keyless() bool
+	Extensions() Extensions
+	WithComponentPolicy(context.Context, ComponentPolicy) (Policy, error)
 }
 
 type policy struct {
@@ -67,6 +70,9 @@ type policy struct {
 	attestationTime *time.Time
 	identity        cosign.Identity
 	ignoreRekor     bool
+	extensions      Extensions
+	trustedRoot     *trustedRoot
+	tsaCerts        []*x509.Certificate
 }
 
 // PublicKeyPEM returns the PublicKey in PEM format.
@@ -100,6 +106,11 @@ func (p *policy) Identity() cosign.Identity {
 	return p.identity
 }
 
+// Extensions returns the ec specific configuration found alongside the policy spec.
+func (p *policy) Extensions() Extensions {
+	return p.extensions
+}
+
 // Keyless returns whether or not the Policy uses the keyless workflow for verification.
 func (p *policy) Keyless() bool {
 	return p.PublicKey == ""
@@ -107,11 +118,18 @@ func (p *policy) Keyless() bool {
 
 type Options struct {
 	EffectiveTime string
-	Identity      cosign.Identity
-	IgnoreRekor   bool
-	PolicyRef     string
-	PublicKey     string
-	RekorURL      string
+	// Exceptions is the path to a YAML, or JSON, file with exceptions added to the ones in
+	// the policy configuration.
+	Exceptions  string
+	Identity    cosign.Identity
+	IgnoreRekor bool
+	PolicyRef   string
+	PublicKey   string
+	RekorURL    string
+	TrustedRoot TrustedRootOptions
+	// TSACertChain is the path to a PEM file with the certificate chain of the RFC 3161
+	// timestamp authority.
+	TSACertChain string
 }
 
 // NewOfflinePolicy construct and return a new instance of Policy that is used
@@ -147,6 +165,40 @@ func NewInertPolicy(ctx context.Context, policyRef string) (Policy, error) {
 	return &p, nil
 }
 
+// NewInertPolicyAt construct and return a new instance of Policy, like NewInertPolicy, with
+// the given effective time. The extensions of the policy configuration are validated as when
+// validating an image, but no signature verification options are set up.
+func NewInertPolicyAt(ctx context.Context, policyRef string, effectiveTime string) (Policy, error) {
+	p := policy{
+		choosenTime: effectiveTime,
+		checkOpts:   &cosign.CheckOpts{},
+	}
+
+	if err := p.loadPolicy(ctx, policyRef); err != nil {
+		return nil, err
+	}
+
+	if efn, err := parseEffectiveTime(effectiveTime); err != nil {
+		return nil, err
+	} else {
+		p.effectiveTime = efn
+	}
+
+	if err := validateComponentPolicies(p.extensions.ComponentPolicies); err != nil {
+		return nil, err
+	}
+
+	if err := validateExceptions(p.extensions.Exceptions); err != nil {
+		return nil, err
+	}
+
+	if err := validateSourceOptions(p.extensions.Sources); err != nil {
+		return nil, err
+	}
+
+	return &p, nil
+}
+
 // NewPolicy construct and return a new instance of Policy.
 //
 // The policyRef parameter is expected to be either a JSON-encoded instance of
@@ -205,6 +257,38 @@ func NewPolicy(ctx context.Context, opts Options) (Policy, error) {
 		p.effectiveTime = efn
 	}
 
+	if err := validateComponentPolicies(p.extensions.ComponentPolicies); err != nil {
+		return nil, err
+	}
+
+	if opts.Exceptions != "" {
+		exceptions, err := LoadExceptions(ctx, opts.Exceptions)
+		if err != nil {
+			return nil, err
+		}
+		p.extensions.Exceptions = append(p.extensions.Exceptions, exceptions...)
+	}
+
+	if err := validateExceptions(p.extensions.Exceptions); err != nil {
+		return nil, err
+	}
+
+	if err := validateSourceOptions(p.extensions.Sources); err != nil {
+		return nil, err
+	}
+
+	if root, err := loadTrustedRoot(ctx, opts.TrustedRoot, p.extensions.TrustedRoot); err != nil {
+		return nil, err
+	} else {
+		p.trustedRoot = root
+	}
+
+	if certs, err := loadTSACertChain(ctx, opts.TSACertChain, p.extensions.TSACertChain); err != nil {
+		return nil, err
+	} else {
+		p.tsaCerts = certs
+	}
+
 	if opts, err := checkOpts(ctx, &p); err != nil {
 		return nil, err
 	} else {
@@ -235,6 +319,11 @@ func (p *policy) loadPolicy(ctx context.Context, policyRef string) error {
 				return fmt.Errorf("unable to parse EnterpriseContractPolicySpec: %w", err)
 			}
 		}
+		extensions, err := parseExtensions(policyRef)
+		if err != nil {
+			return err
+		}
+		p.extensions = extensions
 	} else {
 		log.Debug("Read EnterpriseContractPolicy as k8s resource")
 		k8s, err := kubernetes.NewClient(ctx)
@@ -328,22 +417,37 @@ func checkOpts(ctx context.Context, p *policy) (*cosign.CheckOpts, error) {
 		}
 	} else {
 		log.Debug("Using keyless workflow")
-		log.Debugf("TUF_ROOT=%s", os.Getenv("TUF_ROOT"))
 		opts.Identities = []cosign.Identity{p.identity}
 
-		// Get Fulcio certificates
-		if opts.RootCerts, err = fulcio.GetRoots(); err != nil {
-			return nil, err
-		}
-		if opts.IntermediateCerts, err = fulcio.GetIntermediates(); err != nil {
-			return nil, err
-		}
+		if p.trustedRoot != nil {
+			log.Debug("Using the provided trusted root instead of TUF")
+			if err := p.trustedRoot.keylessMaterial(&opts); err != nil {
+				return nil, err
+			}
+		} else {
+			log.Debugf("TUF_ROOT=%s", os.Getenv("TUF_ROOT"))
+			// Get Fulcio certificates
+			if opts.RootCerts, err = fulcio.GetRoots(); err != nil {
+				return nil, err
+			}
+			if opts.IntermediateCerts, err = fulcio.GetIntermediates(); err != nil {
+				return nil, err
+			}
 
-		// Get Certificate Transparency Log public keys
-		if opts.CTLogPubKeys, err = cosign.GetCTLogPubs(ctx); err != nil {
-			return nil, err
+			// Get Certificate Transparency Log public keys
+			if opts.CTLogPubKeys, err = cosign.GetCTLogPubs(ctx); err != nil {
+				return nil, err
+			}
+			log.Debug("Retrieved Rekor public keys")
 		}
-		log.Debug("Retrieved Rekor public keys")
+	}
+
+	tsaCerts := append([]*x509.Certificate{}, p.tsaCerts...)
+	if p.trustedRoot != nil {
+		tsaCerts = append(tsaCerts, p.trustedRoot.tsaCerts...)
+	}
+	if err := timestampAuthorityMaterial(&opts, tsaCerts); err != nil {
+		return nil, err
 	}
 
 	opts.IgnoreTlog = p.ignoreRekor
@@ -368,15 +472,70 @@ func checkOpts(ctx context.Context, p *policy) (*cosign.CheckOpts, error) {
 			log.Debugf("Rekor client created, url %q", rekorURL)
 		}
 
-		if opts.RekorPubKeys, err = cosign.GetRekorPubs(ctx); err != nil {
-			return nil, err
+		if p.trustedRoot != nil {
+			if err := p.trustedRoot.transparencyLogMaterial(&opts); err != nil {
+				return nil, err
+			}
+		} else {
+			if opts.RekorPubKeys, err = cosign.GetRekorPubs(ctx); err != nil {
+				return nil, err
+			}
+			log.Debug("Retrieved Rekor public keys")
 		}
-		log.Debug("Retrieved Rekor public keys")
 	}
 
 	return &opts, nil
 }
 
+// SignatureOptions configure the verification of signatures independently of a policy, e.g.
+// of the images referenced by the image being validated.
+type SignatureOptions struct {
+	Identity cosign.Identity
+	// IgnoreRekor can only be set if the policy ignores Rekor, whose setting is used otherwise.
+	IgnoreRekor bool
+	// PublicKey is the public key in PEM format. References to keys, e.g. files or KMS keys,
+	// are not accepted.
+	PublicKey string
+	// RekorURL must be blank, or the Rekor URL of the policy.
+	RekorURL string
+}
+
+// NewCheckOpts returns the options to verify signatures with the public key or, when no public
+// key is set, with the keyless identity. The options are built the same way as the ones of a
+// policy, using the trusted root, timestamp authority, Rekor URL and whether to ignore Rekor of
+// the given policy. The options cannot loosen the verification required by the policy.
+func NewCheckOpts(ctx context.Context, parent Policy, opts SignatureOptions) (*cosign.CheckOpts, error) {
+	p := policy{}
+	if parent, ok := parent.(*policy); ok && parent != nil {
+		p.RekorUrl = parent.RekorUrl
+		p.trustedRoot = parent.trustedRoot
+		p.tsaCerts = parent.tsaCerts
+		p.ignoreRekor = parent.ignoreRekor
+	}
+
+	if opts.IgnoreRekor && !p.ignoreRekor {
+		return nil, errors.New("rekor cannot be ignored, the policy requires it")
+	}
+
+	if opts.RekorURL != "" && opts.RekorURL != p.RekorUrl {
+		return nil, fmt.Errorf("rekor URL %q does not match the Rekor URL of the policy", opts.RekorURL)
+	}
+
+	if opts.PublicKey != "" && !strings.Contains(opts.PublicKey, "-----BEGIN PUBLIC KEY-----") {
+		return nil, errors.New("public key must be provided in PEM format")
+	}
+	p.PublicKey = opts.PublicKey
+
+	if p.Keyless() {
+		if err := validateIdentity(opts.Identity); err != nil {
+			return nil, err
+		}
+		p.identity = opts.Identity
+	}
+
+	return checkOpts(ctx, &p)
+}
+
 type signatureClient interface {
 	publicKeyFromKeyRef(context.Context, string) (sigstoreSig.Verifier, error)
 }
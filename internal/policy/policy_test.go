@@ -33,6 +33,7 @@ import (
 	cosignSig "github.com/sigstore/cosign/v2/pkg/signature"
 	sigstoreSig "github.com/sigstore/sigstore/pkg/signature"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/yaml"
 
@@ -421,6 +422,119 @@ func TestCheckOpts(t *testing.T) {
 	}
 }
 
+func TestNewCheckOpts(t *testing.T) {
+	identity := cosign.Identity{Subject: "my-subject", Issuer: "my-issuer"}
+
+	cases := []struct {
+		name          string
+		parent        Policy
+		opts          SignatureOptions
+		expectKeyless bool
+		expectRekor   bool
+		expectNoTlog  bool
+		err           string
+	}{
+		{
+			name: "public key",
+			opts: SignatureOptions{PublicKey: utils.TestPublicKey},
+		},
+		{
+			name:         "public key ignoring rekor",
+			parent:       &policy{ignoreRekor: true},
+			opts:         SignatureOptions{PublicKey: utils.TestPublicKey, IgnoreRekor: true},
+			expectNoTlog: true,
+		},
+		{
+			name:         "ignoring rekor inherited from the policy",
+			parent:       &policy{ignoreRekor: true},
+			opts:         SignatureOptions{PublicKey: utils.TestPublicKey},
+			expectNoTlog: true,
+		},
+		{
+			name:   "ignoring rekor required by the policy",
+			parent: &policy{EnterpriseContractPolicySpec: ecc.EnterpriseContractPolicySpec{RekorUrl: utils.TestRekorURL}},
+			opts:   SignatureOptions{PublicKey: utils.TestPublicKey, IgnoreRekor: true},
+			err:    "rekor cannot be ignored, the policy requires it",
+		},
+		{
+			name: "ignoring rekor without a policy",
+			opts: SignatureOptions{PublicKey: utils.TestPublicKey, IgnoreRekor: true},
+			err:  "rekor cannot be ignored, the policy requires it",
+		},
+		{
+			name:        "public key with rekor url of the policy",
+			parent:      &policy{EnterpriseContractPolicySpec: ecc.EnterpriseContractPolicySpec{RekorUrl: utils.TestRekorURL}},
+			opts:        SignatureOptions{PublicKey: utils.TestPublicKey, RekorURL: utils.TestRekorURL},
+			expectRekor: true,
+		},
+		{
+			name:        "rekor url inherited from the policy",
+			parent:      &policy{EnterpriseContractPolicySpec: ecc.EnterpriseContractPolicySpec{RekorUrl: utils.TestRekorURL}},
+			opts:        SignatureOptions{PublicKey: utils.TestPublicKey},
+			expectRekor: true,
+		},
+		{
+			name:   "rekor url not of the policy",
+			parent: &policy{EnterpriseContractPolicySpec: ecc.EnterpriseContractPolicySpec{RekorUrl: utils.TestRekorURL}},
+			opts:   SignatureOptions{PublicKey: utils.TestPublicKey, RekorURL: "https://rekor.evil.example"},
+			err:    `rekor URL "https://rekor.evil.example" does not match the Rekor URL of the policy`,
+		},
+		{
+			name: "rekor url without a policy",
+			opts: SignatureOptions{PublicKey: utils.TestPublicKey, RekorURL: utils.TestRekorURL},
+			err:  "does not match the Rekor URL of the policy",
+		},
+		{
+			name: "public key reference",
+			opts: SignatureOptions{PublicKey: "k8s://tekton-chains/public-key"},
+			err:  "public key must be provided in PEM format",
+		},
+		{
+			name: "public key file",
+			opts: SignatureOptions{PublicKey: "/etc/passwd"},
+			err:  "public key must be provided in PEM format",
+		},
+		{
+			name:          "keyless",
+			opts:          SignatureOptions{Identity: identity},
+			expectKeyless: true,
+		},
+		{
+			name: "keyless without identity",
+			opts: SignatureOptions{},
+			err:  "certificate OIDC issuer must be provided for keyless workflow",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			utils.SetTestRekorPublicKey(t)
+			utils.SetTestFulcioRoots(t)
+			utils.SetTestCTLogPublicKey(t)
+
+			opts, err := NewCheckOpts(context.Background(), c.parent, c.opts)
+			if c.err != "" {
+				assert.Nil(t, opts)
+				assert.ErrorContains(t, err, c.err)
+				return
+			}
+			require.NoError(t, err)
+
+			assert.Equal(t, c.expectNoTlog, opts.IgnoreTlog)
+			assert.Equal(t, c.expectRekor, opts.RekorClient != nil)
+
+			if c.expectKeyless {
+				assert.Empty(t, opts.SigVerifier)
+				assert.Equal(t, []cosign.Identity{identity}, opts.Identities)
+				assert.NotEmpty(t, opts.RootCerts)
+			} else {
+				assert.NotEmpty(t, opts.SigVerifier)
+				assert.Empty(t, opts.Identities)
+			}
+		})
+	}
+}
+
 func TestPublicKeyPEM(t *testing.T) {
 	cases := []struct {
 		name              string
@@ -651,3 +765,25 @@ func toYAML(policy any) string {
 	}
 	return string(inline)
 }
+
+func TestNewInertPolicyAt(t *testing.T) {
+	p, err := NewInertPolicyAt(context.Background(), `{
+		"sources": [
+			{"name": "a", "policy": ["x", "y"], "rulePrecedence": "last"}
+		]
+	}`, "2001-02-03")
+	assert.NoError(t, err)
+
+	assert.Equal(t, time.Date(2001, 2, 3, 0, 0, 0, 0, time.UTC), p.EffectiveTime())
+	assert.Equal(t, RulePrecedenceLast, p.Extensions().SourceOptions(p.Spec().Sources[0]).Precedence())
+
+	_, err = NewInertPolicyAt(context.Background(), `{"sources": [{"policy": ["x"]}]}`, "")
+	assert.ErrorContains(t, err, "invalid policy time argument")
+
+	_, err = NewInertPolicyAt(context.Background(), `{
+		"sources": [
+			{"policy": ["x"], "rulePrecedence": "random"}
+		]
+	}`, Now)
+	assert.Error(t, err)
+}
@@ -0,0 +1,159 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package scaffold generates the files of a new policy repository from templates.
+package scaffold
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// The common directory holds the files generated for every template, the other directories
+// hold the files of each template. Files ending in .tmpl are rendered using text/template with
+// the Params, and written without the .tmpl suffix.
+//
+//go:embed templates
+var templates embed.FS
+
+const (
+	templatesDir = "templates"
+	commonDir    = "common"
+	tmplSuffix   = ".tmpl"
+)
+
+// DefaultTemplate is the template used if none is selected.
+const DefaultTemplate = "default"
+
+// Params are the values the .tmpl files are rendered with.
+type Params struct {
+	// Name of the policy repository, used as the name of the policy configuration.
+	Name string
+	// Dir is the absolute path of the policy repository, the policy configuration refers to
+	// its policy and data directories.
+	Dir string
+	// Template is the name of the template used.
+	Template string
+}
+
+// File is a file of the policy repository.
+type File struct {
+	// Path relative to the root of the policy repository.
+	Path    string
+	Content []byte
+}
+
+// Templates returns the names of the available templates, sorted.
+func Templates() []string {
+	entries, err := templates.ReadDir(templatesDir)
+	if err != nil {
+		// the templates are embedded, this cannot happen
+		panic(err)
+	}
+
+	var names []string
+	for _, e := range entries {
+		if e.IsDir() && e.Name() != commonDir {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+var invalidNameCharacters = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// Name returns a name for the policy repository in the directory, usable as the name of a
+// Kubernetes resource.
+func Name(dir string) string {
+	name := invalidNameCharacters.ReplaceAllString(strings.ToLower(path.Base(dir)), "-")
+	name = strings.Trim(name, "-")
+	if name == "" {
+		return "policy"
+	}
+
+	return name
+}
+
+// Files returns the files of the policy repository generated from the template, sorted by
+// path.
+func Files(params Params) ([]File, error) {
+	if params.Template == "" {
+		params.Template = DefaultTemplate
+	}
+
+	if !slices.Contains(Templates(), params.Template) {
+		return nil, fmt.Errorf("unknown template %q, available templates: %s", params.Template, strings.Join(Templates(), ", "))
+	}
+
+	var files []File
+	for _, dir := range []string{commonDir, params.Template} {
+		root := path.Join(templatesDir, dir)
+		err := fs.WalkDir(templates, root, func(p string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+
+			content, err := templates.ReadFile(p)
+			if err != nil {
+				return err
+			}
+
+			rel := strings.TrimPrefix(p, root+"/")
+			if strings.HasSuffix(rel, tmplSuffix) {
+				rel = strings.TrimSuffix(rel, tmplSuffix)
+				if content, err = render(rel, content, params); err != nil {
+					return err
+				}
+			}
+
+			files = append(files, File{Path: rel, Content: content})
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Path < files[j].Path
+	})
+
+	return files, nil
+}
+
+func render(name string, content []byte, params Params) ([]byte, error) {
+	t, err := template.New(name).Option("missingkey=error").Parse(string(content))
+	if err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	if err := t.Execute(&b, params); err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
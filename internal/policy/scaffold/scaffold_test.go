@@ -0,0 +1,115 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unit
+
+package scaffold
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/enterprise-contract/ec-cli/internal/policy/tester"
+)
+
+func TestTemplates(t *testing.T) {
+	assert.Equal(t, []string{"default", "sbom", "tekton"}, Templates())
+}
+
+func TestName(t *testing.T) {
+	cases := []struct {
+		dir      string
+		expected string
+	}{
+		{dir: "/home/user/my-policy", expected: "my-policy"},
+		{dir: "/home/user/My_Policies.git", expected: "my-policies-git"},
+		{dir: "/", expected: "policy"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.dir, func(t *testing.T) {
+			assert.Equal(t, c.expected, Name(c.dir))
+		})
+	}
+}
+
+func TestFiles(t *testing.T) {
+	files, err := Files(Params{Name: "my-policy", Dir: "/src/my-policy"})
+	require.NoError(t, err)
+
+	paths := make([]string, 0, len(files))
+	byPath := map[string]string{}
+	for _, f := range files {
+		paths = append(paths, f.Path)
+		byPath[f.Path] = string(f.Content)
+	}
+
+	assert.Equal(t, []string{
+		"README.md",
+		"data/rule_data.yml",
+		"policy-input.json",
+		"policy.yaml",
+		"policy/lib/lib.rego",
+		"policy/lib/lib_test.rego",
+		"policy/pipeline/required_tasks.rego",
+		"policy/pipeline/required_tasks_test.rego",
+		"policy/release/labels.rego",
+		"policy/release/labels_test.rego",
+	}, paths)
+
+	assert.Contains(t, byPath["policy.yaml"], "  name: my-policy\n")
+	assert.Contains(t, byPath["policy.yaml"], "    - /src/my-policy/policy/release\n")
+	assert.Contains(t, byPath["README.md"], "created from the `default` template")
+}
+
+func TestFilesUnknownTemplate(t *testing.T) {
+	_, err := Files(Params{Template: "nope"})
+	assert.EqualError(t, err, `unknown template "nope", available templates: default, sbom, tekton`)
+}
+
+// TestTemplatesPass makes sure the tests of the rules of each template pass, the rules are
+// annotated and fully covered by the tests.
+func TestTemplatesPass(t *testing.T) {
+	for _, template := range Templates() {
+		t.Run(template, func(t *testing.T) {
+			dir := t.TempDir()
+			files, err := Files(Params{Name: "test", Dir: dir, Template: template})
+			require.NoError(t, err)
+
+			for _, f := range files {
+				p := filepath.Join(dir, f.Path)
+				require.NoError(t, os.MkdirAll(filepath.Dir(p), 0755))
+				require.NoError(t, os.WriteFile(p, f.Content, 0600))
+			}
+
+			report, err := tester.Run(context.Background(), tester.Options{
+				Paths:    []string{filepath.Join(dir, "policy")},
+				Coverage: true,
+			})
+			require.NoError(t, err)
+
+			assert.NotEmpty(t, report.Tests)
+			assert.Equal(t, len(report.Tests), report.Passed())
+			assert.Empty(t, report.Annotations)
+			assert.Empty(t, report.BelowThreshold(100))
+		})
+	}
+}
@@ -0,0 +1,30 @@
+#
+# METADATA
+# description: >-
+#   Helpers shared by the policy rules.
+#
+package lib
+
+import future.keywords.if
+
+# result_helper returns the result of a deny or warn rule. The code of the result is made of
+# the name of the package and the short_name annotation of the rule, and the message is the
+# failure_msg annotation of the rule formatted with the provided parameters.
+result_helper(chain, params) := {
+	"code": _code(chain),
+	"msg": sprintf(chain[0].annotations.custom.failure_msg, params),
+}
+
+_code(chain) := code if {
+	path := chain[0].path
+	code := sprintf("%s.%s", [path[count(path) - 2], chain[0].annotations.custom.short_name])
+}
+
+# rule_data returns the value of the key from the ruleData of the policy configuration or, if
+# not set there, from the rule_data of the data sources. An empty list is returned if the key
+# is not set in either.
+rule_data(key) := value if {
+	value := data.rule_data__configuration__[key]
+} else := value if {
+	value := data.rule_data[key]
+} else := []
@@ -0,0 +1,27 @@
+package lib_test
+
+import future.keywords.if
+
+import data.lib
+
+test_result_helper if {
+	chain := [{
+		"annotations": {"custom": {"short_name": "rule", "failure_msg": "Value %q is not allowed"}},
+		"path": ["policy", "release", "pkg", "deny"],
+	}]
+
+	lib.result_helper(chain, ["x"]) == {"code": "pkg.rule", "msg": "Value \"x\" is not allowed"}
+}
+
+test_rule_data if {
+	lib.rule_data("key") == ["data"] with data.rule_data.key as ["data"]
+}
+
+test_rule_data_configuration if {
+	lib.rule_data("key") == ["configuration"] with data.rule_data.key as ["data"]
+		with data.rule_data__configuration__.key as ["configuration"]
+}
+
+test_rule_data_missing if {
+	lib.rule_data("missing") == []
+}
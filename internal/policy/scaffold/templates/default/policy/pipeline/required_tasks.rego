@@ -0,0 +1,40 @@
+#
+# METADATA
+# title: Required tasks
+# description: >-
+#   Checks the tasks of Tekton pipeline definitions.
+#
+package policy.pipeline.required_tasks
+
+import future.keywords.contains
+import future.keywords.if
+import future.keywords.in
+
+import data.lib
+
+# METADATA
+# title: Required tasks
+# description: >-
+#   The pipeline runs each of the tasks listed in the required_pipeline_tasks rule data.
+# custom:
+#   short_name: missing
+#   failure_msg: The required %q task is missing from the pipeline
+#   solution: >-
+#     Add the task to the pipeline definition.
+#   collections:
+#   - minimal
+#
+deny contains result if {
+	input.kind == "Pipeline"
+	some required in lib.rule_data("required_pipeline_tasks")
+	not required in _task_names
+	result := lib.result_helper(rego.metadata.chain(), [required])
+}
+
+_task_names contains task.taskRef.name if {
+	some task in input.spec.tasks
+}
+
+_task_names contains task.taskRef.name if {
+	some task in input.spec.finally
+}
@@ -0,0 +1,29 @@
+package policy.pipeline.required_tasks_test
+
+import future.keywords.if
+
+import data.policy.pipeline.required_tasks
+
+test_required_tasks if {
+	pipeline := {
+		"kind": "Pipeline",
+		"spec": {
+			"tasks": [{"name": "clone", "taskRef": {"name": "git-clone"}}],
+			"finally": [{"name": "build", "taskRef": {"name": "buildah"}}],
+		},
+	}
+	required_tasks.deny == set() with input as pipeline
+		with data.rule_data.required_pipeline_tasks as ["git-clone", "buildah"]
+}
+
+test_missing_tasks if {
+	pipeline := {"kind": "Pipeline", "spec": {"tasks": [{"name": "clone", "taskRef": {"name": "git-clone"}}]}}
+	expected := {{"code": "required_tasks.missing", "msg": "The required \"buildah\" task is missing from the pipeline"}}
+	required_tasks.deny == expected with input as pipeline
+		with data.rule_data.required_pipeline_tasks as ["git-clone", "buildah"]
+}
+
+test_not_a_pipeline if {
+	required_tasks.deny == set() with input as {"kind": "Task"}
+		with data.rule_data.required_pipeline_tasks as ["git-clone"]
+}
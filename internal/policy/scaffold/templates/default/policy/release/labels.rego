@@ -0,0 +1,49 @@
+#
+# METADATA
+# title: Image labels
+# description: >-
+#   Checks the labels set on the image.
+#
+package policy.release.labels
+
+import future.keywords.contains
+import future.keywords.if
+import future.keywords.in
+
+import data.lib
+
+# METADATA
+# title: Required labels
+# description: >-
+#   The image has each of the labels listed in the required_labels rule data.
+# custom:
+#   short_name: required
+#   failure_msg: The required %q label is missing
+#   solution: >-
+#     Set the label on the image, e.g. using the LABEL instruction of the Containerfile.
+#   collections:
+#   - minimal
+#
+deny contains result if {
+	some label in lib.rule_data("required_labels")
+	not input.image.config.Labels[label]
+	result := lib.result_helper(rego.metadata.chain(), [label])
+}
+
+# METADATA
+# title: Deprecated labels
+# description: >-
+#   The image does not have any of the labels listed in the deprecated_labels rule data.
+# custom:
+#   short_name: deprecated
+#   failure_msg: The %q label is deprecated, use the %q label instead
+#   solution: >-
+#     Replace the deprecated label on the image.
+#   collections:
+#   - minimal
+#
+warn contains result if {
+	some deprecated in lib.rule_data("deprecated_labels")
+	input.image.config.Labels[deprecated.name]
+	result := lib.result_helper(rego.metadata.chain(), [deprecated.name, deprecated.replacement])
+}
@@ -0,0 +1,30 @@
+package policy.release.labels_test
+
+import future.keywords.if
+
+import data.policy.release.labels
+
+test_required_labels if {
+	labels.deny == set() with input.image.config.Labels as {"name": "app", "version": "1.0"}
+		with data.rule_data.required_labels as ["name", "version"]
+}
+
+test_missing_labels if {
+	expected := {
+		{"code": "labels.required", "msg": "The required \"name\" label is missing"},
+		{"code": "labels.required", "msg": "The required \"version\" label is missing"},
+	}
+	labels.deny == expected with input.image.config as {}
+		with data.rule_data.required_labels as ["name", "version"]
+}
+
+test_deprecated_labels if {
+	expected := {{"code": "labels.deprecated", "msg": "The \"INSTALL\" label is deprecated, use the \"install\" label instead"}}
+	labels.warn == expected with input.image.config.Labels as {"INSTALL": "podman run app"}
+		with data.rule_data.deprecated_labels as [{"name": "INSTALL", "replacement": "install"}]
+}
+
+test_no_deprecated_labels if {
+	labels.warn == set() with input.image.config.Labels as {"install": "podman run app"}
+		with data.rule_data.deprecated_labels as [{"name": "INSTALL", "replacement": "install"}]
+}
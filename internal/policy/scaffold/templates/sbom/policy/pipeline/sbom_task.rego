@@ -0,0 +1,36 @@
+#
+# METADATA
+# title: SBOM task
+# description: >-
+#   Checks that Tekton pipeline definitions generate an SBOM.
+#
+package policy.pipeline.sbom_task
+
+import future.keywords.contains
+import future.keywords.if
+import future.keywords.in
+
+import data.lib
+
+# METADATA
+# title: SBOM generated
+# description: >-
+#   The pipeline runs one of the tasks listed in the sbom_tasks rule data.
+# custom:
+#   short_name: missing
+#   failure_msg: The pipeline does not run any of the %v tasks generating an SBOM
+#   solution: >-
+#     Add a task generating an SBOM of the image to the pipeline definition.
+#   collections:
+#   - minimal
+#
+deny contains result if {
+	input.kind == "Pipeline"
+	sbom_tasks := lib.rule_data("sbom_tasks")
+	count({name | some name in sbom_tasks; name in _task_names}) == 0
+	result := lib.result_helper(rego.metadata.chain(), [sbom_tasks])
+}
+
+_task_names contains task.taskRef.name if {
+	some task in input.spec.tasks
+}
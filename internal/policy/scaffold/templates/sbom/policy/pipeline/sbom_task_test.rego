@@ -0,0 +1,23 @@
+package policy.pipeline.sbom_task_test
+
+import future.keywords.if
+
+import data.policy.pipeline.sbom_task
+
+test_sbom_task if {
+	pipeline := {"kind": "Pipeline", "spec": {"tasks": [{"name": "sbom", "taskRef": {"name": "syft-sbom"}}]}}
+	sbom_task.deny == set() with input as pipeline with data.rule_data.sbom_tasks as ["syft-sbom"]
+}
+
+test_missing_sbom_task if {
+	pipeline := {"kind": "Pipeline", "spec": {"tasks": [{"name": "build", "taskRef": {"name": "buildah"}}]}}
+	expected := {{
+		"code": "sbom_task.missing",
+		"msg": "The pipeline does not run any of the [\"syft-sbom\"] tasks generating an SBOM",
+	}}
+	sbom_task.deny == expected with input as pipeline with data.rule_data.sbom_tasks as ["syft-sbom"]
+}
+
+test_not_a_pipeline if {
+	sbom_task.deny == set() with input as {"kind": "Task"} with data.rule_data.sbom_tasks as ["syft-sbom"]
+}
@@ -0,0 +1,91 @@
+#
+# METADATA
+# title: SBOM
+# description: >-
+#   Checks the CycloneDX SBOM attested for the image.
+#
+package policy.release.sbom
+
+import future.keywords.contains
+import future.keywords.if
+import future.keywords.in
+
+import data.lib
+
+# METADATA
+# title: SBOM attested
+# description: >-
+#   A CycloneDX SBOM is attested for the image.
+# custom:
+#   short_name: missing
+#   failure_msg: No CycloneDX SBOM attestation found
+#   solution: >-
+#     Generate a CycloneDX SBOM in the build pipeline and attest it, e.g. using
+#     cosign attest --type cyclonedx.
+#   collections:
+#   - minimal
+#
+deny contains result if {
+	count(_sboms) == 0
+	result := lib.result_helper(rego.metadata.chain(), [])
+}
+
+# METADATA
+# title: Disallowed packages
+# description: >-
+#   The SBOM does not list any of the packages in the disallowed_packages rule data. Packages
+#   are matched by the type and name of their package URL.
+# custom:
+#   short_name: disallowed_package
+#   failure_msg: Package %q is not allowed
+#   solution: >-
+#     Remove the package from the image, or replace it with an allowed one.
+#   collections:
+#   - minimal
+#
+deny contains result if {
+	some component in _components
+	purl := ec.purl.parse(component.purl)
+	some disallowed in lib.rule_data("disallowed_packages")
+	purl.type == disallowed.type
+	purl.name == disallowed.name
+	result := lib.result_helper(rego.metadata.chain(), [component.purl])
+}
+
+# METADATA
+# title: Allowed licenses
+# description: >-
+#   The licenses of the packages in the SBOM can be complied with using only the licenses in
+#   the allowed_licenses rule data.
+# custom:
+#   short_name: license
+#   failure_msg: Package %q is distributed under %q, which is not allowed
+#   solution: >-
+#     Replace the package with one distributed under an allowed license.
+#   collections:
+#   - minimal
+#
+warn contains result if {
+	some component in _components
+	some license in component.licenses
+	expression := _license_expression(license)
+	not ec.spdx.license_satisfies(expression, lib.rule_data("allowed_licenses"))
+	result := lib.result_helper(rego.metadata.chain(), [component.name, expression])
+}
+
+_sboms contains attestation.statement.predicate if {
+	some attestation in input.attestations
+	attestation.statement.predicateType == "https://cyclonedx.org/bom"
+}
+
+_components contains component if {
+	some sbom in _sboms
+	some component in sbom.components
+}
+
+# A license is either an SPDX license expression, or a license identifier.
+_license_expression(license) := expression if {
+	expression := license.expression
+} else := id if {
+	id := license.license.id
+}
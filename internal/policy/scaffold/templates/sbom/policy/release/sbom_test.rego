@@ -0,0 +1,47 @@
+package policy.release.sbom_test
+
+import future.keywords.if
+
+import data.policy.release.sbom
+
+_attestation(components) := {"statement": {
+	"predicateType": "https://cyclonedx.org/bom",
+	"predicate": {"bomFormat": "CycloneDX", "components": components},
+}}
+
+_rule_data := {
+	"disallowed_packages": [{"type": "maven", "name": "log4j-core"}],
+	"allowed_licenses": ["MIT", "Apache-2.0"],
+}
+
+test_allowed if {
+	components := [
+		{"name": "jq", "purl": "pkg:rpm/redhat/jq@1.6-15.el9", "licenses": [{"license": {"id": "MIT"}}]},
+		{"name": "app", "purl": "pkg:golang/example.com/app@v1.0.0", "licenses": [{"expression": "MIT OR GPL-3.0-only"}]},
+	]
+	sbom.deny == set() with input.attestations as [_attestation(components)] with data.rule_data as _rule_data
+	sbom.warn == set() with input.attestations as [_attestation(components)] with data.rule_data as _rule_data
+}
+
+test_missing if {
+	expected := {{"code": "sbom.missing", "msg": "No CycloneDX SBOM attestation found"}}
+	sbom.deny == expected with input.attestations as [] with data.rule_data as _rule_data
+}
+
+test_disallowed_package if {
+	components := [{"name": "log4j-core", "purl": "pkg:maven/org.apache.logging.log4j/log4j-core@2.14.1"}]
+	expected := {{
+		"code": "sbom.disallowed_package",
+		"msg": "Package \"pkg:maven/org.apache.logging.log4j/log4j-core@2.14.1\" is not allowed",
+	}}
+	sbom.deny == expected with input.attestations as [_attestation(components)] with data.rule_data as _rule_data
+}
+
+test_license if {
+	components := [{"name": "readline", "purl": "pkg:rpm/redhat/readline@8.1-4.el9", "licenses": [{"license": {"id": "GPL-3.0-only"}}]}]
+	expected := {{
+		"code": "sbom.license",
+		"msg": "Package \"readline\" is distributed under \"GPL-3.0-only\", which is not allowed",
+	}}
+	sbom.warn == expected with input.attestations as [_attestation(components)] with data.rule_data as _rule_data
+}
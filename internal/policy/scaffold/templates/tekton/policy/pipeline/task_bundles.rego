@@ -0,0 +1,41 @@
+#
+# METADATA
+# title: Task bundles
+# description: >-
+#   Checks the bundles the tasks of Tekton pipeline definitions are loaded from.
+#
+package policy.pipeline.task_bundles
+
+import future.keywords.contains
+import future.keywords.if
+import future.keywords.in
+
+import data.lib
+
+# METADATA
+# title: Trusted task bundles
+# description: >-
+#   The tasks of the pipeline are loaded, using the bundles resolver, from bundles with a
+#   reference starting with one of the prefixes listed in the allowed_bundle_prefixes rule data.
+# custom:
+#   short_name: untrusted_bundle
+#   failure_msg: Task %q is not loaded from a trusted bundle
+#   solution: >-
+#     Reference the task from one of the trusted bundles using the bundles resolver.
+#   collections:
+#   - minimal
+#
+deny contains result if {
+	input.kind == "Pipeline"
+	some task in array.concat(object.get(input.spec, "tasks", []), object.get(input.spec, "finally", []))
+	not _trusted(task)
+	result := lib.result_helper(rego.metadata.chain(), [task.name])
+}
+
+_trusted(task) if {
+	task.taskRef.resolver == "bundles"
+	some param in task.taskRef.params
+	param.name == "bundle"
+	some prefix in lib.rule_data("allowed_bundle_prefixes")
+	startswith(param.value, prefix)
+}
@@ -0,0 +1,39 @@
+package policy.pipeline.task_bundles_test
+
+import future.keywords.if
+
+import data.policy.pipeline.task_bundles
+
+_task(name, bundle) := {"name": name, "taskRef": {"resolver": "bundles", "params": [
+	{"name": "name", "value": name},
+	{"name": "bundle", "value": bundle},
+	{"name": "kind", "value": "task"},
+]}}
+
+test_trusted_bundles if {
+	pipeline := {"kind": "Pipeline", "spec": {
+		"tasks": [_task("git-clone", "quay.io/org/tasks/git-clone:0.1")],
+		"finally": [_task("summary", "quay.io/org/tasks/summary:0.1")],
+	}}
+	task_bundles.deny == set() with input as pipeline
+		with data.rule_data.allowed_bundle_prefixes as ["quay.io/org/tasks/"]
+}
+
+test_untrusted_bundle if {
+	pipeline := {"kind": "Pipeline", "spec": {"tasks": [_task("buildah", "docker.io/someone/buildah:latest")]}}
+	expected := {{"code": "task_bundles.untrusted_bundle", "msg": "Task \"buildah\" is not loaded from a trusted bundle"}}
+	task_bundles.deny == expected with input as pipeline
+		with data.rule_data.allowed_bundle_prefixes as ["quay.io/org/tasks/"]
+}
+
+test_not_a_bundle if {
+	pipeline := {"kind": "Pipeline", "spec": {"tasks": [{"name": "buildah", "taskRef": {"name": "buildah"}}]}}
+	expected := {{"code": "task_bundles.untrusted_bundle", "msg": "Task \"buildah\" is not loaded from a trusted bundle"}}
+	task_bundles.deny == expected with input as pipeline
+		with data.rule_data.allowed_bundle_prefixes as ["quay.io/org/tasks/"]
+}
+
+test_not_a_pipeline if {
+	task_bundles.deny == set() with input as {"kind": "Task"}
+		with data.rule_data.allowed_bundle_prefixes as ["quay.io/org/tasks/"]
+}
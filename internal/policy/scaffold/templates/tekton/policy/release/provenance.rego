@@ -0,0 +1,91 @@
+#
+# METADATA
+# title: Provenance
+# description: >-
+#   Checks the SLSA provenance attested by Tekton Chains for the pipeline run that built the
+#   image.
+#
+package policy.release.provenance
+
+import future.keywords.contains
+import future.keywords.if
+import future.keywords.in
+
+import data.lib
+
+# METADATA
+# title: Provenance attested
+# description: >-
+#   A SLSA provenance of the Tekton pipeline run that built the image is attested.
+# custom:
+#   short_name: missing
+#   failure_msg: No SLSA provenance attestation of a Tekton pipeline run found
+#   solution: >-
+#     Build the image with a Tekton pipeline and have Tekton Chains attest its provenance.
+#   collections:
+#   - minimal
+#
+deny contains result if {
+	count(_provenances) == 0
+	result := lib.result_helper(rego.metadata.chain(), [])
+}
+
+# METADATA
+# title: Required tasks
+# description: >-
+#   The pipeline run that built the image ran each of the tasks listed in the required_tasks
+#   rule data.
+# custom:
+#   short_name: required_task
+#   failure_msg: The required %q task was not run
+#   solution: >-
+#     Add the task to the pipeline building the image.
+#   collections:
+#   - minimal
+#
+deny contains result if {
+	count(_provenances) > 0
+	some required in lib.rule_data("required_tasks")
+	not required in _task_names
+	result := lib.result_helper(rego.metadata.chain(), [required])
+}
+
+# METADATA
+# title: Trusted task bundles
+# description: >-
+#   The tasks run by the pipeline run are loaded from bundles with a reference starting with
+#   one of the prefixes listed in the allowed_bundle_prefixes rule data.
+# custom:
+#   short_name: untrusted_bundle
+#   failure_msg: Task %q was loaded from the untrusted bundle %q
+#   solution: >-
+#     Use tasks from the trusted bundles in the pipeline building the image.
+#   collections:
+#   - minimal
+#
+warn contains result if {
+	some task in _tasks
+	bundle := object.get(task, ["ref", "bundle"], "")
+	not _trusted(bundle)
+	result := lib.result_helper(rego.metadata.chain(), [task.name, bundle])
+}
+
+_provenances contains attestation.statement.predicate if {
+	some attestation in input.attestations
+	attestation.statement.predicateType == "https://slsa.dev/provenance/v0.2"
+	attestation.statement.predicate.buildType == "tekton.dev/v1beta1/PipelineRun"
+}
+
+_tasks contains task if {
+	some provenance in _provenances
+	some task in provenance.buildConfig.tasks
+}
+
+_task_names contains task.ref.name if {
+	some task in _tasks
+}
+
+_trusted(bundle) if {
+	some prefix in lib.rule_data("allowed_bundle_prefixes")
+	startswith(bundle, prefix)
+}
@@ -0,0 +1,43 @@
+package policy.release.provenance_test
+
+import future.keywords.if
+
+import data.policy.release.provenance
+
+_attestation(tasks) := {"statement": {
+	"predicateType": "https://slsa.dev/provenance/v0.2",
+	"predicate": {"buildType": "tekton.dev/v1beta1/PipelineRun", "buildConfig": {"tasks": tasks}},
+}}
+
+_rule_data := {
+	"required_tasks": ["git-clone", "buildah"],
+	"allowed_bundle_prefixes": ["quay.io/org/tasks/"],
+}
+
+_clone := {"name": "clone", "ref": {"name": "git-clone", "bundle": "quay.io/org/tasks/git-clone:0.1@sha256:abc"}}
+
+_build := {"name": "build", "ref": {"name": "buildah", "bundle": "quay.io/org/tasks/buildah:0.1@sha256:def"}}
+
+test_trusted_pipeline_run if {
+	provenance.deny == set() with input.attestations as [_attestation([_clone, _build])] with data.rule_data as _rule_data
+	provenance.warn == set() with input.attestations as [_attestation([_clone, _build])] with data.rule_data as _rule_data
+}
+
+test_missing if {
+	expected := {{"code": "provenance.missing", "msg": "No SLSA provenance attestation of a Tekton pipeline run found"}}
+	provenance.deny == expected with input.attestations as [] with data.rule_data as _rule_data
+}
+
+test_required_task if {
+	expected := {{"code": "provenance.required_task", "msg": "The required \"buildah\" task was not run"}}
+	provenance.deny == expected with input.attestations as [_attestation([_clone])] with data.rule_data as _rule_data
+}
+
+test_untrusted_bundle if {
+	build := json.patch(_build, [{"op": "replace", "path": "/ref/bundle", "value": "docker.io/someone/buildah:latest"}])
+	expected := {{
+		"code": "provenance.untrusted_bundle",
+		"msg": "Task \"build\" was loaded from the untrusted bundle \"docker.io/someone/buildah:latest\"",
+	}}
+	provenance.warn == expected with input.attestations as [_attestation([_clone, build])] with data.rule_data as _rule_data
+}
@@ -63,6 +63,53 @@ func GitConfigDownload(ctx context.Context, tmpDir, src string) (string, error)
 	return configFile, nil
 }
 
+// ReadPolicyConfiguration returns the contents of the policy configuration given as a git
+// URL, from which a suitable file is downloaded, or as the path of a JSON or YAML file. Other
+// policy configurations, i.e. inline or references to Kubernetes resources, are returned as
+// they are.
+func ReadPolicyConfiguration(ctx context.Context, policyConfiguration string) (string, error) {
+	fs := utils.FS(ctx)
+
+	// Check if policyConfiguration is a git url, if so, try to download a config file from git
+	if SourceIsGit(policyConfiguration) {
+		log.Debugf("Fetching policy config from git url %s", policyConfiguration)
+
+		// Create a temporary dir to download the config. It will be different to the
+		// workdir used later for downloading policy sources, but it won't matter
+		// because this dir is not used again once the config file has been read.
+		tmpDir, err := utils.CreateWorkDir(fs)
+		if err != nil {
+			return "", err
+		}
+		defer utils.CleanupWorkDir(fs, tmpDir)
+
+		// Git download and find a suitable config file
+		configFile, err := GitConfigDownload(ctx, tmpDir, policyConfiguration)
+		if err != nil {
+			return "", err
+		}
+
+		// Continuing with the name of the newly downloaded file means the file is read below
+		policyConfiguration = configFile
+	}
+
+	// Check if policyConfiguration is a file path, if so, read it
+	if utils.HasJsonOrYamlExt(policyConfiguration) {
+		policyBytes, err := afero.ReadFile(fs, policyConfiguration)
+		if err != nil {
+			return "", err
+		}
+		// Check for empty file as that would cause a false "success"
+		if len(policyBytes) == 0 {
+			return "", fmt.Errorf("file %s is empty", policyConfiguration)
+		}
+
+		return string(policyBytes), nil
+	}
+
+	return policyConfiguration, nil
+}
+
 var policyFileBaseNames = []string{
 	".ec/policy",
 	"policy",
@@ -103,3 +103,27 @@ func TestSourceIsGit(t *testing.T) {
 		assert.Equal(t, tt.want, SourceIsGit(tt.src))
 	}
 }
+
+func TestReadPolicyConfiguration(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	ctx := utils.WithFS(context.Background(), fs)
+
+	assert.NoError(t, afero.WriteFile(fs, "policy.yaml", []byte("sources: []"), 0644))
+	assert.NoError(t, afero.WriteFile(fs, "empty.json", []byte{}, 0644))
+
+	got, err := ReadPolicyConfiguration(ctx, "policy.yaml")
+	assert.NoError(t, err)
+	assert.Equal(t, "sources: []", got)
+
+	_, err = ReadPolicyConfiguration(ctx, "empty.json")
+	assert.EqualError(t, err, "file empty.json is empty")
+
+	_, err = ReadPolicyConfiguration(ctx, "missing.yaml")
+	assert.Error(t, err)
+
+	for _, ref := range []string{"", "namespace/name", `{"sources":[]}`} {
+		got, err := ReadPolicyConfiguration(ctx, ref)
+		assert.NoError(t, err)
+		assert.Equal(t, ref, got)
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package policy
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+
+	ecc "github.com/enterprise-contract/enterprise-contract-controller/api/v1alpha1"
+	"github.com/hashicorp/go-multierror"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/enterprise-contract/ec-cli/internal/opa/rule"
+)
+
+// Rule precedences, determine how rules with the same code defined in more than one policy
+// source of a source group are handled.
+const (
+	// RulePrecedenceError fails the evaluation, this is the default.
+	RulePrecedenceError = "error"
+	// RulePrecedenceFirst uses the rule from the first policy source defining it.
+	RulePrecedenceFirst = "first"
+	// RulePrecedenceLast uses the rule from the last policy source defining it.
+	RulePrecedenceLast = "last"
+)
+
+var ruleNamespacePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+var codePrefixPattern = regexp.MustCompile(`^[a-zA-Z0-9_]+(\.[a-zA-Z0-9_]+)*$`)
+
+// SourceOptions holds ec specific configuration of a source group. The attributes are set on
+// the source group itself, alongside its name and policy sources.
+type SourceOptions struct {
+	// Name and Policy identify the source group the options belong to.
+	Name   string   `json:"name,omitempty"`
+	Policy []string `json:"policy,omitempty"`
+	// RulePrecedence determines how rules with the same code defined in more than one policy
+	// source of the group are handled, one of "error" (default), "first" or "last".
+	RulePrecedence string `json:"rulePrecedence,omitempty"`
+	// RuleNamespaces map policy source URLs to namespaces. The codes of the rules found in a
+	// policy source with a namespace are prefixed with the namespace and a slash, e.g.
+	// "org/pkg.rule", so they do not clash with the codes of the rules in other sources.
+	RuleNamespaces map[string]string `json:"ruleNamespaces,omitempty"`
+	// BlobSizeLimit is the maximum size of the blobs the rules of the group can read via the
+	// ec.oci.blob rego function, as a quantity, e.g. "50Mi". Defaults to 10Mi.
+	BlobSizeLimit string `json:"blobSizeLimit,omitempty"`
+	// DocumentationUrl is the template of the documentation URL of the rules of the group not
+	// declaring, or inheriting from their packages, the documentation_url annotation, e.g.
+	// "https://docs.example.com/{{ .CodePackage }}.html#{{ .ShortName }}".
+	DocumentationUrl string `json:"documentationUrl,omitempty"`
+	// CodePrefixes are the package prefixes removed from the packages of the rules of the
+	// group not declaring, or inheriting from their packages, the code_prefixes annotation to
+	// form their codes, e.g. "org.policy". The codes need to match the codes the rules report
+	// in their results.
+	CodePrefixes []string `json:"codePrefixes,omitempty"`
+}
+
+// Precedence returns the rule precedence, defaulting to RulePrecedenceError.
+func (o SourceOptions) Precedence() string {
+	if o.RulePrecedence == "" {
+		return RulePrecedenceError
+	}
+
+	return o.RulePrecedence
+}
+
+// BlobLimit returns the blob size limit in bytes, or 0 if none was set.
+func (o SourceOptions) BlobLimit() int64 {
+	if o.BlobSizeLimit == "" {
+		return 0
+	}
+
+	q, err := resource.ParseQuantity(o.BlobSizeLimit)
+	if err != nil {
+		return 0
+	}
+
+	return q.Value()
+}
+
+// SourceOptions returns the options of the given source group, or empty options if none
+// were provided for it.
+func (e Extensions) SourceOptions(src ecc.Source) SourceOptions {
+	for _, o := range e.Sources {
+		if o.Name == src.Name && slices.Equal(o.Policy, src.Policy) {
+			return o
+		}
+	}
+
+	return SourceOptions{}
+}
+
+func validateSourceOptions(options []SourceOptions) error {
+	var errs error
+	for i, o := range options {
+		if !slices.Contains([]string{"", RulePrecedenceError, RulePrecedenceFirst, RulePrecedenceLast}, o.RulePrecedence) {
+			errs = multierror.Append(errs, fmt.Errorf("source group at index %d has an invalid rulePrecedence %q, expecting one of: %s, %s, %s",
+				i, o.RulePrecedence, RulePrecedenceError, RulePrecedenceFirst, RulePrecedenceLast))
+		}
+		if o.BlobSizeLimit != "" {
+			if q, err := resource.ParseQuantity(o.BlobSizeLimit); err != nil || q.Sign() <= 0 {
+				errs = multierror.Append(errs, fmt.Errorf("source group at index %d has an invalid blobSizeLimit %q, expecting a positive quantity, e.g. 50Mi", i, o.BlobSizeLimit))
+			}
+		}
+		if o.DocumentationUrl != "" {
+			if _, err := rule.RenderDocumentationUrl(o.DocumentationUrl, rule.Info{}); err != nil {
+				errs = multierror.Append(errs, fmt.Errorf("source group at index %d has an invalid documentationUrl template: %w", i, err))
+			}
+		}
+		for _, prefix := range o.CodePrefixes {
+			if !codePrefixPattern.MatchString(prefix) {
+				errs = multierror.Append(errs, fmt.Errorf("source group at index %d has an invalid code prefix %q, expecting a package, e.g. org.policy", i, prefix))
+			}
+		}
+		for url, namespace := range o.RuleNamespaces {
+			if !slices.Contains(o.Policy, url) {
+				errs = multierror.Append(errs, fmt.Errorf("source group at index %d has a rule namespace for %q, which is not one of its policy sources", i, url))
+			}
+			if !ruleNamespacePattern.MatchString(namespace) {
+				errs = multierror.Append(errs, fmt.Errorf("source group at index %d has an invalid rule namespace %q, only letters, digits, \"_\" and \"-\" are allowed", i, namespace))
+			}
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,75 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unit
+
+package policy
+
+import (
+	"testing"
+
+	ecc "github.com/enterprise-contract/enterprise-contract-controller/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSourceOptions(t *testing.T) {
+	extensions, err := parseExtensions(`{
+		"sources": [
+			{"name": "upstream", "policy": ["a"]},
+			{"name": "overlay", "policy": ["a", "b"], "rulePrecedence": "last", "ruleNamespaces": {"b": "org"}, "blobSizeLimit": "50Mi",
+			 "documentationUrl": "https://docs.example.com/{{ .CodePackage }}", "codePrefixes": ["org.policy"]}
+		]
+	}`)
+	require.NoError(t, err)
+
+	overlay := extensions.SourceOptions(ecc.Source{Name: "overlay", Policy: []string{"a", "b"}})
+	assert.Equal(t, RulePrecedenceLast, overlay.Precedence())
+	assert.Equal(t, map[string]string{"b": "org"}, overlay.RuleNamespaces)
+	assert.Equal(t, int64(50*1024*1024), overlay.BlobLimit())
+	assert.Equal(t, "https://docs.example.com/{{ .CodePackage }}", overlay.DocumentationUrl)
+	assert.Equal(t, []string{"org.policy"}, overlay.CodePrefixes)
+
+	upstream := extensions.SourceOptions(ecc.Source{Name: "upstream", Policy: []string{"a"}})
+	assert.Equal(t, RulePrecedenceError, upstream.Precedence())
+	assert.Equal(t, int64(0), upstream.BlobLimit())
+
+	assert.Equal(t, SourceOptions{}, extensions.SourceOptions(ecc.Source{Name: "overlay", Policy: []string{"b"}}))
+}
+
+func TestValidateSourceOptions(t *testing.T) {
+	assert.NoError(t, validateSourceOptions([]SourceOptions{
+		{Policy: []string{"a"}},
+		{Policy: []string{"a", "b"}, RulePrecedence: RulePrecedenceFirst, RuleNamespaces: map[string]string{"b": "org-1_x"}, BlobSizeLimit: "1G"},
+		{Policy: []string{"a"}, DocumentationUrl: "https://docs.example.com/{{ .Code }}", CodePrefixes: []string{"org", "org.policy_1"}},
+	}))
+
+	err := validateSourceOptions([]SourceOptions{
+		{Policy: []string{"a"}, RulePrecedence: "random", RuleNamespaces: map[string]string{"b": "org/x"}},
+		{Policy: []string{"a"}, BlobSizeLimit: "lots"},
+		{Policy: []string{"a"}, BlobSizeLimit: "-1Mi"},
+		{Policy: []string{"a"}, DocumentationUrl: "https://docs.example.com/{{ .Code", CodePrefixes: []string{"org..policy"}},
+		{Policy: []string{"a"}, DocumentationUrl: "https://docs.example.com/{{ .Unknown }}"},
+	})
+	assert.ErrorContains(t, err, `source group at index 0 has an invalid rulePrecedence "random", expecting one of: error, first, last`)
+	assert.ErrorContains(t, err, `source group at index 0 has a rule namespace for "b", which is not one of its policy sources`)
+	assert.ErrorContains(t, err, `source group at index 0 has an invalid rule namespace "org/x", only letters, digits, "_" and "-" are allowed`)
+	assert.ErrorContains(t, err, `source group at index 1 has an invalid blobSizeLimit "lots", expecting a positive quantity, e.g. 50Mi`)
+	assert.ErrorContains(t, err, `source group at index 2 has an invalid blobSizeLimit "-1Mi", expecting a positive quantity, e.g. 50Mi`)
+	assert.ErrorContains(t, err, `source group at index 3 has an invalid documentationUrl template: template: documentation_url:1: unclosed action`)
+	assert.ErrorContains(t, err, `source group at index 3 has an invalid code prefix "org..policy", expecting a package, e.g. org.policy`)
+	assert.ErrorContains(t, err, `source group at index 4 has an invalid documentationUrl template`)
+}
@@ -0,0 +1,297 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package tester runs the rego unit tests of policy rules the same way policies are evaluated
+// by ec, and checks that the rules carry the annotations ec relies on.
+package tester
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/open-policy-agent/opa/cover"
+	"github.com/open-policy-agent/opa/storage"
+	opatester "github.com/open-policy-agent/opa/tester"
+
+	"github.com/enterprise-contract/ec-cli/internal/evaluator"
+	"github.com/enterprise-contract/ec-cli/internal/policy/lint"
+	"github.com/enterprise-contract/ec-cli/internal/utils"
+)
+
+// Outcomes of a test.
+const (
+	OutcomePass  = "pass"
+	OutcomeFail  = "fail"
+	OutcomeError = "error"
+	OutcomeSkip  = "skip"
+)
+
+// Options of a test run.
+type Options struct {
+	// Paths of the policy rules, tests and data files, directories are loaded recursively.
+	Paths []string
+	// Run is a regular expression matching the names of the tests to run, all tests are run
+	// if empty.
+	Run string
+	// Coverage enables the line coverage report.
+	Coverage bool
+	// Timeout of each test, no timeout is applied if zero.
+	Timeout time.Duration
+}
+
+// Result is the result of a single test.
+type Result struct {
+	// Name of the test, including its package, e.g. data.policy.release.test_something.
+	Name     string `json:"name"`
+	Location string `json:"location"`
+	Outcome  string `json:"outcome"`
+	Error    string `json:"error,omitempty"`
+	// Output is the output of the print statements of a test that did not pass.
+	Output string `json:"output,omitempty"`
+}
+
+// PackageCoverage is the line coverage of the rules of a package, excluding the tests.
+type PackageCoverage struct {
+	Package         string  `json:"package"`
+	CoveredLines    int     `json:"coveredLines"`
+	NotCoveredLines int     `json:"notCoveredLines"`
+	Coverage        float64 `json:"coverage"`
+	// NotCovered lists the lines not covered, as file:line or file:start-end.
+	NotCovered []string `json:"notCovered,omitempty"`
+}
+
+// Report holds the outcome of a test run.
+type Report struct {
+	Tests []Result `json:"tests"`
+	// Coverage is set only when the coverage report is enabled.
+	Coverage []PackageCoverage `json:"coverage,omitempty"`
+	// Annotations holds the issues with the annotations of the rules, as found by
+	// lint.LintRules.
+	Annotations []lint.Issue `json:"annotations,omitempty"`
+}
+
+// Passed returns the number of tests that passed.
+func (r Report) Passed() int {
+	passed := 0
+	for _, t := range r.Tests {
+		if t.Outcome == OutcomePass {
+			passed++
+		}
+	}
+
+	return passed
+}
+
+// Failed returns the number of tests that failed or could not be evaluated.
+func (r Report) Failed() int {
+	failed := 0
+	for _, t := range r.Tests {
+		if t.Outcome == OutcomeFail || t.Outcome == OutcomeError {
+			failed++
+		}
+	}
+
+	return failed
+}
+
+// AnnotationErrors returns the number of issues with the annotations of the rules reported as
+// errors.
+func (r Report) AnnotationErrors() int {
+	errors := 0
+	for _, i := range r.Annotations {
+		if i.Severity == lint.SeverityError {
+			errors++
+		}
+	}
+
+	return errors
+}
+
+// BelowThreshold returns the packages with a line coverage below the threshold, in percent.
+func (r Report) BelowThreshold(threshold float64) []PackageCoverage {
+	var below []PackageCoverage
+	for _, c := range r.Coverage {
+		if c.Coverage < threshold {
+			below = append(below, c)
+		}
+	}
+
+	return below
+}
+
+// Run runs the tests found in the paths. The policies are compiled with the capabilities ec
+// evaluates policies with, so the rego functions provided by ec are available to the rules and
+// the tests, and the OPA functions ec does not allow, e.g. http.send, are not. An error is
+// returned only if the tests could not be run, e.g. the rules do not compile.
+func Run(ctx context.Context, opts Options) (Report, error) {
+	modules, store, err := opatester.Load(opts.Paths, nil)
+	if err != nil {
+		return Report{}, err
+	}
+
+	capabilitiesJSON, err := evaluator.Capabilities(ctx)
+	if err != nil {
+		return Report{}, err
+	}
+	capabilities, err := ast.LoadCapabilitiesJSON(strings.NewReader(capabilitiesJSON))
+	if err != nil {
+		return Report{}, err
+	}
+
+	compiler := ast.NewCompiler().
+		WithCapabilities(capabilities).
+		WithEnablePrintStatements(true)
+
+	// the runner applies the timeout to each test as is, a zero timeout would fail every test
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = time.Duration(math.MaxInt64)
+	}
+
+	runner := opatester.NewRunner().
+		SetCompiler(compiler).
+		SetStore(store).
+		SetModules(modules).
+		CapturePrintOutput(true).
+		Filter(opts.Run).
+		SetTimeout(timeout)
+
+	var coverage *cover.Cover
+	if opts.Coverage {
+		coverage = cover.New()
+		runner = runner.SetCoverageQueryTracer(coverage)
+	}
+
+	var report Report
+	err = storage.Txn(ctx, store, storage.TransactionParams{}, func(txn storage.Transaction) error {
+		ch, err := runner.RunTests(ctx, txn)
+		if err != nil {
+			return err
+		}
+
+		for r := range ch {
+			report.Tests = append(report.Tests, result(r))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return Report{}, err
+	}
+
+	policies := map[string]*ast.Module{}
+	for file, m := range modules {
+		if !isTest(file) {
+			policies[file] = m
+		}
+	}
+
+	if coverage != nil {
+		report.Coverage = packageCoverage(coverage.Report(policies), policies)
+	}
+
+	issues, err := lint.LintRules(utils.FS(ctx), opts.Paths, lint.RulesOptions{})
+	if err != nil {
+		return Report{}, err
+	}
+	report.Annotations = issues
+
+	return report, nil
+}
+
+func isTest(file string) bool {
+	return strings.HasSuffix(file, "_test.rego")
+}
+
+func result(r *opatester.Result) Result {
+	res := Result{
+		Name:    fmt.Sprintf("%s.%s", r.Package, r.Name),
+		Outcome: OutcomePass,
+	}
+	if r.Location != nil {
+		res.Location = fmt.Sprintf("%s:%d", r.Location.File, r.Location.Row)
+	}
+
+	switch {
+	case r.Skip:
+		res.Outcome = OutcomeSkip
+	case r.Error != nil:
+		res.Outcome = OutcomeError
+		res.Error = r.Error.Error()
+	case r.Fail:
+		res.Outcome = OutcomeFail
+	}
+
+	if res.Outcome != OutcomePass {
+		res.Output = string(r.Output)
+	}
+
+	return res
+}
+
+// packageCoverage aggregates the line coverage of the files by package, sorted by package.
+func packageCoverage(report cover.Report, modules map[string]*ast.Module) []PackageCoverage {
+	byPackage := map[string]*PackageCoverage{}
+	files := make([]string, 0, len(modules))
+	for file := range modules {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		pkg := modules[file].Package.Path.String()
+		c, ok := byPackage[pkg]
+		if !ok {
+			c = &PackageCoverage{Package: pkg}
+			byPackage[pkg] = c
+		}
+
+		fr, ok := report.Files[file]
+		if !ok {
+			continue
+		}
+		c.CoveredLines += fr.CoveredLines
+		c.NotCoveredLines += fr.NotCoveredLines
+		for _, r := range fr.NotCovered {
+			if r.Start.Row == r.End.Row {
+				c.NotCovered = append(c.NotCovered, fmt.Sprintf("%s:%d", file, r.Start.Row))
+			} else {
+				c.NotCovered = append(c.NotCovered, fmt.Sprintf("%s:%d-%d", file, r.Start.Row, r.End.Row))
+			}
+		}
+	}
+
+	coverage := make([]PackageCoverage, 0, len(byPackage))
+	for _, c := range byPackage {
+		if total := c.CoveredLines + c.NotCoveredLines; total > 0 {
+			c.Coverage = 100.0 * float64(c.CoveredLines) / float64(total)
+		} else {
+			c.Coverage = 100.0
+		}
+		coverage = append(coverage, *c)
+	}
+
+	sort.Slice(coverage, func(i, j int) bool {
+		return coverage[i].Package < coverage[j].Package
+	})
+
+	return coverage
+}
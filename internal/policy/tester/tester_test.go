@@ -0,0 +1,179 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unit
+
+package tester
+
+import (
+	"context"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/enterprise-contract/ec-cli/internal/policy/lint"
+)
+
+const policyRules = `package policy.release.main
+
+import rego.v1
+
+# METADATA
+# title: Version
+# description: The version is at least 1.0.0
+# custom:
+#   short_name: version
+deny contains result if {
+	ec.semver.compare(input.version, "1.0.0") < 0
+	result := {"code": "main.version", "msg": "old version"}
+}
+
+warn contains result if {
+	input.deprecated
+	result := {"msg": "deprecated"}
+}
+`
+
+func writePolicies(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(path.Join(dir, name), []byte(content), 0600))
+	}
+
+	return dir
+}
+
+func TestRun(t *testing.T) {
+	dir := writePolicies(t, map[string]string{
+		"main.rego": policyRules,
+		"main_test.rego": `package policy.release.main_test
+
+import rego.v1
+
+import data.policy.release.main
+
+test_old_version if {
+	count(main.deny) == 1 with input as {"version": "0.9.1"}
+}
+
+test_new_version if {
+	print("evaluating")
+	count(main.deny) == 1 with input as {"version": "1.2.0"}
+}
+`,
+	})
+
+	report, err := Run(context.Background(), Options{Paths: []string{dir}, Coverage: true})
+	require.NoError(t, err)
+
+	require.Len(t, report.Tests, 2)
+	assert.Equal(t, Result{
+		Name:     "data.policy.release.main_test.test_old_version",
+		Location: path.Join(dir, "main_test.rego") + ":7",
+		Outcome:  OutcomePass,
+	}, report.Tests[0])
+	assert.Equal(t, Result{
+		Name:     "data.policy.release.main_test.test_new_version",
+		Location: path.Join(dir, "main_test.rego") + ":11",
+		Outcome:  OutcomeFail,
+		Output:   "evaluating\n",
+	}, report.Tests[1])
+	assert.Equal(t, 1, report.Passed())
+	assert.Equal(t, 1, report.Failed())
+
+	assert.Equal(t, []lint.Issue{
+		{
+			File:     path.Join(dir, "main.rego"),
+			Row:      15,
+			Severity: lint.SeverityError,
+			Check:    lint.CheckMissingShortName,
+			Message:  "rule data.policy.release.main.warn has no short_name annotation, its successes are not reported and it cannot be included or excluded by its code",
+		},
+	}, report.Annotations)
+	assert.Equal(t, 1, report.AnnotationErrors())
+
+	require.Len(t, report.Coverage, 1)
+	coverage := report.Coverage[0]
+	assert.Equal(t, "data.policy.release.main", coverage.Package)
+	assert.Equal(t, []string{path.Join(dir, "main.rego") + ":15-17"}, coverage.NotCovered)
+	assert.Less(t, coverage.Coverage, 100.0)
+	assert.Equal(t, []PackageCoverage{coverage}, report.BelowThreshold(100))
+	assert.Empty(t, report.BelowThreshold(coverage.Coverage))
+}
+
+func TestRunFilter(t *testing.T) {
+	dir := writePolicies(t, map[string]string{
+		"main.rego": policyRules,
+		"main_test.rego": `package policy.release.main_test
+
+import rego.v1
+
+test_one if {
+	true
+}
+
+test_two if {
+	false
+}
+`,
+	})
+
+	report, err := Run(context.Background(), Options{Paths: []string{dir}, Run: "test_one"})
+	require.NoError(t, err)
+
+	require.Len(t, report.Tests, 1)
+	assert.Equal(t, "data.policy.release.main_test.test_one", report.Tests[0].Name)
+	assert.Nil(t, report.Coverage)
+}
+
+func TestRunNoTimeout(t *testing.T) {
+	dir := writePolicies(t, map[string]string{
+		"main.rego": policyRules,
+		"main_test.rego": `package policy.release.main_test
+
+import rego.v1
+
+test_one if {
+	true
+}
+`,
+	})
+
+	// a zero timeout means no timeout, not an immediately expired one
+	for i := 0; i < 20; i++ {
+		report, err := Run(context.Background(), Options{Paths: []string{dir}, Timeout: 0})
+		require.NoError(t, err)
+		require.Len(t, report.Tests, 1)
+		assert.Equal(t, OutcomePass, report.Tests[0].Outcome)
+	}
+}
+
+func TestRunDisallowedBuiltin(t *testing.T) {
+	dir := writePolicies(t, map[string]string{
+		"main.rego": `package policy.release.main
+
+import rego.v1
+
+response := http.send({"method": "get", "url": "https://example.com"})
+`,
+	})
+
+	_, err := Run(context.Background(), Options{Paths: []string{dir}})
+	assert.ErrorContains(t, err, "undefined function http.send")
+}
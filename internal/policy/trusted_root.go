@@ -0,0 +1,293 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package policy
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sigstore/cosign/v2/pkg/cosign"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	"github.com/sigstore/sigstore/pkg/tuf"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+
+	"github.com/enterprise-contract/ec-cli/internal/utils"
+)
+
+// TrustedRootOptions holds the locations of the Sigstore verification material to be used
+// instead of the material distributed via TUF. Each attribute is a path to a file.
+type TrustedRootOptions struct {
+	// TrustedRoot is a Sigstore trusted_root.json file.
+	TrustedRoot string
+	// CARoots is a PEM file with the root, and optionally intermediate, certificates of the
+	// certificate authority, i.e. Fulcio.
+	CARoots string
+	// CTLogPublicKey is a PEM file with the public key of the certificate transparency log.
+	CTLogPublicKey string
+	// RekorPublicKey is a PEM file with the public key of the Rekor transparency log.
+	RekorPublicKey string
+}
+
+func (o TrustedRootOptions) empty() bool {
+	return o == TrustedRootOptions{}
+}
+
+// trustedRoot holds the Sigstore verification material used when verifying signatures and
+// attestations. When present, TUF is not consulted.
+type trustedRoot struct {
+	rootCerts         *x509.CertPool
+	intermediateCerts *x509.CertPool
+	ctLogPubKeys      *cosign.TrustedTransparencyLogPubKeys
+	rekorPubKeys      *cosign.TrustedTransparencyLogPubKeys
+	tsaCerts          []*x509.Certificate
+}
+
+// trustedRootJSON is the subset of the Sigstore TrustedRoot message, as serialized in the
+// trusted_root.json file, relevant to the verification performed by ec. See:
+// https://github.com/sigstore/protobuf-specs/blob/main/protos/sigstore_trustroot.proto
+type trustedRootJSON struct {
+	MediaType              string                    `json:"mediaType"`
+	Tlogs                  []transparencyLogInstance `json:"tlogs"`
+	CertificateAuthorities []certificateAuthority    `json:"certificateAuthorities"`
+	Ctlogs                 []transparencyLogInstance `json:"ctlogs"`
+	TimestampAuthorities   []certificateAuthority    `json:"timestampAuthorities"`
+}
+
+type validityPeriod struct {
+	Start string `json:"start,omitempty"`
+	End   string `json:"end,omitempty"`
+}
+
+type transparencyLogInstance struct {
+	BaseURL   string `json:"baseUrl"`
+	PublicKey struct {
+		RawBytes []byte          `json:"rawBytes"`
+		ValidFor *validityPeriod `json:"validFor,omitempty"`
+	} `json:"publicKey"`
+}
+
+type certificateAuthority struct {
+	URI       string `json:"uri"`
+	CertChain struct {
+		Certificates []struct {
+			RawBytes []byte `json:"rawBytes"`
+		} `json:"certificates"`
+	} `json:"certChain"`
+	ValidFor *validityPeriod `json:"validFor,omitempty"`
+}
+
+// status returns the TUF status equivalent of the validity period. Material with an end date
+// in the past is considered expired, i.e. it can still be used to verify entries created
+// while it was valid.
+func (v *validityPeriod) status() tuf.StatusKind {
+	if v == nil || v.End == "" {
+		return tuf.Active
+	}
+	end, err := time.Parse(time.RFC3339, v.End)
+	if err != nil {
+		log.Warnf("unable to parse validity end %q, assuming active: %v", v.End, err)
+		return tuf.Active
+	}
+	if end.Before(now()) {
+		return tuf.Expired
+	}
+	return tuf.Active
+}
+
+// loadTrustedRoot reads the Sigstore verification material from the locations in the given
+// options. The trustedRootJSON parameter may be the contents of a trusted_root.json file, as
+// specified in the policy. It is only used when opts.TrustedRoot is not set.
+func loadTrustedRoot(ctx context.Context, opts TrustedRootOptions, trustedRootJSON string) (*trustedRoot, error) {
+	if opts.empty() && trustedRootJSON == "" {
+		return nil, nil
+	}
+
+	fs := utils.FS(ctx)
+	root := trustedRoot{}
+
+	if opts.TrustedRoot != "" {
+		data, err := afero.ReadFile(fs, opts.TrustedRoot)
+		if err != nil {
+			return nil, fmt.Errorf("reading trusted root: %w", err)
+		}
+		trustedRootJSON = string(data)
+	} else if trustedRootJSON != "" && !strings.HasPrefix(strings.TrimSpace(trustedRootJSON), "{") {
+		// The value in the policy is a path to the trusted root file
+		data, err := afero.ReadFile(fs, trustedRootJSON)
+		if err != nil {
+			return nil, fmt.Errorf("reading trusted root: %w", err)
+		}
+		trustedRootJSON = string(data)
+	}
+
+	if trustedRootJSON != "" {
+		if err := root.addTrustedRootJSON([]byte(trustedRootJSON)); err != nil {
+			return nil, err
+		}
+		log.Debug("Loaded Sigstore trusted root")
+	}
+
+	if opts.CARoots != "" {
+		data, err := afero.ReadFile(fs, opts.CARoots)
+		if err != nil {
+			return nil, fmt.Errorf("reading certificate authority roots: %w", err)
+		}
+		certs, err := cryptoutils.UnmarshalCertificatesFromPEM(data)
+		if err != nil {
+			return nil, fmt.Errorf("parsing certificate authority roots: %w", err)
+		}
+		if len(certs) == 0 {
+			return nil, fmt.Errorf("no certificates found in %s", opts.CARoots)
+		}
+		root.addCertificates(certs)
+		log.Debugf("Loaded %d certificate authority certificates from %s", len(certs), opts.CARoots)
+	}
+
+	for _, k := range []struct {
+		path string
+		keys **cosign.TrustedTransparencyLogPubKeys
+	}{
+		{opts.CTLogPublicKey, &root.ctLogPubKeys},
+		{opts.RekorPublicKey, &root.rekorPubKeys},
+	} {
+		if k.path == "" {
+			continue
+		}
+		data, err := afero.ReadFile(fs, k.path)
+		if err != nil {
+			return nil, fmt.Errorf("reading public key: %w", err)
+		}
+		if err := addPublicKey(k.keys, data, tuf.Active); err != nil {
+			return nil, fmt.Errorf("parsing public key from %s: %w", k.path, err)
+		}
+		log.Debugf("Loaded transparency log public key from %s", k.path)
+	}
+
+	return &root, nil
+}
+
+func (r *trustedRoot) addTrustedRootJSON(data []byte) error {
+	var tr trustedRootJSON
+	if err := json.Unmarshal(data, &tr); err != nil {
+		return fmt.Errorf("parsing trusted root: %w", err)
+	}
+
+	for _, ca := range tr.CertificateAuthorities {
+		certs := make([]*x509.Certificate, 0, len(ca.CertChain.Certificates))
+		for _, c := range ca.CertChain.Certificates {
+			cert, err := x509.ParseCertificate(c.RawBytes)
+			if err != nil {
+				return fmt.Errorf("parsing certificate of certificate authority %q: %w", ca.URI, err)
+			}
+			certs = append(certs, cert)
+		}
+		r.addCertificates(certs)
+	}
+
+	for _, ta := range tr.TimestampAuthorities {
+		for _, c := range ta.CertChain.Certificates {
+			cert, err := x509.ParseCertificate(c.RawBytes)
+			if err != nil {
+				return fmt.Errorf("parsing certificate of timestamp authority %q: %w", ta.URI, err)
+			}
+			r.tsaCerts = append(r.tsaCerts, cert)
+		}
+	}
+
+	for _, l := range []struct {
+		instances []transparencyLogInstance
+		keys      **cosign.TrustedTransparencyLogPubKeys
+	}{
+		{tr.Ctlogs, &r.ctLogPubKeys},
+		{tr.Tlogs, &r.rekorPubKeys},
+	} {
+		for _, i := range l.instances {
+			data := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: i.PublicKey.RawBytes})
+			if err := addPublicKey(l.keys, data, i.PublicKey.ValidFor.status()); err != nil {
+				return fmt.Errorf("parsing public key of transparency log %q: %w", i.BaseURL, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// addCertificates adds the certificate chain to the trusted root. Self-signed certificates are
+// considered roots, any other certificate is considered an intermediate.
+func (r *trustedRoot) addCertificates(certs []*x509.Certificate) {
+	for _, cert := range certs {
+		if isSelfSigned(cert) {
+			if r.rootCerts == nil {
+				r.rootCerts = x509.NewCertPool()
+			}
+			r.rootCerts.AddCert(cert)
+		} else {
+			if r.intermediateCerts == nil {
+				r.intermediateCerts = x509.NewCertPool()
+			}
+			r.intermediateCerts.AddCert(cert)
+		}
+	}
+}
+
+func isSelfSigned(cert *x509.Certificate) bool {
+	return cert.CheckSignatureFrom(cert) == nil
+}
+
+func addPublicKey(keys **cosign.TrustedTransparencyLogPubKeys, data []byte, status tuf.StatusKind) error {
+	if *keys == nil {
+		k := cosign.NewTrustedTransparencyLogPubKeys()
+		*keys = &k
+	}
+	return (*keys).AddTransparencyLogPubKey(data, status)
+}
+
+// keylessMaterial sets the certificate authority and certificate transparency log material on
+// the given CheckOpts.
+func (r *trustedRoot) keylessMaterial(opts *cosign.CheckOpts) error {
+	if r.rootCerts == nil {
+		return errors.New("trusted root does not provide any certificate authority root certificates, provide them with --ca-roots or in the trusted root")
+	}
+	opts.RootCerts = r.rootCerts
+	opts.IntermediateCerts = r.intermediateCerts
+
+	// When the CTLogPubKeys is not set cosign fetches the keys via TUF
+	if r.ctLogPubKeys == nil {
+		return errors.New("trusted root does not provide any certificate transparency log public keys, provide them with --ctlog-public-key or in the trusted root")
+	}
+	opts.CTLogPubKeys = r.ctLogPubKeys
+
+	return nil
+}
+
+// transparencyLogMaterial sets the Rekor material on the given CheckOpts.
+func (r *trustedRoot) transparencyLogMaterial(opts *cosign.CheckOpts) error {
+	// When the RekorPubKeys is not set cosign fetches the keys via TUF
+	if r.rekorPubKeys == nil {
+		return errors.New("trusted root does not provide any transparency log public keys, provide them with --rekor-public-key or in the trusted root")
+	}
+	opts.RekorPubKeys = r.rekorPubKeys
+
+	return nil
+}
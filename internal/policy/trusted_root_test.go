@@ -0,0 +1,233 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unit
+
+package policy
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"testing"
+
+	"github.com/sigstore/cosign/v2/pkg/cosign"
+	"github.com/sigstore/sigstore/pkg/tuf"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/enterprise-contract/ec-cli/internal/utils"
+)
+
+// derOf returns the base64 encoded DER bytes of the given PEM
+func derOf(t *testing.T, data string) string {
+	block, _ := pem.Decode([]byte(data))
+	require.NotNil(t, block)
+	return base64.StdEncoding.EncodeToString(block.Bytes)
+}
+
+func testTrustedRootJSON(t *testing.T, withCTLog bool) string {
+	ctlogs := ""
+	if withCTLog {
+		ctlogs = fmt.Sprintf(`, "ctlogs": [{"baseUrl": "https://ctlog.example", "publicKey": {"rawBytes": %q}}]`,
+			derOf(t, utils.TestCTLogPublicKey))
+	}
+
+	return fmt.Sprintf(`{
+		"mediaType": "application/vnd.dev.sigstore.trustedroot+json;version=0.1",
+		"tlogs": [{"baseUrl": "https://rekor.example", "publicKey": {"rawBytes": %q}}],
+		"certificateAuthorities": [{
+			"uri": "https://fulcio.example",
+			"certChain": {"certificates": [{"rawBytes": %q}, {"rawBytes": %q}]}
+		}]%s
+	}`, derOf(t, utils.TestRekorPublicKey), derOf(t, utils.TestFulcioRootCert), derOf(t, utils.TestFulcioRootIntermediate), ctlogs)
+}
+
+func TestCheckOptsWithTrustedRoot(t *testing.T) {
+	identity := cosign.Identity{Issuer: "my-issuer", Subject: "my-subject"}
+
+	cases := []struct {
+		name        string
+		files       map[string]string
+		policyRef   func(*testing.T) string
+		trustedRoot TrustedRootOptions
+		ignoreRekor bool
+		err         string
+	}{
+		{
+			name:        "trusted root file",
+			files:       map[string]string{"/trusted_root.json": testTrustedRootJSON(t, true)},
+			trustedRoot: TrustedRootOptions{TrustedRoot: "/trusted_root.json"},
+		},
+		{
+			name: "inline trusted root in policy",
+			policyRef: func(t *testing.T) string {
+				return toJson(map[string]any{"trustedRoot": testTrustedRootJSON(t, true)})
+			},
+		},
+		{
+			name:  "trusted root path in policy",
+			files: map[string]string{"/trusted_root.json": testTrustedRootJSON(t, true)},
+			policyRef: func(t *testing.T) string {
+				return `{"trustedRoot": "/trusted_root.json"}`
+			},
+		},
+		{
+			name: "PEM files",
+			files: map[string]string{
+				"/fulcio.pem": utils.TestFulcioRootCert + utils.TestFulcioRootIntermediate,
+				"/ctlog.pub":  utils.TestCTLogPublicKey,
+				"/rekor.pub":  utils.TestRekorPublicKey,
+			},
+			trustedRoot: TrustedRootOptions{
+				CARoots:        "/fulcio.pem",
+				CTLogPublicKey: "/ctlog.pub",
+				RekorPublicKey: "/rekor.pub",
+			},
+		},
+		{
+			name:        "missing certificate transparency log",
+			files:       map[string]string{"/trusted_root.json": testTrustedRootJSON(t, false)},
+			trustedRoot: TrustedRootOptions{TrustedRoot: "/trusted_root.json"},
+			err:         "trusted root does not provide any certificate transparency log public keys",
+		},
+		{
+			name: "missing transparency log",
+			files: map[string]string{
+				"/fulcio.pem": utils.TestFulcioRootCert + utils.TestFulcioRootIntermediate,
+				"/ctlog.pub":  utils.TestCTLogPublicKey,
+			},
+			trustedRoot: TrustedRootOptions{
+				CARoots:        "/fulcio.pem",
+				CTLogPublicKey: "/ctlog.pub",
+			},
+			err: "trusted root does not provide any transparency log public keys, provide them with --rekor-public-key",
+		},
+		{
+			name:        "missing certificate authority",
+			files:       map[string]string{"/rekor.pub": utils.TestRekorPublicKey},
+			trustedRoot: TrustedRootOptions{RekorPublicKey: "/rekor.pub"},
+			err:         "trusted root does not provide any certificate authority root certificates, provide them with --ca-roots",
+		},
+		{
+			name: "missing transparency log ignoring rekor",
+			files: map[string]string{
+				"/fulcio.pem": utils.TestFulcioRootCert + utils.TestFulcioRootIntermediate,
+				"/ctlog.pub":  utils.TestCTLogPublicKey,
+			},
+			trustedRoot: TrustedRootOptions{
+				CARoots:        "/fulcio.pem",
+				CTLogPublicKey: "/ctlog.pub",
+			},
+			ignoreRekor: true,
+		},
+		{
+			name:        "missing trusted root file",
+			trustedRoot: TrustedRootOptions{TrustedRoot: "/nope.json"},
+			err:         "reading trusted root",
+		},
+		{
+			name:        "invalid trusted root file",
+			files:       map[string]string{"/trusted_root.json": "{"},
+			trustedRoot: TrustedRootOptions{TrustedRoot: "/trusted_root.json"},
+			err:         "parsing trusted root",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			for name, content := range c.files {
+				require.NoError(t, afero.WriteFile(fs, name, []byte(content), 0400))
+			}
+			ctx := utils.WithFS(context.Background(), fs)
+
+			// Make sure TUF is never consulted
+			t.Setenv("TUF_ROOT", "/dev/null")
+
+			policyRef := ""
+			if c.policyRef != nil {
+				policyRef = c.policyRef(t)
+			}
+
+			p, err := NewPolicy(ctx, Options{
+				PolicyRef:     policyRef,
+				EffectiveTime: Now,
+				Identity:      identity,
+				IgnoreRekor:   c.ignoreRekor,
+				TrustedRoot:   c.trustedRoot,
+			})
+			if c.err != "" {
+				assert.ErrorContains(t, err, c.err)
+				return
+			}
+			require.NoError(t, err)
+
+			opts, err := p.CheckOpts()
+			require.NoError(t, err)
+
+			assert.NotNil(t, opts.RootCerts)
+			assert.NotNil(t, opts.IntermediateCerts)
+			assert.NotNil(t, opts.CTLogPubKeys)
+			assert.Len(t, opts.CTLogPubKeys.Keys, 1)
+			if c.ignoreRekor {
+				assert.Nil(t, opts.RekorPubKeys)
+			} else {
+				require.NotNil(t, opts.RekorPubKeys)
+				key, present := opts.RekorPubKeys.Keys[utils.TestRekorURLLogID]
+				assert.True(t, present, "Expecting specific log id based on the provided public key")
+				assert.Equal(t, tuf.Active, key.Status)
+			}
+		})
+	}
+}
+
+func TestNewCheckOptsWithTrustedRoot(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/trusted_root.json", []byte(testTrustedRootJSON(t, true)), 0400))
+	ctx := utils.WithFS(context.Background(), fs)
+
+	// Make sure TUF is never consulted
+	t.Setenv("TUF_ROOT", "/dev/null")
+
+	p, err := NewPolicy(ctx, Options{
+		EffectiveTime: Now,
+		Identity:      cosign.Identity{Subject: "my-subject", Issuer: "my-issuer"},
+		TrustedRoot:   TrustedRootOptions{TrustedRoot: "/trusted_root.json"},
+	})
+	require.NoError(t, err)
+
+	opts, err := NewCheckOpts(ctx, p, SignatureOptions{
+		Identity: cosign.Identity{Subject: "other-subject", Issuer: "other-issuer"},
+	})
+	require.NoError(t, err)
+
+	assert.NotNil(t, opts.RootCerts)
+	assert.NotNil(t, opts.IntermediateCerts)
+	assert.Len(t, opts.CTLogPubKeys.Keys, 1)
+	require.NotNil(t, opts.RekorPubKeys)
+	assert.Contains(t, opts.RekorPubKeys.Keys, utils.TestRekorURLLogID)
+}
+
+func TestValidityPeriodStatus(t *testing.T) {
+	var nilPeriod *validityPeriod
+	assert.Equal(t, tuf.Active, nilPeriod.status())
+	assert.Equal(t, tuf.Active, (&validityPeriod{Start: "2021-01-01T00:00:00Z"}).status())
+	assert.Equal(t, tuf.Expired, (&validityPeriod{End: "2021-01-01T00:00:00Z"}).status())
+	assert.Equal(t, tuf.Active, (&validityPeriod{End: "2999-01-01T00:00:00Z"}).status())
+}
@@ -0,0 +1,98 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package policy
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"strings"
+
+	"github.com/sigstore/cosign/v2/pkg/cosign"
+	"github.com/sigstore/sigstore/pkg/cryptoutils"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+
+	"github.com/enterprise-contract/ec-cli/internal/utils"
+)
+
+// loadTSACertChain reads the certificate chain of the RFC 3161 timestamp authority. The
+// certChain parameter is a path to a PEM file, while fromPolicy is either a path to a PEM file
+// or the PEM contents, as specified in the policy. The fromPolicy parameter is only used when
+// certChain is not set.
+func loadTSACertChain(ctx context.Context, certChain, fromPolicy string) ([]*x509.Certificate, error) {
+	var data []byte
+	switch {
+	case certChain != "":
+		var err error
+		if data, err = afero.ReadFile(utils.FS(ctx), certChain); err != nil {
+			return nil, fmt.Errorf("reading timestamp authority certificate chain: %w", err)
+		}
+	case strings.Contains(fromPolicy, "-----BEGIN CERTIFICATE-----"):
+		data = []byte(fromPolicy)
+	case fromPolicy != "":
+		var err error
+		if data, err = afero.ReadFile(utils.FS(ctx), fromPolicy); err != nil {
+			return nil, fmt.Errorf("reading timestamp authority certificate chain: %w", err)
+		}
+	default:
+		return nil, nil
+	}
+
+	certs, err := cryptoutils.UnmarshalCertificatesFromPEM(data)
+	if err != nil {
+		return nil, fmt.Errorf("parsing timestamp authority certificate chain: %w", err)
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no certificates found in the timestamp authority certificate chain")
+	}
+	log.Debugf("Loaded %d timestamp authority certificates", len(certs))
+
+	return certs, nil
+}
+
+// timestampAuthorityMaterial sets the certificates used to verify RFC 3161 signed timestamps on
+// the given CheckOpts. Certificates that are not certificate authorities are considered to be
+// the certificate used to sign the timestamps. When there is not exactly one such certificate,
+// cosign relies on the certificate embedded in the timestamp instead.
+func timestampAuthorityMaterial(opts *cosign.CheckOpts, certs []*x509.Certificate) error {
+	if len(certs) == 0 {
+		return nil
+	}
+
+	var leaves []*x509.Certificate
+	for _, cert := range certs {
+		switch {
+		case !cert.IsCA:
+			leaves = append(leaves, cert)
+		case isSelfSigned(cert):
+			opts.TSARootCertificates = append(opts.TSARootCertificates, cert)
+		default:
+			opts.TSAIntermediateCertificates = append(opts.TSAIntermediateCertificates, cert)
+		}
+	}
+
+	if len(opts.TSARootCertificates) == 0 {
+		return fmt.Errorf("timestamp authority certificate chain does not contain a root certificate")
+	}
+
+	if len(leaves) == 1 {
+		opts.TSACertificate = leaves[0]
+	}
+
+	return nil
+}
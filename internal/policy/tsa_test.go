@@ -0,0 +1,187 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unit
+
+package policy
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/enterprise-contract/ec-cli/internal/utils"
+)
+
+// testTSAChain creates a root certificate and a timestamping certificate issued by it
+func testTSAChain(t *testing.T) (root, leaf *x509.Certificate) {
+	rootKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	rootTemplate := x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "tsa root"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	rootDER, err := x509.CreateCertificate(rand.Reader, &rootTemplate, &rootTemplate, rootKey.Public(), rootKey)
+	require.NoError(t, err)
+	root, err = x509.ParseCertificate(rootDER)
+	require.NoError(t, err)
+
+	leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	leafTemplate := x509.Certificate{
+		SerialNumber:          big.NewInt(2),
+		Subject:               pkix.Name{CommonName: "tsa"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		BasicConstraintsValid: true,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageTimeStamping},
+	}
+	leafDER, err := x509.CreateCertificate(rand.Reader, &leafTemplate, root, leafKey.Public(), rootKey)
+	require.NoError(t, err)
+	leaf, err = x509.ParseCertificate(leafDER)
+	require.NoError(t, err)
+
+	return
+}
+
+func toPEM(certs ...*x509.Certificate) string {
+	var out []byte
+	for _, c := range certs {
+		out = append(out, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.Raw})...)
+	}
+	return string(out)
+}
+
+func TestCheckOptsWithTimestampAuthority(t *testing.T) {
+	root, leaf := testTSAChain(t)
+
+	cases := []struct {
+		name         string
+		files        map[string]string
+		policyRef    string
+		tsaCertChain string
+		trustedRoot  TrustedRootOptions
+		expectLeaf   bool
+		err          string
+	}{
+		{
+			name:         "certificate chain file",
+			files:        map[string]string{"/tsa.pem": toPEM(leaf, root)},
+			tsaCertChain: "/tsa.pem",
+			expectLeaf:   true,
+		},
+		{
+			name:         "root only",
+			files:        map[string]string{"/tsa.pem": toPEM(root)},
+			tsaCertChain: "/tsa.pem",
+		},
+		{
+			name:       "inline certificate chain in policy",
+			policyRef:  toJson(map[string]any{"publicKey": utils.TestPublicKey, "tsaCertChain": toPEM(leaf, root)}),
+			expectLeaf: true,
+		},
+		{
+			name:       "certificate chain path in policy",
+			files:      map[string]string{"/tsa.pem": toPEM(leaf, root)},
+			policyRef:  toJson(map[string]any{"publicKey": utils.TestPublicKey, "tsaCertChain": "/tsa.pem"}),
+			expectLeaf: true,
+		},
+		{
+			name: "trusted root",
+			files: map[string]string{"/trusted_root.json": fmt.Sprintf(`{
+				"tlogs": [{"publicKey": {"rawBytes": %q}}],
+				"timestampAuthorities": [{"uri": "https://tsa.example", "certChain": {"certificates": [{"rawBytes": %q}, {"rawBytes": %q}]}}]
+			}`, derOf(t, utils.TestRekorPublicKey), base64.StdEncoding.EncodeToString(leaf.Raw), base64.StdEncoding.EncodeToString(root.Raw))},
+			trustedRoot: TrustedRootOptions{TrustedRoot: "/trusted_root.json"},
+			expectLeaf:  true,
+		},
+		{
+			name:         "missing root",
+			files:        map[string]string{"/tsa.pem": toPEM(leaf)},
+			tsaCertChain: "/tsa.pem",
+			err:          "timestamp authority certificate chain does not contain a root certificate",
+		},
+		{
+			name:         "no certificates",
+			files:        map[string]string{"/tsa.pem": ""},
+			tsaCertChain: "/tsa.pem",
+			err:          "no certificates found in the timestamp authority certificate chain",
+		},
+		{
+			name:         "missing file",
+			tsaCertChain: "/tsa.pem",
+			err:          "reading timestamp authority certificate chain",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			for name, content := range c.files {
+				require.NoError(t, afero.WriteFile(fs, name, []byte(content), 0400))
+			}
+			ctx := utils.WithFS(context.Background(), fs)
+			utils.SetTestRekorPublicKey(t)
+
+			publicKey := ""
+			if c.policyRef == "" {
+				publicKey = utils.TestPublicKey
+			}
+
+			p, err := NewPolicy(ctx, Options{
+				PolicyRef:     c.policyRef,
+				PublicKey:     publicKey,
+				EffectiveTime: Now,
+				TrustedRoot:   c.trustedRoot,
+				TSACertChain:  c.tsaCertChain,
+			})
+			if c.err != "" {
+				assert.ErrorContains(t, err, c.err)
+				return
+			}
+			require.NoError(t, err)
+
+			opts, err := p.CheckOpts()
+			require.NoError(t, err)
+
+			assert.Equal(t, []*x509.Certificate{root}, opts.TSARootCertificates)
+			assert.Empty(t, opts.TSAIntermediateCertificates)
+			if c.expectLeaf {
+				assert.Equal(t, leaf, opts.TSACertificate)
+			} else {
+				assert.Nil(t, opts.TSACertificate)
+			}
+		})
+	}
+}
@@ -19,10 +19,17 @@ package signature
 import (
 	"encoding/hex"
 	"encoding/pem"
+	"fmt"
+	"time"
 
+	"github.com/digitorus/timestamp"
 	"github.com/sigstore/cosign/v2/pkg/oci"
 )
 
+// timestampMetadataKey is the key of the EntitySignature metadata holding the time from the
+// RFC 3161 signed timestamp.
+const timestampMetadataKey = "RFC3161 Timestamp"
+
 type EntitySignature struct {
 	KeyID       string            `json:"keyid"`
 	Signature   string            `json:"sig"`
@@ -72,5 +79,31 @@ func NewEntitySignature(sig oci.Signature) (EntitySignature, error) {
 			Bytes: c.Raw,
 		})))
 	}
+
+	if err := addTimestampMetadataTo(&es.Metadata, sig); err != nil {
+		return EntitySignature{}, err
+	}
+
 	return es, nil
 }
+
+// addTimestampMetadataTo adds the time from the RFC 3161 signed timestamp of the Signature, if
+// any, to the metadata. The timestamp is not verified here. Signatures are expected to have been
+// verified by cosign which rejects signatures with timestamps it is unable to verify.
+func addTimestampMetadataTo(where *map[string]string, sig oci.Signature) error {
+	ts, err := sig.RFC3161Timestamp()
+	if err != nil {
+		return err
+	}
+	if ts == nil || len(ts.SignedRFC3161Timestamp) == 0 {
+		return nil
+	}
+
+	t, err := timestamp.ParseResponse(ts.SignedRFC3161Timestamp)
+	if err != nil {
+		return fmt.Errorf("parsing RFC 3161 timestamp: %w", err)
+	}
+	(*where)[timestampMetadataKey] = t.Time.UTC().Format(time.RFC3339)
+
+	return nil
+}
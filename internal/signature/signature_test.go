@@ -0,0 +1,87 @@
+// Copyright The Enterprise Contract Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build unit
+
+package signature
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/digitorus/timestamp"
+	"github.com/sigstore/cosign/v2/pkg/cosign/bundle"
+	"github.com/sigstore/cosign/v2/pkg/oci/static"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewEntitySignatureWithTimestamp(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "tsa"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageTimeStamping},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, key.Public(), key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	sig := []byte("signature")
+	digest := sha256.Sum256(sig)
+	ts := timestamp.Timestamp{
+		HashAlgorithm: crypto.SHA256,
+		HashedMessage: digest[:],
+		Time:          time.Date(2023, 11, 1, 10, 20, 30, 0, time.UTC),
+		Policy:        []int{1, 2, 3},
+		Certificates:  []*x509.Certificate{cert},
+	}
+	response, err := ts.CreateResponse(cert, key)
+	require.NoError(t, err)
+
+	signature, err := static.NewSignature([]byte(`image`), "c2lnbmF0dXJl",
+		static.WithRFC3161Timestamp(&bundle.RFC3161Timestamp{SignedRFC3161Timestamp: response}))
+	require.NoError(t, err)
+
+	es, err := NewEntitySignature(signature)
+	require.NoError(t, err)
+
+	assert.Equal(t, "2023-11-01T10:20:30Z", es.Metadata["RFC3161 Timestamp"])
+}
+
+func TestNewEntitySignatureWithInvalidTimestamp(t *testing.T) {
+	signature, err := static.NewSignature([]byte(`image`), "c2lnbmF0dXJl",
+		static.WithRFC3161Timestamp(&bundle.RFC3161Timestamp{SignedRFC3161Timestamp: []byte("bogus")}))
+	require.NoError(t, err)
+
+	_, err = NewEntitySignature(signature)
+	assert.ErrorContains(t, err, "parsing RFC 3161 timestamp")
+}